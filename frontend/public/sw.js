@@ -0,0 +1,37 @@
+// Service worker for VedSagar alerts delivered over Web Push. The
+// notification service sends JSON: title, body, event_id, notification_id,
+// severity, url and, for alerts that ask to be acknowledged, ack_url.
+
+self.addEventListener("push", (event) => {
+  let alert = {};
+  try {
+    alert = event.data ? event.data.json() : {};
+  } catch {
+    alert = { body: event.data ? event.data.text() : "" };
+  }
+  const urgent = alert.severity === "extreme" || alert.severity === "severe";
+  event.waitUntil(
+    self.registration.showNotification(alert.title || "VedSagar alert", {
+      body: alert.body,
+      tag: alert.event_id, // an update replaces the earlier alert
+      renotify: Boolean(alert.event_id),
+      requireInteraction: urgent,
+      actions: alert.ack_url ? [{ action: "ack", title: "I got this alert" }] : [],
+      data: { url: alert.url || "/", ackUrl: alert.ack_url },
+    })
+  );
+});
+
+self.addEventListener("notificationclick", (event) => {
+  event.notification.close();
+  const { url, ackUrl } = event.notification.data;
+  if (event.action === "ack" && ackUrl) {
+    event.waitUntil(
+      fetch(ackUrl, { method: "POST", headers: { Accept: "application/json" } }).catch(() =>
+        self.clients.openWindow(ackUrl)
+      )
+    );
+    return;
+  }
+  event.waitUntil(self.clients.openWindow(url));
+});
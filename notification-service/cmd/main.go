@@ -2,46 +2,210 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
+	"notification-service/internal/ack"
 	"notification-service/internal/api"
+	"notification-service/internal/config"
+	"notification-service/internal/dispatcher/channels"
+	"notification-service/internal/metrics"
 	"notification-service/internal/processor"
+	"notification-service/internal/resolver"
+	"notification-service/internal/smsenc"
+	"notification-service/internal/storage"
+	memorystore "notification-service/internal/storage/memory"
 	redisstore "notification-service/internal/storage/redis"
+	sqlstore "notification-service/internal/storage/sql"
+	"notification-service/internal/templating"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
+// stores groups the storage interfaces the service needs; a backend may
+// implement several of them with one value.
+type stores struct {
+	notifs storage.NotificationStore
+	events storage.EventStore
+	queue  storage.EventQueue
+
+	locations  storage.LocationStore
+	recipients storage.RecipientStore
+	subs       storage.SubscriptionStore
+	templates  storage.TemplateStore
+	webhooks   storage.WebhookStore
+
+	suppressions storage.SuppressionStore
+	reports      storage.ReportStore
+	acks         storage.AckStore
+
+	closers []func(context.Context) error
+}
+
+func (s stores) close() {
+	for _, c := range s.closers {
+		_ = c(context.Background())
+	}
+}
+
 func main() {
 	_ = godotenv.Load()
+	cfg := config.Load()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	// Update config usage to full Redis URL (future update)
-	store, err := redisstore.NewRedisStore(ctx, redisstore.Config{
-		Addr:     os.Getenv("REDIS_ADDR"),
-		Username: os.Getenv("REDIS_USERNAME"),
-		Password: os.Getenv("REDIS_PASSWORD"),
-		UseTLS:   false,
-	})
+	st, err := openStores(ctx, cfg)
 	if err != nil {
-		log.Fatalf("redis init: %v", err)
+		log.Fatalf("storage init: %v", err)
 	}
-	defer store.Close(ctx)
+	defer st.close()
 
-	// Initialize dispatcher in processor package
-	processor.Init(store)
+	// Initialize dispatcher and intake queue in processor package
+	processor.Init(st.notifs, st.queue, st.events)
+	processor.Disp().SetDirectory(st.recipients)
+	processor.Disp().SetConcurrency(cfg.DispatchConcurrency)
+	templates := templating.NewEngine(st.templates)
+	processor.Disp().SetTemplates(templates)
+	smsPolicy, err := smsenc.ParsePolicy(cfg.SMSLengthPolicy, cfg.SMSMaxSegments)
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	processor.Disp().SetSMSPolicy(smsPolicy)
+	processor.Disp().SetSuppressions(st.suppressions)
+	escalation, err := ack.ParsePolicies(cfg.EscalationPolicy)
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	ackLinks := ack.NewSigner(cfg.AckSecret, cfg.PublicBaseURL)
+	processor.Disp().SetAcknowledgements(st.acks, ackLinks, escalation)
+	webPush := channels.NewWebPushHandler(st.recipients, cfg.WebPushAllowInternal)
+	processor.Disp().Register("webpush", webPush)
+	processor.Disp().Register("webhook", channels.NewWebhookHandler(st.webhooks, st.events, cfg.WebhookAllowInternal))
+	mqttHandler := channels.NewMQTTHandler(st.events)
+	defer mqttHandler.Close()
+	processor.Disp().Register("mqtt", mqttHandler)
+
+	// Resolve recipients inside an event's target areas before dispatch
+	processor.Use(resolver.NewGeoResolver(st.locations))
+	// Address partner endpoints on the webhook channel
+	processor.Use(resolver.NewWebhookFanout(st.webhooks))
+	// Then drop recipients who have not subscribed to this kind of alert
+	processor.Use(resolver.NewSubscriptionFilter(st.subs, cfg.LifeSafetySeverities))
+
+	// Drain the intake queue in the background
+	processor.StartEventConsumers(ctx, st.queue, cfg.EventWorkers, cfg.EventClaimIdle)
 
 	// Start retry worker with 1-min polls
-	processor.StartRetryWorker(ctx, store, processor.Disp(), time.Minute)
+	processor.StartRetryWorker(ctx, st.notifs, processor.Disp(), time.Minute)
 
 	r := gin.Default()
+	r.Use(api.CORSMiddleware(cfg.CORSAllowedOrigins))
 	r.POST("/events", api.HandleEvent)
-	r.GET("/health", api.HealthCheckHandler(store))
+	r.POST("/events/cap", api.HandleCAPEvent(cfg.CAPDefaultChannels, cfg.CAPTestRecipients))
+	r.GET("/events/:id", api.GetEventHandler(st.events, st.notifs))
+	r.GET("/events/:id/notifications", api.ListEventNotificationsHandler(st.events, st.notifs))
+	r.GET("/events/:id/reports", api.ListEventReportsHandler(st.reports))
+	r.GET("/events/:id/unacknowledged", api.UnacknowledgedHandler(st.notifs, st.acks))
+	r.GET(ack.LinkPath+":token", api.AckPageHandler(ackLinks))
+	r.POST(ack.LinkPath+":token", api.AckHandler(ackLinks))
+	r.GET("/notifications/:id", api.GetNotificationHandler(st.notifs))
+	r.GET("/webpush/vapid-public-key", api.VAPIDPublicKeyHandler(webPush.PublicKey()))
+	// the directory holds personal contact details and locations, and
+	// templates and suppressions decide what is sent to whom
+	admin := api.AdminTokenMiddleware(cfg.AdminToken)
+	recipientAdmin := r.Group("/recipients", admin)
+	recipientAdmin.POST("", api.CreateRecipientHandler(st.recipients))
+	recipientAdmin.GET("", api.ListRecipientsHandler(st.recipients))
+	recipientAdmin.GET("/:id", api.GetRecipientHandler(st.recipients))
+	recipientAdmin.PUT("/:id", api.PutRecipientHandler(st.recipients))
+	recipientAdmin.DELETE("/:id", api.DeleteRecipientHandler(st.recipients))
+	recipientAdmin.POST("/:id/subscriptions", api.CreateSubscriptionHandler(st.recipients, st.subs))
+	recipientAdmin.GET("/:id/subscriptions", api.ListSubscriptionsHandler(st.subs))
+	recipientAdmin.PUT("/:id/subscriptions/:sub_id", api.PutSubscriptionHandler(st.subs))
+	recipientAdmin.DELETE("/:id/subscriptions/:sub_id", api.DeleteSubscriptionHandler(st.subs))
+	recipientAdmin.PUT("/:id/location", api.SetLocationHandler(st.locations))
+	recipientAdmin.GET("/:id/location", api.GetLocationHandler(st.locations))
+	recipientAdmin.DELETE("/:id/location", api.DeleteLocationHandler(st.locations))
+	recipientAdmin.POST("/:id/webpush", api.SubscribeWebPushHandler(st.recipients, cfg.WebPushAllowInternal))
+	recipientAdmin.GET("/:id/webpush", api.ListWebPushHandler(st.recipients))
+	recipientAdmin.DELETE("/:id/webpush", api.UnsubscribeWebPushHandler(st.recipients))
+	webhookAdmin := r.Group("/webhooks", admin)
+	webhookAdmin.POST("", api.CreateWebhookHandler(st.webhooks, cfg.WebhookAllowInternal))
+	webhookAdmin.GET("", api.ListWebhooksHandler(st.webhooks))
+	webhookAdmin.GET("/:id", api.GetWebhookHandler(st.webhooks))
+	webhookAdmin.PUT("/:id", api.PutWebhookHandler(st.webhooks, cfg.WebhookAllowInternal))
+	webhookAdmin.DELETE("/:id", api.DeleteWebhookHandler(st.webhooks))
+	webhookAdmin.POST("/:id/rotate-secret", api.RotateWebhookSecretHandler(st.webhooks))
+	templateAdmin := r.Group("/templates", admin)
+	templateAdmin.POST("", api.CreateTemplateHandler(st.templates))
+	templateAdmin.GET("", api.ListTemplatesHandler(st.templates))
+	templateAdmin.GET("/:name", api.GetTemplateHandler(st.templates))
+	templateAdmin.GET("/:name/versions", api.ListTemplateVersionsHandler(st.templates))
+	templateAdmin.POST("/:name/preview", api.PreviewTemplateHandler(templates))
+	r.GET("/suppressions", admin, api.ListSuppressionsHandler(st.suppressions))
+	twilioHooks := r.Group("/", api.TwilioSignatureMiddleware(cfg.TwilioAuthToken, cfg.PublicBaseURL))
+	twilioHooks.POST(channels.VoiceGatherPath, api.VoiceGatherHandler())
+	twilioHooks.POST(channels.VoiceStatusPath, api.VoiceStatusHandler())
+	twilioHooks.POST(channels.SMSStatusPath, api.SMSStatusHandler())
+	twilioHooks.POST("/sms/inbound", api.InboundSMSHandler(st.suppressions, st.reports, cfg.SMSHelpText))
+	r.GET("/health", api.HealthCheckHandler(st.notifs))
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("server failed to start: %v", err)
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("server failed to start: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("shutting down")
+	shutdownCtx, stop := context.WithTimeout(context.Background(), 10*time.Second)
+	defer stop()
+	_ = srv.Shutdown(shutdownCtx)
+}
+
+func openStores(ctx context.Context, cfg config.Config) (stores, error) {
+	switch cfg.StoreBackend {
+	case "memory":
+		log.Println("using in-memory storage; data is lost on restart")
+		mem := memorystore.New()
+		return stores{notifs: mem, events: mem, queue: mem, locations: mem, recipients: mem, subs: mem, templates: mem, webhooks: mem, suppressions: mem, reports: mem, acks: mem}, nil
+	case "redis":
+		rs, err := openRedis(ctx)
+		if err != nil {
+			return stores{}, err
+		}
+		return stores{notifs: rs, events: rs, queue: rs, locations: rs, recipients: rs, subs: rs, templates: rs, webhooks: rs, suppressions: rs, reports: rs, acks: rs, closers: []func(context.Context) error{rs.Close}}, nil
+	case "sqlite", "postgres":
+		db, err := sqlstore.NewSQLStore(ctx, sqlstore.Config{Dialect: cfg.StoreBackend, DSN: cfg.DatabaseURL})
+		if err != nil {
+			return stores{}, err
+		}
+		// the intake queue and recipient data stay on Redis
+		rs, err := openRedis(ctx)
+		if err != nil {
+			db.Close(ctx)
+			return stores{}, err
+		}
+		return stores{notifs: db, events: db, queue: rs, locations: rs, recipients: rs, subs: rs, templates: rs, webhooks: rs, suppressions: rs, reports: rs, acks: rs, closers: []func(context.Context) error{db.Close, rs.Close}}, nil
+	default:
+		return stores{}, errors.New("unknown STORE_BACKEND " + cfg.StoreBackend)
 	}
 }
+
+func openRedis(ctx context.Context) (*redisstore.RedisStore, error) {
+	// Update config usage to full Redis URL (future update)
+	return redisstore.NewRedisStore(ctx, redisstore.Config{
+		Addr:     os.Getenv("REDIS_ADDR"),
+		Username: os.Getenv("REDIS_USERNAME"),
+		Password: os.Getenv("REDIS_PASSWORD"),
+		UseTLS:   false,
+	})
+}
@@ -0,0 +1,78 @@
+package ack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Step sends a channel once After has passed since dispatch without the
+// recipient acknowledging the alert.
+type Step struct {
+	Channel string
+	After   time.Duration
+}
+
+// Policy is the escalation of one severity, in the order configured.
+type Policy []Step
+
+// Delay reports how long after dispatch the policy sends a channel; ok is
+// false for channels the policy does not name, which are sent right away.
+func (p Policy) Delay(channel string) (delay time.Duration, ok bool) {
+	for _, s := range p {
+		if s.Channel == channel {
+			return s.After, true
+		}
+	}
+	return 0, false
+}
+
+// Policies maps event severities to their escalation. Alerts of a severity
+// with a policy ask recipients to acknowledge them.
+type Policies map[string]Policy
+
+// For returns the policy of a severity, nil when it has none.
+func (p Policies) For(severity string) Policy {
+	return p[strings.ToLower(severity)]
+}
+
+// ParsePolicies reads policies of the form
+//
+//	extreme=push,sms@5m,voice@15m;severe=push,sms@10m
+//
+// A channel without a delay is sent right away.
+func ParsePolicies(s string) (Policies, error) {
+	out := Policies{}
+	for _, clause := range strings.Split(s, ";") {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			continue
+		}
+		severity, steps, ok := strings.Cut(clause, "=")
+		severity = strings.ToLower(strings.TrimSpace(severity))
+		if !ok || severity == "" {
+			return nil, fmt.Errorf("escalation policy %q: want severity=channel[@delay],...", clause)
+		}
+		var policy Policy
+		for _, step := range strings.Split(steps, ",") {
+			channel, after, delayed := strings.Cut(strings.TrimSpace(step), "@")
+			if channel == "" {
+				return nil, fmt.Errorf("escalation policy for %s: empty channel", severity)
+			}
+			s := Step{Channel: channel}
+			if delayed {
+				d, err := time.ParseDuration(after)
+				if err != nil || d < 0 {
+					return nil, fmt.Errorf("escalation policy for %s: invalid delay %q", severity, after)
+				}
+				s.After = d
+			}
+			if _, dup := policy.Delay(channel); dup {
+				return nil, fmt.Errorf("escalation policy for %s: %s named twice", severity, channel)
+			}
+			policy = append(policy, s)
+		}
+		out[severity] = policy
+	}
+	return out, nil
+}
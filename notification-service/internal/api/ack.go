@@ -0,0 +1,165 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"notification-service/internal/ack"
+	"notification-service/internal/processor"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AckPageHandler serves GET /ack/:token, the signed link in SMS and email
+// alerts. It only shows a confirmation button that posts back: mail and SMS
+// link scanners fetch every URL in a message, and must not acknowledge on
+// the recipient's behalf.
+func AckPageHandler(links *ack.Signer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := ackNotification(c, links); !ok {
+			return
+		}
+		ackPage(c, http.StatusOK, `<p>Please confirm that you received this alert.</p>`+
+			`<form method="post"><button type="submit">I received this alert</button></form>`)
+	}
+}
+
+// AckHandler serves POST /ack/:token: the confirmation page's button, and
+// the acknowledge action of push notifications. It answers with a page or,
+// when the client asks for it, JSON.
+func AckHandler(links *ack.Signer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		notif, ok := ackNotification(c, links)
+		if !ok {
+			return
+		}
+		via := "link"
+		if notif.Channel == "push" || notif.Channel == "webpush" {
+			via = "push action"
+		}
+		if notif.Status != "acknowledged" && !processor.Disp().Acknowledge(c.Request.Context(), *notif, via) {
+			if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEJSON {
+				c.JSON(http.StatusConflict, gin.H{"error": "this alert is no longer active"})
+				return
+			}
+			ackPage(c, http.StatusConflict, `<p>This alert is no longer active. No confirmation is needed.</p>`)
+			return
+		}
+		if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEJSON {
+			c.JSON(http.StatusOK, gin.H{"notification_id": notif.ID, "status": "acknowledged"})
+			return
+		}
+		ackPage(c, http.StatusOK, `<p>Thank you. Your confirmation has been recorded. Stay safe.</p>`)
+	}
+}
+
+// ackNotification loads the notification an ack token was issued for,
+// writing the error response when the token is invalid or unknown.
+func ackNotification(c *gin.Context, links *ack.Signer) (*models.Notification, bool) {
+	if links == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "acknowledgement links are not configured"})
+		return nil, false
+	}
+	id, ok := links.Verify(c.Param("token"))
+	if !ok {
+		ackPage(c, http.StatusNotFound, `<p>This confirmation link is not valid.</p>`)
+		return nil, false
+	}
+	notif, err := processor.Disp().Store().GetNotification(c.Request.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		ackPage(c, http.StatusNotFound, `<p>This alert is no longer on record.</p>`)
+		return nil, false
+	}
+	if err != nil {
+		respondStoreError(c, err)
+		return nil, false
+	}
+	return notif, true
+}
+
+func ackPage(c *gin.Context, status int, body string) {
+	c.Header("Cache-Control", "no-store")
+	c.Data(status, "text/html; charset=utf-8", []byte(`<!doctype html><html><head><meta charset="utf-8">`+
+		`<meta name="viewport" content="width=device-width, initial-scale=1"><title>VedSagar alert</title>`+
+		`</head><body>`+body+`</body></html>`))
+}
+
+// unackedRecipient is a recipient who has not acknowledged an event, with
+// the notifications sent or scheduled to them.
+type unackedRecipient struct {
+	Recipient     string                `json:"recipient"`
+	Notifications []unackedNotification `json:"notifications"`
+}
+
+type unackedNotification struct {
+	ID      string `json:"id"`
+	Channel string `json:"channel"`
+	Address string `json:"address"`
+	Status  string `json:"status"`
+}
+
+// ackChannels reach a person who can acknowledge; partner systems and
+// sirens on the other channels cannot.
+var ackChannels = map[string]bool{
+	"sms": true, "email": true, "push": true, "webpush": true, "voice": true,
+}
+
+// UnacknowledgedHandler serves GET /events/:id/unacknowledged: the event's
+// recipients who have not acknowledged it, with where each notification to
+// them stands, and the acknowledgements received so far.
+func UnacknowledgedHandler(notifs storage.NotificationStore, acks storage.AckStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		eventID := c.Param("id")
+		received, err := acks.ListAcks(ctx, eventID)
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		acked := map[string]bool{}
+		for _, a := range received {
+			acked[a.Recipient] = true
+		}
+
+		byRecipient := map[string]*unackedRecipient{}
+		const pageSize = 500
+		for offset := 0; ; offset += pageSize {
+			page, total, err := notifs.ListNotificationsByEvent(ctx, eventID, offset, pageSize)
+			if err != nil {
+				respondStoreError(c, err)
+				return
+			}
+			for _, n := range page {
+				key := n.RecipientKey()
+				if !ackChannels[n.Channel] || acked[key] {
+					continue
+				}
+				r := byRecipient[key]
+				if r == nil {
+					r = &unackedRecipient{Recipient: key}
+					byRecipient[key] = r
+				}
+				r.Notifications = append(r.Notifications, unackedNotification{
+					ID: n.ID, Channel: n.Channel, Address: n.Recipient, Status: n.Status,
+				})
+			}
+			if len(page) == 0 || offset+pageSize >= total {
+				break
+			}
+		}
+
+		out := make([]unackedRecipient, 0, len(byRecipient))
+		for _, r := range byRecipient {
+			out = append(out, *r)
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Recipient < out[j].Recipient })
+		c.JSON(http.StatusOK, gin.H{
+			"event_id":       eventID,
+			"unacknowledged": out,
+			"acknowledged":   received,
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"notification-service/internal/ack"
+	"notification-service/internal/processor"
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAckHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	links := ack.NewSigner("secret", "https://alerts.example")
+	tests := []struct {
+		name   string
+		status string
+		token  string
+		code   int
+		want   string // notification status afterwards
+	}{
+		{"delivered", "success", "", http.StatusOK, "acknowledged"},
+		{"already acknowledged", "acknowledged", "", http.StatusOK, "acknowledged"},
+		{"scheduled escalation", "scheduled", "", http.StatusConflict, "scheduled"},
+		{"never sent", "pending", "", http.StatusConflict, "pending"},
+		{"cancelled", "cancelled", "", http.StatusConflict, "cancelled"},
+		{"tampered token", "success", "notif-1.AAAAAAAAAAAAAAAA", http.StatusNotFound, "success"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mem := memorystore.New()
+			processor.Init(mem, mem, mem)
+			processor.Disp().SetAcknowledgements(mem, links, ack.Policies{"extreme": {{Channel: "sms"}}})
+			if err := mem.SaveNotification(ctx, models.Notification{
+				ID: "notif-1", EventID: "evt-1", Recipient: "+911234567890", Channel: "sms",
+				Severity: "extreme", Status: tt.status, Timestamp: time.Now(),
+			}); err != nil {
+				t.Fatalf("SaveNotification: %v", err)
+			}
+			token := tt.token
+			if token == "" {
+				token = links.Token("notif-1")
+			}
+
+			r := gin.New()
+			r.POST(ack.LinkPath+":token", AckHandler(links))
+			req := httptest.NewRequest(http.MethodPost, ack.LinkPath+token, nil)
+			req.Header.Set("Accept", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+
+			if got, _ := mem.GetNotification(ctx, "notif-1"); got.Status != tt.want {
+				t.Errorf("status = %s, want %s", got.Status, tt.want)
+			}
+			wantAck := tt.want == "acknowledged" && tt.status != "acknowledged"
+			if acked, _ := mem.IsAcknowledged(ctx, "evt-1", "+911234567890"); acked != wantAck {
+				t.Errorf("ack recorded = %v, want %v", acked, wantAck)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"notification-service/internal/cap"
+	"notification-service/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleCAPEvent accepts a CAP 1.2 XML document on POST /events/cap.
+// Update and Cancel messages amend the referenced alerts and return 200.
+// CAP carries no delivery channels, so they come from the "channels" query
+// parameter (comma-separated) or defaultChannels. Recipients come from the
+// alert's <addresses> plus the "recipients" query parameter.
+//
+// Test and Exercise alerts are not for the public: they go only to
+// testRecipients, whatever the alert targets, and are rejected when there
+// are none. Their broadcast channels are dropped, as those reach partner
+// endpoints and sirens rather than the test recipients.
+func HandleCAPEvent(defaultChannels, testRecipients []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		alert, err := cap.Parse(c.Request.Body)
+		if err != nil {
+			var verr *cap.ValidationError
+			if errors.As(err, &verr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid CAP alert", "details": verr.Problems})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		switch {
+		case alert.Status == "Draft" || alert.Status == "System":
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "CAP status " + alert.Status + " is not dispatched"})
+			return
+		case (alert.Status == "Test" || alert.Status == "Exercise") && len(testRecipients) == 0:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "CAP status " + alert.Status + " is not dispatched without CAP_TEST_RECIPIENTS"})
+			return
+		case alert.MsgType == "Ack" || alert.MsgType == "Error":
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "CAP msgType " + alert.MsgType + " is not supported"})
+			return
+		}
+
+		event, err := alert.ToEvent()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if alert.MsgType == "Update" || alert.MsgType == "Cancel" {
+			event.SendAllClear = c.Query("all_clear") == "true" || alert.HasResponseType("AllClear")
+			if err := processor.SubmitEvent(c.Request.Context(), &event); err != nil {
+				c.JSON(submitErrorStatus(err, http.StatusUnprocessableEntity), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"status":     "applied",
+				"event_id":   event.ID,
+				"references": event.References,
+			})
+			return
+		}
+		event.Channels = splitList(c.Query("channels"))
+		if len(event.Channels) == 0 {
+			event.Channels = defaultChannels
+		}
+		event.Recipients = append(event.Recipients, splitList(c.Query("recipients"))...)
+		if alert.Status == "Test" || alert.Status == "Exercise" {
+			event.Recipients = append([]string(nil), testRecipients...)
+			event.Areas = nil
+			event.Channels = slices.DeleteFunc(slices.Clone(event.Channels), func(ch string) bool {
+				return slices.Contains(broadcastChannels, ch)
+			})
+		}
+
+		if err := processor.ValidateEvent(event); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := processor.SubmitEvent(c.Request.Context(), &event); err != nil {
+			c.JSON(submitErrorStatus(err, http.StatusServiceUnavailable), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":   "accepted",
+			"event_id": event.ID,
+			"message":  "CAP alert queued for dispatch",
+		})
+	}
+}
+
+// broadcastChannels reach whoever listens, e.g. every matching partner
+// webhook or a public siren, instead of the event's recipients.
+var broadcastChannels = []string{"webhook", "mqtt"}
+
+// splitList parses a comma-separated query value, dropping empty items.
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
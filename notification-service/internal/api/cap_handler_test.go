@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"notification-service/internal/processor"
+	memorystore "notification-service/internal/storage/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+const exerciseAlert = `<?xml version="1.0" encoding="UTF-8"?>
+<alert xmlns="urn:oasis:names:tc:emergency:cap:1.2">
+  <identifier>INCOIS-EX-001</identifier>
+  <sender>incois@incois.gov.in</sender>
+  <sent>2025-11-01T10:00:00+05:30</sent>
+  <status>Exercise</status>
+  <msgType>Alert</msgType>
+  <scope>Public</scope>
+  <addresses>citizen-1 citizen-2</addresses>
+  <info>
+    <category>Geo</category>
+    <event>Tsunami Warning</event>
+    <urgency>Immediate</urgency>
+    <severity>Extreme</severity>
+    <certainty>Observed</certainty>
+    <expires>2099-11-01T16:00:00+05:30</expires>
+    <headline>Tsunami warning drill</headline>
+    <description>Waves expected within 2 hours.</description>
+    <area>
+      <areaDesc>Chennai coast</areaDesc>
+      <circle>13.08,80.27 25</circle>
+    </area>
+  </info>
+</alert>`
+
+func TestCAPExerciseOnlyReachesTestRecipients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mem := memorystore.New()
+	processor.Init(mem, mem, mem)
+
+	post := func(testRecipients []string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.POST("/events/cap", HandleCAPEvent([]string{"sms"}, testRecipients))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/events/cap?recipients=citizen-3", strings.NewReader(exerciseAlert)))
+		return w
+	}
+
+	if w := post(nil); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("without test recipients: got %d %s, want 422", w.Code, w.Body)
+	}
+
+	if w := post([]string{"tester"}); w.Code != http.StatusAccepted {
+		t.Fatalf("with test recipients: got %d %s, want 202", w.Code, w.Body)
+	}
+	queued, err := mem.ReadEvents(t.Context(), "test", 1, time.Second)
+	if err != nil || len(queued) != 1 {
+		t.Fatalf("queued events: %v, %v", queued, err)
+	}
+	ev := queued[0].Event
+	if strings.Join(ev.Recipients, ",") != "tester" || len(ev.Areas) != 0 {
+		t.Errorf("exercise targets recipients %v and %d areas, want only the test recipients", ev.Recipients, len(ev.Areas))
+	}
+	if !strings.HasPrefix(ev.Title, "EXERCISE: ") {
+		t.Errorf("title %q is not labelled", ev.Title)
+	}
+}
+
+func TestCAPExerciseSkipsBroadcastChannels(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mem := memorystore.New()
+	processor.Init(mem, mem, mem)
+
+	post := func(channels string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.POST("/events/cap", HandleCAPEvent([]string{"sms"}, []string{"tester"}))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/events/cap?channels="+channels, strings.NewReader(exerciseAlert)))
+		return w
+	}
+
+	if w := post("sms,webhook,mqtt"); w.Code != http.StatusAccepted {
+		t.Fatalf("got %d %s, want 202", w.Code, w.Body)
+	}
+	queued, err := mem.ReadEvents(t.Context(), "test", 1, time.Second)
+	if err != nil || len(queued) != 1 {
+		t.Fatalf("queued events: %v, %v", queued, err)
+	}
+	if got := strings.Join(queued[0].Event.Channels, ","); got != "sms" {
+		t.Errorf("exercise channels = %s, want only sms", got)
+	}
+
+	// nothing left to send the exercise on
+	if w := post("webhook,mqtt"); w.Code != http.StatusBadRequest {
+		t.Errorf("broadcast-only exercise: got %d %s, want 400", w.Code, w.Body)
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"notification-service/internal/processor"
@@ -18,17 +19,52 @@ func HandleEvent(c *gin.Context) {
 		return
 	}
 
-	if err := processor.ProcessEvent(event); err != nil {
+	if err := processor.ValidateEvent(event); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// all-clears reach opted-out recipients, so only a cancel may send one
+	if event.AllClear {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "all_clear is set by the service; cancel with send_all_clear instead"})
+		return
+	}
+
+	if err := processor.SubmitEvent(c.Request.Context(), &event); err != nil {
+		c.JSON(submitErrorStatus(err, http.StatusServiceUnavailable), gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Event received and dispatched successfully",
+	if event.MsgType == "update" || event.MsgType == "cancel" {
+		c.JSON(http.StatusOK, gin.H{
+			"status":     "applied",
+			"event_id":   event.ID,
+			"references": event.References,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":   "accepted",
+		"event_id": event.ID,
+		"message":  "Event queued for dispatch",
 	})
 }
 
+// submitErrorStatus maps a SubmitEvent error to an HTTP status, using def
+// for errors that are not the caller's fault.
+func submitErrorStatus(err error, def int) int {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrExists):
+		return http.StatusConflict
+	case errors.Is(err, processor.ErrExpired):
+		return http.StatusBadRequest
+	default:
+		return def
+	}
+}
+
 func HealthCheckHandler(store storage.NotificationStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"notification-service/internal/processor"
+	memorystore "notification-service/internal/storage/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleEventRefusesClientAllClear(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"all_clear", `{"id":"evt-2","type":"flood","message":"m","channels":["sms"],"recipients":["+911"],"references":["evt-1"],"all_clear":true}`, http.StatusBadRequest},
+		{"alert with references", `{"id":"evt-2","msg_type":"alert","type":"flood","message":"m","channels":["sms"],"recipients":["+911"],"references":["evt-1"]}`, http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := memorystore.New()
+			processor.Init(mem, mem, mem)
+			r := gin.New()
+			r.POST("/events", HandleEvent)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.want)
+			}
+			_, err := mem.GetEvent(context.Background(), "evt-2")
+			if stored := err == nil; stored != (tt.want == http.StatusAccepted) {
+				t.Errorf("event stored = %v, want %v", stored, !stored)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"notification-service/internal/ids"
+	"notification-service/internal/logger"
+	"notification-service/internal/processor"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reply keywords, matched against the whole message ignoring case and
+// punctuation. The opt-out and opt-in words are the ones carriers and Twilio
+// honour, less Twilio's YES: "yes" is an ordinary answer to an alert and
+// must not lift an opt-out.
+var (
+	stopKeywords  = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT"}
+	startKeywords = []string{"START", "UNSTOP"}
+	helpKeywords  = []string{"HELP", "INFO"}
+	ackKeywords   = []string{"SAFE", "ACK", "IM SAFE", "I AM SAFE"}
+)
+
+// InboundSMSHandler serves POST /sms/inbound, Twilio's webhook for messages
+// sent to our number. STOP and START update the suppression list, HELP is
+// answered with helpText, SAFE or ACK acknowledges the latest alert sent to
+// the number unless its delivery failed, and anything else is kept as a citizen report on that alert's
+// event. Twilio itself confirms STOP and START, so those get no reply.
+func InboundSMSHandler(suppressions storage.SuppressionStore, reports storage.ReportStore, helpText string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, body := c.PostForm("From"), strings.TrimSpace(c.PostForm("Body"))
+		if from == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "From is required"})
+			return
+		}
+		ctx := c.Request.Context()
+		keyword := replyKeyword(body)
+
+		switch {
+		case slices.Contains(stopKeywords, keyword):
+			if err := suppressions.Suppress(ctx, models.Suppression{Channel: "sms", Address: from, Reason: keyword, CreatedAt: time.Now()}); err != nil {
+				respondStoreError(c, err)
+				return
+			}
+			logger.Info("SMS opt-out from " + from)
+			twiml(c, "")
+			return
+		case slices.Contains(startKeywords, keyword):
+			if err := suppressions.Unsuppress(ctx, "sms", from); err != nil {
+				respondStoreError(c, err)
+				return
+			}
+			logger.Info("SMS opt-in from " + from)
+			twiml(c, "")
+			return
+		case slices.Contains(helpKeywords, keyword):
+			twiml(c, helpText)
+			return
+		}
+
+		latest, err := processor.Disp().Store().LatestNotificationTo(ctx, "sms", from)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			respondStoreError(c, err)
+			return
+		}
+
+		if slices.Contains(ackKeywords, keyword) {
+			if latest == nil || !slices.Contains(storage.Delivered, latest.Status) ||
+				!processor.Disp().Acknowledge(ctx, *latest, "sms reply") {
+				twiml(c, "We have no recent alert for this number to confirm.")
+				return
+			}
+			twiml(c, "Thank you. Your confirmation has been recorded. Stay safe.")
+			return
+		}
+
+		report := models.CitizenReport{
+			ID:                ids.New("rpt"),
+			Channel:           "sms",
+			From:              from,
+			Body:              body,
+			ProviderMessageID: c.PostForm("MessageSid"),
+			ReceivedAt:        time.Now(),
+		}
+		if latest != nil {
+			report.EventID, report.NotificationID, report.RecipientID = latest.EventID, latest.ID, latest.RecipientID
+		}
+		if err := reports.SaveReport(ctx, report); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		logger.Info("Citizen report " + report.ID + " from " + from + " on event " + report.EventID)
+		twiml(c, "Thank you. Your report has been received.")
+	}
+}
+
+// ListEventReportsHandler serves GET /events/:id/reports.
+func ListEventReportsHandler(reports storage.ReportStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := reports.ListReportsByEvent(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"reports": list})
+	}
+}
+
+// ListSuppressionsHandler serves GET /suppressions?channel=, by default the
+// SMS opt-outs.
+func ListSuppressionsHandler(suppressions storage.SuppressionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		channel := c.DefaultQuery("channel", "sms")
+		list, err := suppressions.ListSuppressions(c.Request.Context(), channel)
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"suppressions": list})
+	}
+}
+
+// replyKeyword normalises a reply for keyword matching: upper case, words
+// separated by single spaces, punctuation dropped.
+func replyKeyword(body string) string {
+	body = strings.NewReplacer("'", "", "’", "").Replace(strings.ToUpper(body))
+	words := strings.FieldsFunc(body, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// twiml answers a Twilio messaging webhook, with a reply SMS unless message
+// is empty.
+func twiml(c *gin.Context, message string) {
+	reply := ""
+	if message != "" {
+		reply = "<Message>" + xmlText(message) + "</Message>"
+	}
+	c.Data(http.StatusOK, "text/xml", []byte(`<?xml version="1.0" encoding="UTF-8"?><Response>`+reply+`</Response>`))
+}
+
+func xmlText(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"notification-service/internal/processor"
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInboundSMS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const from = "+911234567890"
+	const helpText = "VedSagar alerts. Reply STOP to opt out."
+	tests := []struct {
+		name           string
+		suppressed     bool   // before the reply
+		alertStatus    string // of the latest alert to the number, "" for none
+		body           string
+		reply          string // "" for no reply SMS
+		wantSuppressed bool
+		wantReport     bool
+		wantStatus     string
+	}{
+		{"stop", false, "success", "Stop", "", true, false, "success"},
+		{"punctuation", false, "success", "quit!", "", true, false, "success"},
+		{"unsubscribe", false, "success", "UNSUBSCRIBE", "", true, false, "success"},
+		{"start", true, "success", "start", "", false, false, "success"},
+		{"yes is a report", true, "success", "Yes", "report has been received", true, true, "success"},
+		{"help", false, "success", "help?", helpText, false, false, "success"},
+		{"safe", false, "success", "I'm safe", "confirmation has been recorded", false, false, "acknowledged"},
+		{"safe before delivery report", false, "sent", "SAFE", "confirmation has been recorded", false, false, "acknowledged"},
+		{"safe after failed delivery", false, "failed", "safe", "no recent alert", false, false, "failed"},
+		{"safe without alert", false, "", "safe", "no recent alert", false, false, ""},
+		{"report", false, "success", "Water is knee deep near the temple", "report has been received", false, true, "success"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mem := memorystore.New()
+			processor.Init(mem, mem, mem)
+			processor.Disp().SetAcknowledgements(mem, nil, nil)
+			if tt.suppressed {
+				if err := mem.Suppress(ctx, models.Suppression{Channel: "sms", Address: from, Reason: "STOP", CreatedAt: time.Now()}); err != nil {
+					t.Fatalf("Suppress: %v", err)
+				}
+			}
+			if tt.alertStatus != "" {
+				if err := mem.SaveNotification(ctx, models.Notification{ID: "notif-1", EventID: "evt-1", Recipient: from,
+					Channel: "sms", Status: tt.alertStatus, Timestamp: time.Now()}); err != nil {
+					t.Fatalf("SaveNotification: %v", err)
+				}
+			}
+
+			r := gin.New()
+			r.POST("/sms/inbound", InboundSMSHandler(mem, mem, helpText))
+			form := url.Values{"From": {from}, "Body": {tt.body}, "MessageSid": {"SM9"}}
+			req := httptest.NewRequest(http.MethodPost, "/sms/inbound", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+			}
+
+			body := w.Body.String()
+			if tt.reply == "" && strings.Contains(body, "<Message>") || !strings.Contains(body, tt.reply) {
+				t.Errorf("reply %s, want %q", body, tt.reply)
+			}
+			if got, _ := mem.IsSuppressed(ctx, "sms", from); got != tt.wantSuppressed {
+				t.Errorf("suppressed = %v, want %v", got, tt.wantSuppressed)
+			}
+			eventID := "evt-1"
+			if tt.alertStatus == "" {
+				eventID = ""
+			}
+			reports, _ := mem.ListReportsByEvent(ctx, eventID)
+			if got := len(reports) == 1 && reports[0].Body == tt.body; got != tt.wantReport {
+				t.Errorf("reports on %q = %+v, want one: %v", eventID, reports, tt.wantReport)
+			}
+			if tt.alertStatus != "" {
+				if got, _ := mem.GetNotification(ctx, "notif-1"); got.Status != tt.wantStatus {
+					t.Errorf("alert status = %s, want %s", got.Status, tt.wantStatus)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetLocationHandler serves PUT /recipients/:id/location with {"lat":..,"lon":..}.
+func SetLocationHandler(locations storage.LocationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			Lat *float64 `json:"lat"`
+			Lon *float64 `json:"lon"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil || body.Lat == nil || body.Lon == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lon are required"})
+			return
+		}
+		loc := models.GeoPoint{Lat: *body.Lat, Lon: *body.Lon}
+		if !validLatLon(loc) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lat/lon out of range"})
+			return
+		}
+		if err := locations.SetLocation(c.Request.Context(), c.Param("id"), loc); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"recipient_id": c.Param("id"), "location": loc})
+	}
+}
+
+// GetLocationHandler serves GET /recipients/:id/location.
+func GetLocationHandler(locations storage.LocationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		loc, err := locations.GetLocation(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"recipient_id": c.Param("id"), "location": loc})
+	}
+}
+
+// DeleteLocationHandler serves DELETE /recipients/:id/location.
+func DeleteLocationHandler(locations storage.LocationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := locations.RemoveLocation(c.Request.Context(), c.Param("id")); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
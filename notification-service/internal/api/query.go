@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// GetNotificationHandler serves GET /notifications/:id.
+func GetNotificationHandler(store storage.NotificationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		notif, err := store.GetNotification(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, notif)
+	}
+}
+
+// GetEventHandler serves GET /events/:id: the stored event plus a rollup of
+// its notifications by status, channel and recipient.
+func GetEventHandler(events storage.EventStore, store storage.NotificationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		id := c.Param("id")
+
+		record, err := events.GetEvent(ctx, id)
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+
+		summary := models.EventSummary{
+			ByStatus:   map[string]int{},
+			ByChannel:  map[string]map[string]int{},
+			Recipients: map[string]map[string]string{},
+		}
+		for offset := 0; ; offset += maxPageSize {
+			page, total, err := store.ListNotificationsByEvent(ctx, id, offset, maxPageSize)
+			if err != nil {
+				respondStoreError(c, err)
+				return
+			}
+			for _, n := range page {
+				summary.Total++
+				summary.SMSSegments += n.SMSSegments
+				summary.ByStatus[n.Status]++
+				if summary.ByChannel[n.Channel] == nil {
+					summary.ByChannel[n.Channel] = map[string]int{}
+				}
+				summary.ByChannel[n.Channel][n.Status]++
+				if summary.Recipients[n.Recipient] == nil {
+					summary.Recipients[n.Recipient] = map[string]string{}
+				}
+				summary.Recipients[n.Recipient][n.Channel] = n.Status
+			}
+			if len(page) == 0 || offset+maxPageSize >= total {
+				break
+			}
+		}
+
+		expires := record.Event.ExpiresAt
+		c.JSON(http.StatusOK, gin.H{
+			"event":       record.Event,
+			"expired":     expires != nil && !time.Now().Before(*expires),
+			"status":      record.Status,
+			"accepted_at": record.AcceptedAt,
+			"updated_at":  record.UpdatedAt,
+			"summary":     summary,
+		})
+	}
+}
+
+// ListEventNotificationsHandler serves GET /events/:id/notifications?offset=&limit=.
+// An unknown event is a 404 rather than an empty page.
+func ListEventNotificationsHandler(events storage.EventStore, store storage.NotificationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		offset, limit, ok := pagination(c)
+		if !ok {
+			return
+		}
+		notifs, total, err := store.ListNotificationsByEvent(ctx, c.Param("id"), offset, limit)
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		if total == 0 {
+			if _, err := events.GetEvent(ctx, c.Param("id")); err != nil {
+				respondStoreError(c, err)
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"notifications": notifs,
+			"total":         total,
+			"offset":        offset,
+			"limit":         limit,
+		})
+	}
+}
+
+// pagination parses offset/limit query params, writing a 400 on bad input.
+func pagination(c *gin.Context) (offset, limit int, ok bool) {
+	offset, limit = 0, defaultPageSize
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+			return 0, 0, false
+		}
+		offset = n
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return 0, 0, false
+		}
+		limit = min(n, maxPageSize)
+	}
+	return offset, limit, true
+}
+
+func respondStoreError(c *gin.Context, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
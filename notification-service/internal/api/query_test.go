@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queryStore holds evt-1 with n0..n4, sent in that order, and evt-empty,
+// accepted but not dispatched yet.
+func queryStore(t *testing.T) *memorystore.MemoryStore {
+	t.Helper()
+	ctx := context.Background()
+	mem := memorystore.New()
+	for _, id := range []string{"evt-1", "evt-empty"} {
+		if err := mem.CreateEvent(ctx, models.EventRecord{Event: models.Event{ID: id, Type: "flood"},
+			Status: "dispatched", AcceptedAt: time.Now()}); err != nil {
+			t.Fatalf("CreateEvent: %v", err)
+		}
+	}
+	notifs := []struct{ channel, recipient, status string }{
+		{"sms", "+911", "success"},
+		{"sms", "+912", "failed"},
+		{"email", "+911", "success"},
+		{"sms", "+913", "expired"},
+		{"email", "+913", "queued"},
+	}
+	start := time.Now().Add(-time.Hour)
+	for i, n := range notifs {
+		segments := 0
+		if n.channel == "sms" {
+			segments = 2
+		}
+		if err := mem.SaveNotification(ctx, models.Notification{ID: fmt.Sprintf("n%d", i), EventID: "evt-1",
+			Channel: n.channel, Recipient: n.recipient, Status: n.status, SMSSegments: segments,
+			Timestamp: start.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+	}
+	return mem
+}
+
+func TestGetEventHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mem := queryStore(t)
+	r := gin.New()
+	r.GET("/events/:id", GetEventHandler(mem, mem))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events/evt-1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	var got struct {
+		Status  string              `json:"status"`
+		Expired bool                `json:"expired"`
+		Summary models.EventSummary `json:"summary"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Status != "dispatched" || got.Expired {
+		t.Errorf("status/expired = %s/%v, want dispatched/false", got.Status, got.Expired)
+	}
+	s := got.Summary
+	if s.Total != 5 || s.SMSSegments != 6 {
+		t.Errorf("total/segments = %d/%d, want 5/6", s.Total, s.SMSSegments)
+	}
+	if want := map[string]int{"success": 2, "failed": 1, "expired": 1, "queued": 1}; !maps.Equal(s.ByStatus, want) {
+		t.Errorf("by status = %v, want %v", s.ByStatus, want)
+	}
+	if want := map[string]int{"success": 1, "failed": 1, "expired": 1}; !maps.Equal(s.ByChannel["sms"], want) {
+		t.Errorf("sms by status = %v, want %v", s.ByChannel["sms"], want)
+	}
+	if want := map[string]string{"sms": "expired", "email": "queued"}; !maps.Equal(s.Recipients["+913"], want) {
+		t.Errorf("+913 by channel = %v, want %v", s.Recipients["+913"], want)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events/evt-unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown event: got %d %s, want 404", w.Code, w.Body)
+	}
+}
+
+func TestListEventNotificationsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mem := queryStore(t)
+	r := gin.New()
+	r.GET("/events/:id/notifications", ListEventNotificationsHandler(mem, mem))
+
+	tests := []struct {
+		name  string
+		path  string
+		code  int
+		ids   []string
+		total int
+	}{
+		{"FirstPage", "/events/evt-1/notifications", http.StatusOK, []string{"n0", "n1", "n2", "n3", "n4"}, 5},
+		{"Page", "/events/evt-1/notifications?offset=1&limit=2", http.StatusOK, []string{"n1", "n2"}, 5},
+		{"LastPage", "/events/evt-1/notifications?offset=4&limit=2", http.StatusOK, []string{"n4"}, 5},
+		{"PastTheEnd", "/events/evt-1/notifications?offset=10", http.StatusOK, []string{}, 5},
+		{"NotDispatchedYet", "/events/evt-empty/notifications", http.StatusOK, []string{}, 0},
+		{"ZeroLimit", "/events/evt-1/notifications?limit=0", http.StatusBadRequest, nil, 0},
+		{"NegativeOffset", "/events/evt-1/notifications?offset=-1", http.StatusBadRequest, nil, 0},
+		{"UnknownEvent", "/events/evt-unknown/notifications", http.StatusNotFound, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.code {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+			if tt.ids == nil {
+				return
+			}
+			var page struct {
+				Notifications []models.Notification `json:"notifications"`
+				Total         int                   `json:"total"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			ids := []string{}
+			for _, n := range page.Notifications {
+				ids = append(ids, n.ID)
+			}
+			if !slices.Equal(ids, tt.ids) {
+				t.Errorf("page = %v, want %v", ids, tt.ids)
+			}
+			if page.Total != tt.total {
+				t.Errorf("total = %d, want %d", page.Total, tt.total)
+			}
+		})
+	}
+}
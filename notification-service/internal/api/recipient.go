@@ -0,0 +1,170 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"notification-service/internal/ids"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateRecipientHandler serves POST /recipients. The ID is generated when
+// the body does not carry one.
+func CreateRecipientHandler(directory storage.RecipientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var r models.Recipient
+		if err := c.ShouldBindJSON(&r); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if r.ID == "" {
+			r.ID = ids.New("rcpt")
+		}
+		r.WebPush = nil // registered through /recipients/:id/webpush
+		if err := validateRecipient(r); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		if _, err := directory.GetRecipient(ctx, r.ID); err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "recipient " + r.ID + " already exists"})
+			return
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			respondStoreError(c, err)
+			return
+		}
+
+		r.CreatedAt = time.Now()
+		r.UpdatedAt = r.CreatedAt
+		if err := directory.SaveRecipient(ctx, r); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, r)
+	}
+}
+
+// PutRecipientHandler serves PUT /recipients/:id, replacing the whole profile
+// or creating it. Web push subscriptions are kept.
+func PutRecipientHandler(directory storage.RecipientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var r models.Recipient
+		if err := c.ShouldBindJSON(&r); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if r.ID != "" && r.ID != c.Param("id") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id in body does not match path"})
+			return
+		}
+		r.ID = c.Param("id")
+		if err := validateRecipient(r); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		r.UpdatedAt = time.Now()
+		r.CreatedAt = r.UpdatedAt
+		r.WebPush = nil // kept from the stored profile; see SubscribeWebPushHandler
+		status := http.StatusCreated
+		existing, err := directory.GetRecipient(ctx, r.ID)
+		switch {
+		case err == nil:
+			r.CreatedAt = existing.CreatedAt
+			r.WebPush = existing.WebPush
+			status = http.StatusOK
+		case !errors.Is(err, storage.ErrNotFound):
+			respondStoreError(c, err)
+			return
+		}
+		if err := directory.SaveRecipient(ctx, r); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(status, r)
+	}
+}
+
+// GetRecipientHandler serves GET /recipients/:id.
+func GetRecipientHandler(directory storage.RecipientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r, err := directory.GetRecipient(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, r)
+	}
+}
+
+// ListRecipientsHandler serves GET /recipients?offset=&limit=.
+func ListRecipientsHandler(directory storage.RecipientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		offset, limit, ok := pagination(c)
+		if !ok {
+			return
+		}
+		recipients, total, err := directory.ListRecipients(c.Request.Context(), offset, limit)
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"recipients": recipients,
+			"total":      total,
+			"offset":     offset,
+			"limit":      limit,
+		})
+	}
+}
+
+// DeleteRecipientHandler serves DELETE /recipients/:id.
+func DeleteRecipientHandler(directory storage.RecipientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := directory.DeleteRecipient(c.Request.Context(), c.Param("id")); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func validateRecipient(r models.Recipient) error {
+	if strings.ContainsAny(r.ID, " /") {
+		return fmt.Errorf("id must not contain spaces or slashes")
+	}
+	for _, p := range r.Phones {
+		if !strings.HasPrefix(p, "+") || len(p) < 8 {
+			return fmt.Errorf("phone %q is not in E.164 format", p)
+		}
+	}
+	for _, e := range r.Emails {
+		if !strings.Contains(e, "@") {
+			return fmt.Errorf("invalid email address %q", e)
+		}
+	}
+	for _, t := range r.PushTokens {
+		if t.Token == "" {
+			return fmt.Errorf("push token must not be empty")
+		}
+		if t.Platform != "" && t.Platform != "fcm" && t.Platform != "apns" {
+			return fmt.Errorf("push platform %q must be fcm or apns", t.Platform)
+		}
+	}
+	if r.Location != nil && !validLatLon(*r.Location) {
+		return fmt.Errorf("location lat/lon out of range")
+	}
+	return nil
+}
+
+func validLatLon(p models.GeoPoint) bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180
+}
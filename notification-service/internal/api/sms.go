@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"notification-service/internal/dispatcher/channels"
+	"notification-service/internal/processor"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// finalStatuses are notification statuses a late delivery report must not
+// overwrite.
+var finalStatuses = map[string]bool{
+	"success": true, "acknowledged": true, "failed_permanent": true, "expired": true, "cancelled": true,
+}
+
+// staleReport reports whether a provider status callback must leave the
+// notification alone: its outcome is already known, the report is about an
+// earlier attempt than providerID names, or its event has been cancelled.
+func staleReport(ctx context.Context, notif models.Notification, providerID string) bool {
+	if finalStatuses[notif.Status] {
+		return true
+	}
+	if providerID != "" && notif.ProviderMessageID != "" && providerID != notif.ProviderMessageID {
+		return true
+	}
+	return processor.EventCancelled(ctx, notif.EventID)
+}
+
+// SMSStatusHandler serves POST /sms/status?notification_id=, Twilio's
+// delivery report for an alert SMS. The notification advances through
+// queued and sent; delivered succeeds, and undelivered or failed messages
+// are retried like any failed send.
+func SMSStatusHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		notif, ok := callbackNotification(c)
+		if !ok {
+			return
+		}
+		ctx := c.Request.Context()
+		if staleReport(ctx, *notif, c.PostForm("MessageSid")) {
+			c.Status(http.StatusNoContent)
+			return
+		}
+
+		switch status := c.PostForm("MessageStatus"); status {
+		case "accepted", "scheduled", "queued", "sending":
+			processor.Disp().Progress(ctx, *notif, "queued")
+		case "sent":
+			processor.Disp().Progress(ctx, *notif, "sent")
+		case "":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "MessageStatus is required"})
+			return
+		default:
+			// the attempt already failed and awaits its retry; a repeated report
+			if notif.Status == "failed" {
+				c.Status(http.StatusNoContent)
+				return
+			}
+			result := channels.SMSStatusResult(notif.ID, status, c.PostForm("ErrorCode"))
+			processor.Disp().RecordResult(ctx, *notif, result)
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"notification-service/internal/processor"
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSMSStatusMapsToNotificationState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name          string
+		status        string // notification status before the report
+		eventStatus   string
+		messageSid    string
+		messageStatus string
+		errorCode     string
+		code          int
+		want          string
+		wantAtt       int
+	}{
+		{"accepted", "pending", "dispatched", "SM1", "accepted", "", http.StatusNoContent, "queued", 0},
+		{"queued", "pending", "dispatched", "SM1", "queued", "", http.StatusNoContent, "queued", 0},
+		{"sending", "queued", "dispatched", "SM1", "sending", "", http.StatusNoContent, "queued", 0},
+		{"sent", "queued", "dispatched", "SM1", "sent", "", http.StatusNoContent, "sent", 0},
+		{"delivered", "sent", "dispatched", "SM1", "delivered", "", http.StatusNoContent, "success", 0},
+		{"undelivered is retried", "sent", "dispatched", "SM1", "undelivered", "30003", http.StatusNoContent, "failed", 1},
+		{"carrier rate limit is retried", "sent", "dispatched", "SM1", "failed", "30001", http.StatusNoContent, "failed", 1},
+		{"unknown number", "sent", "dispatched", "SM1", "undelivered", "30005", http.StatusNoContent, "failed_permanent", 1},
+		{"canceled", "queued", "dispatched", "SM1", "canceled", "", http.StatusNoContent, "failed_permanent", 1},
+		{"repeated failure", "failed", "dispatched", "SM1", "failed", "30003", http.StatusNoContent, "failed", 0},
+		{"earlier message", "sent", "dispatched", "SM0", "undelivered", "30003", http.StatusNoContent, "sent", 0},
+		{"already delivered", "success", "dispatched", "SM1", "undelivered", "", http.StatusNoContent, "success", 0},
+		{"already acknowledged", "acknowledged", "dispatched", "SM1", "delivered", "", http.StatusNoContent, "acknowledged", 0},
+		{"cancelled event", "sent", "cancelled", "SM1", "undelivered", "", http.StatusNoContent, "sent", 0},
+		{"missing status", "sent", "dispatched", "SM1", "", "", http.StatusBadRequest, "sent", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mem := memorystore.New()
+			processor.Init(mem, mem, mem)
+			if err := mem.SaveEvent(ctx, models.EventRecord{Event: models.Event{ID: "evt-1"}, Status: tt.eventStatus}); err != nil {
+				t.Fatalf("SaveEvent: %v", err)
+			}
+			if err := mem.SaveNotification(ctx, models.Notification{
+				ID: "notif-1", EventID: "evt-1", Recipient: "+911234567890", Channel: "sms",
+				Status: tt.status, ProviderMessageID: "SM1", Timestamp: time.Now(),
+			}); err != nil {
+				t.Fatalf("SaveNotification: %v", err)
+			}
+
+			r := gin.New()
+			r.POST("/sms/status", SMSStatusHandler())
+			form := url.Values{"MessageSid": {tt.messageSid}, "MessageStatus": {tt.messageStatus}}
+			if tt.errorCode != "" {
+				form.Set("ErrorCode", tt.errorCode)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/sms/status?notification_id=notif-1", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+
+			got, err := mem.GetNotification(ctx, "notif-1")
+			if err != nil {
+				t.Fatalf("GetNotification: %v", err)
+			}
+			if got.Status != tt.want || got.Attempts != tt.wantAtt {
+				t.Errorf("status/attempts = %s/%d, want %s/%d", got.Status, got.Attempts, tt.want, tt.wantAtt)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"notification-service/internal/ids"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateSubscriptionHandler serves POST /recipients/:id/subscriptions.
+func CreateSubscriptionHandler(directory storage.RecipientStore, subs storage.SubscriptionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var sub models.Subscription
+		if err := c.ShouldBindJSON(&sub); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if err := validateSubscription(sub); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		if _, err := directory.GetRecipient(ctx, c.Param("id")); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		sub.ID = ids.New("sub")
+		sub.RecipientID = c.Param("id")
+		sub.CreatedAt = time.Now()
+		sub.UpdatedAt = sub.CreatedAt
+		if err := subs.SaveSubscription(ctx, sub); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, sub)
+	}
+}
+
+// ListSubscriptionsHandler serves GET /recipients/:id/subscriptions.
+func ListSubscriptionsHandler(subs storage.SubscriptionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := subs.ListSubscriptions(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"subscriptions": list})
+	}
+}
+
+// PutSubscriptionHandler serves PUT /recipients/:id/subscriptions/:sub_id,
+// replacing an existing subscription.
+func PutSubscriptionHandler(subs storage.SubscriptionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var sub models.Subscription
+		if err := c.ShouldBindJSON(&sub); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if err := validateSubscription(sub); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		existing, ok := recipientSubscription(c, subs)
+		if !ok {
+			return
+		}
+		sub.ID = existing.ID
+		sub.RecipientID = existing.RecipientID
+		sub.CreatedAt = existing.CreatedAt
+		sub.UpdatedAt = time.Now()
+		if err := subs.SaveSubscription(ctx, sub); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, sub)
+	}
+}
+
+// DeleteSubscriptionHandler serves DELETE /recipients/:id/subscriptions/:sub_id.
+func DeleteSubscriptionHandler(subs storage.SubscriptionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := recipientSubscription(c, subs); !ok {
+			return
+		}
+		if err := subs.DeleteSubscription(c.Request.Context(), c.Param("sub_id")); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// recipientSubscription loads :sub_id and checks it belongs to :id, writing
+// a 404 otherwise.
+func recipientSubscription(c *gin.Context, subs storage.SubscriptionStore) (*models.Subscription, bool) {
+	sub, err := subs.GetSubscription(c.Request.Context(), c.Param("sub_id"))
+	if err != nil {
+		respondStoreError(c, err)
+		return nil, false
+	}
+	if sub.RecipientID != c.Param("id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "subscription " + sub.ID + " not found for recipient " + c.Param("id")})
+		return nil, false
+	}
+	return sub, true
+}
+
+func validateSubscription(sub models.Subscription) error {
+	if sub.HazardType == "" {
+		return fmt.Errorf("missing required field: hazard_type (use \"*\" for every type)")
+	}
+	if sub.MinSeverity != "" && !models.ValidSeverity(sub.MinSeverity) {
+		return fmt.Errorf("unknown min_severity %q (want minor, moderate, severe or extreme)", sub.MinSeverity)
+	}
+	return nil
+}
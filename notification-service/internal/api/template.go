@@ -0,0 +1,166 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"notification-service/internal/processor"
+	"notification-service/internal/smsenc"
+	"notification-service/internal/storage"
+	"notification-service/internal/templating"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateTemplateHandler serves POST /templates, storing the body as the next
+// version of the named template.
+func CreateTemplateHandler(templates storage.TemplateStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var t models.Template
+		if err := c.ShouldBindJSON(&t); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if t.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "missing required field: name"})
+			return
+		}
+		if _, err := templating.Compile(t); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		t.CreatedAt = time.Now()
+		version, err := templates.CreateTemplateVersion(c.Request.Context(), t)
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		t.Version = version
+		c.JSON(http.StatusCreated, t)
+	}
+}
+
+// ListTemplatesHandler serves GET /templates with the latest version of each.
+func ListTemplatesHandler(templates storage.TemplateStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := templates.ListTemplates(c.Request.Context())
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"templates": list})
+	}
+}
+
+// GetTemplateHandler serves GET /templates/:name?version=, defaulting to the
+// latest version.
+func GetTemplateHandler(templates storage.TemplateStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, ok := templateVersion(c, c.Query("version"))
+		if !ok {
+			return
+		}
+		t, err := templates.GetTemplate(c.Request.Context(), c.Param("name"), version)
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, t)
+	}
+}
+
+// ListTemplateVersionsHandler serves GET /templates/:name/versions.
+func ListTemplateVersionsHandler(templates storage.TemplateStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		versions, err := templates.ListTemplateVersions(c.Request.Context(), c.Param("name"))
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"versions": versions})
+	}
+}
+
+// PreviewTemplateHandler serves POST /templates/:name/preview. It renders the
+// template against a sample event, and optionally a recipient profile or
+// language, for each requested channel (default sms, email and push)
+// without sending.
+func PreviewTemplateHandler(engine *templating.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			Version   int               `json:"version"`
+			Event     models.Event      `json:"event"`
+			Recipient *models.Recipient `json:"recipient"`
+			Address   string            `json:"address"`
+			Language  string            `json:"language"` // defaults to the recipient's
+			Channels  []string          `json:"channels"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if body.Version < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "version must be a positive integer"})
+			return
+		}
+		channels := body.Channels
+		if len(channels) == 0 {
+			channels = []string{"sms", "email", "push"}
+		}
+
+		tmpl, err := engine.Load(c.Request.Context(), c.Param("name"), body.Version)
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		data := templating.Data{Event: body.Event, Address: body.Address}
+		if body.Recipient != nil {
+			data.Recipient = *body.Recipient
+		}
+		lang := body.Language
+		if lang == "" {
+			lang = data.Recipient.Language
+		}
+		data.Event.Message, data.Language = body.Event.MessageFor(lang)
+
+		rendered := map[string]templating.Rendered{}
+		var sms *smsenc.Info
+		for _, ch := range channels {
+			out, err := tmpl.Render(ch, data)
+			if err != nil {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "channel": ch})
+				return
+			}
+			if ch == "sms" {
+				// show the body as it would be sent, after the length policy
+				var info smsenc.Info
+				out.Text, info = processor.Disp().FitSMS(out.Text)
+				sms = &info
+			}
+			rendered[ch] = out
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"name":     tmpl.Template.Name,
+			"version":  tmpl.Template.Version,
+			"language": data.Language,
+			"rendered": rendered,
+			"sms":      sms,
+		})
+	}
+}
+
+// templateVersion parses an optional version, writing a 400 on bad input.
+func templateVersion(c *gin.Context, v string) (int, bool) {
+	if v == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "version must be a positive integer"})
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"notification-service/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/twilio/twilio-go/client"
+)
+
+// TwilioSignatureMiddleware rejects callbacks whose X-Twilio-Signature does
+// not match the auth token. Twilio signs the URL it requested, so behind a
+// proxy publicBaseURL must be the externally visible base URL.
+func TwilioSignatureMiddleware(authToken, publicBaseURL string) gin.HandlerFunc {
+	validator := client.NewRequestValidator(authToken)
+	return func(c *gin.Context) {
+		if authToken == "" {
+			logger.Info("Rejected Twilio callback: TWILIO_AUTH_TOKEN is not set")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "twilio callbacks are not configured"})
+			return
+		}
+		if err := c.Request.ParseForm(); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid form body"})
+			return
+		}
+		params := map[string]string{}
+		for k, v := range c.Request.PostForm {
+			if len(v) > 0 {
+				params[k] = v[0]
+			}
+		}
+		if !validator.Validate(requestURL(c, publicBaseURL), params, c.GetHeader("X-Twilio-Signature")) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "invalid twilio signature"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestURL reconstructs the full URL the caller requested.
+func requestURL(c *gin.Context, publicBaseURL string) string {
+	if publicBaseURL != "" {
+		return strings.TrimRight(publicBaseURL, "/") + c.Request.URL.RequestURI()
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if p := c.GetHeader("X-Forwarded-Proto"); p != "" {
+		scheme = p
+	}
+	return scheme + "://" + c.Request.Host + c.Request.URL.RequestURI()
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testAuthToken = "twilio-auth-token"
+
+// twilioSignature signs a callback the way Twilio does: the full URL it
+// requested followed by the sorted form parameters, HMAC-SHA1 with the auth
+// token.
+func twilioSignature(authToken, fullURL string, form url.Values) string {
+	keys := make([]string, 0, len(form))
+	for k := range form {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(fullURL)
+	for _, k := range keys {
+		b.WriteString(k + form.Get(k))
+	}
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestTwilioSignatureMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const path = "/sms/status?notification_id=notif-1"
+	form := url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"delivered"}}
+	tampered := url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"undelivered"}}
+
+	tests := []struct {
+		name       string
+		authToken  string
+		publicBase string
+		host       string // Host the service sees
+		proto      string // X-Forwarded-Proto
+		signedURL  string // URL the signature covers, "" for none
+		body       url.Values
+		want       int
+	}{
+		{"valid", testAuthToken, "", "alerts.example", "", "http://alerts.example" + path, form, http.StatusNoContent},
+		{"tampered body", testAuthToken, "", "alerts.example", "", "http://alerts.example" + path, tampered, http.StatusForbidden},
+		{"other notification", testAuthToken, "", "alerts.example", "", "http://alerts.example/sms/status?notification_id=notif-2", form, http.StatusForbidden},
+		{"wrong token", "another-token", "", "alerts.example", "", "http://alerts.example" + path, form, http.StatusForbidden},
+		{"unsigned", testAuthToken, "", "alerts.example", "", "", form, http.StatusForbidden},
+		{"not configured", "", "", "alerts.example", "", "http://alerts.example" + path, form, http.StatusForbidden},
+		{"behind proxy", testAuthToken, "https://alerts.example/", "10.0.0.5:8080", "", "https://alerts.example" + path, form, http.StatusNoContent},
+		{"behind proxy without base URL", testAuthToken, "", "10.0.0.5:8080", "", "https://alerts.example" + path, form, http.StatusForbidden},
+		{"forwarded proto", testAuthToken, "", "alerts.example", "https", "https://alerts.example" + path, form, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/sms/status", TwilioSignatureMiddleware(tt.authToken, tt.publicBase), func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(tt.body.Encode()))
+			req.Host = tt.host
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			if tt.signedURL != "" {
+				req.Header.Set("X-Twilio-Signature", twilioSignature(testAuthToken, tt.signedURL, form))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+
+	"notification-service/internal/dispatcher/channels"
+	"notification-service/internal/processor"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VoiceGatherHandler serves POST /voice/gather?notification_id=, where
+// Twilio reports the key the recipient pressed during an alert call. Any key
+// acknowledges the alert.
+func VoiceGatherHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		notif, ok := callbackNotification(c)
+		if !ok {
+			return
+		}
+		if c.PostForm("Digits") == "" || !processor.Disp().Acknowledge(c.Request.Context(), *notif, "voice keypress") {
+			c.Data(http.StatusOK, "text/xml", []byte(`<?xml version="1.0" encoding="UTF-8"?><Response/>`))
+			return
+		}
+		c.Data(http.StatusOK, "text/xml", []byte(channels.VoiceAckTwiML(notif.Language)))
+	}
+}
+
+// VoiceStatusHandler serves POST /voice/status?notification_id=, Twilio's
+// report of how an alert call ended. Busy and unanswered calls are retried
+// like any failed send; reports for an earlier call, or for a notification
+// whose outcome is already known, e.g. acknowledged by keypress, are ignored.
+func VoiceStatusHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		notif, ok := callbackNotification(c)
+		if !ok {
+			return
+		}
+		ctx := c.Request.Context()
+		if staleReport(ctx, *notif, c.PostForm("CallSid")) {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		status := c.PostForm("CallStatus")
+		if status == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "CallStatus is required"})
+			return
+		}
+		// the call already failed and awaits its retry; a repeated report
+		if notif.Status == "failed" {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		processor.Disp().RecordResult(ctx, *notif, channels.VoiceCallResult(notif.ID, status))
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// callbackNotification loads the notification a provider callback is about,
+// writing the error response when it cannot.
+func callbackNotification(c *gin.Context) (*models.Notification, bool) {
+	id := c.Query("notification_id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "notification_id is required"})
+		return nil, false
+	}
+	notif, err := processor.Disp().Store().GetNotification(c.Request.Context(), id)
+	if err != nil {
+		respondStoreError(c, err)
+		return nil, false
+	}
+	return notif, true
+}
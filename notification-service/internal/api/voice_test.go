@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"notification-service/internal/processor"
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVoiceStatusGuards(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name        string
+		status      string // notification status before the report
+		eventStatus string
+		callSid     string
+		callStatus  string
+		want        string
+		wantAtt     int
+	}{
+		{"completed", "queued", "dispatched", "CA1", "completed", "success", 0},
+		{"busy is retried", "queued", "dispatched", "CA1", "busy", "failed", 1},
+		{"earlier call", "queued", "dispatched", "CA0", "busy", "queued", 0},
+		{"already acknowledged", "acknowledged", "dispatched", "CA1", "no-answer", "acknowledged", 0},
+		{"already expired", "expired", "dispatched", "CA1", "completed", "expired", 0},
+		{"repeated failure", "failed", "dispatched", "CA1", "failed", "failed", 0},
+		{"cancelled event", "queued", "cancelled", "CA1", "busy", "queued", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mem := memorystore.New()
+			processor.Init(mem, mem, mem)
+			if err := mem.SaveEvent(ctx, models.EventRecord{Event: models.Event{ID: "evt-1"}, Status: tt.eventStatus}); err != nil {
+				t.Fatalf("SaveEvent: %v", err)
+			}
+			if err := mem.SaveNotification(ctx, models.Notification{
+				ID: "notif-1", EventID: "evt-1", Recipient: "+911234567890", Channel: "voice",
+				Status: tt.status, ProviderMessageID: "CA1", Timestamp: time.Now(),
+			}); err != nil {
+				t.Fatalf("SaveNotification: %v", err)
+			}
+
+			r := gin.New()
+			r.POST("/voice/status", VoiceStatusHandler())
+			form := url.Values{"CallSid": {tt.callSid}, "CallStatus": {tt.callStatus}}
+			req := httptest.NewRequest(http.MethodPost, "/voice/status?notification_id=notif-1", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("got %d %s, want 204", w.Code, w.Body)
+			}
+
+			got, err := mem.GetNotification(ctx, "notif-1")
+			if err != nil {
+				t.Fatalf("GetNotification: %v", err)
+			}
+			if got.Status != tt.want || got.Attempts != tt.wantAtt {
+				t.Errorf("status/attempts = %s/%d, want %s/%d", got.Status, got.Attempts, tt.want, tt.wantAtt)
+			}
+		})
+	}
+}
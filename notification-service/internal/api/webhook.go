@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"notification-service/internal/dispatcher/channels"
+	"notification-service/internal/ids"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultSecretGrace is how long a rotated-out webhook secret keeps signing.
+const defaultSecretGrace = 24 * time.Hour
+
+// CreateWebhookHandler serves POST /webhooks. The response is the only one
+// that carries the generated signing secret. Endpoints must be public https
+// URLs unless allowInternal is set for local development.
+func CreateWebhookHandler(webhooks storage.WebhookStore, allowInternal bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var w models.WebhookEndpoint
+		if err := c.ShouldBindJSON(&w); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if err := validateWebhook(c.Request.Context(), w, allowInternal); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		w.ID = ids.New("whk")
+		w.Secret = newWebhookSecret()
+		w.PreviousSecret, w.PreviousExpiresAt = "", nil
+		w.CreatedAt = time.Now()
+		w.UpdatedAt = w.CreatedAt
+		w.SecretRotatedAt = w.CreatedAt
+		if err := webhooks.SaveWebhook(c.Request.Context(), w); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, w)
+	}
+}
+
+// ListWebhooksHandler serves GET /webhooks, without secrets.
+func ListWebhooksHandler(webhooks storage.WebhookStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := webhooks.ListWebhooks(c.Request.Context())
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		for i := range list {
+			list[i] = list[i].Redacted()
+		}
+		c.JSON(http.StatusOK, gin.H{"webhooks": list})
+	}
+}
+
+// GetWebhookHandler serves GET /webhooks/:id, without secrets.
+func GetWebhookHandler(webhooks storage.WebhookStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w, err := webhooks.GetWebhook(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, w.Redacted())
+	}
+}
+
+// PutWebhookHandler serves PUT /webhooks/:id, replacing the URL and filter
+// of an endpoint. Secrets only change through rotate-secret.
+func PutWebhookHandler(webhooks storage.WebhookStore, allowInternal bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var w models.WebhookEndpoint
+		if err := c.ShouldBindJSON(&w); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if err := validateWebhook(c.Request.Context(), w, allowInternal); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		existing, err := webhooks.GetWebhook(ctx, c.Param("id"))
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		w.ID = existing.ID
+		w.Secret, w.PreviousSecret, w.PreviousExpiresAt = existing.Secret, existing.PreviousSecret, existing.PreviousExpiresAt
+		w.SecretRotatedAt = existing.SecretRotatedAt
+		w.CreatedAt = existing.CreatedAt
+		w.UpdatedAt = time.Now()
+		if err := webhooks.SaveWebhook(ctx, w); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, w.Redacted())
+	}
+}
+
+// DeleteWebhookHandler serves DELETE /webhooks/:id.
+func DeleteWebhookHandler(webhooks storage.WebhookStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := webhooks.DeleteWebhook(c.Request.Context(), c.Param("id")); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RotateWebhookSecretHandler serves POST /webhooks/:id/rotate-secret. The
+// new secret is returned once; the old one keeps signing alongside it for
+// the optional grace_seconds (default 24 hours, 0 retires it at once).
+func RotateWebhookSecretHandler(webhooks storage.WebhookStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			GraceSeconds *int `json:"grace_seconds"`
+		}
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&body); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+				return
+			}
+		}
+		grace := defaultSecretGrace
+		if body.GraceSeconds != nil {
+			if *body.GraceSeconds < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "grace_seconds must not be negative"})
+				return
+			}
+			grace = time.Duration(*body.GraceSeconds) * time.Second
+		}
+
+		ctx := c.Request.Context()
+		w, err := webhooks.GetWebhook(ctx, c.Param("id"))
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		now := time.Now()
+		w.PreviousSecret, w.PreviousExpiresAt = "", nil
+		if grace > 0 {
+			expires := now.Add(grace)
+			w.PreviousSecret, w.PreviousExpiresAt = w.Secret, &expires
+		}
+		w.Secret = newWebhookSecret()
+		w.SecretRotatedAt = now
+		w.UpdatedAt = now
+		if err := webhooks.SaveWebhook(ctx, *w); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"id":                  w.ID,
+			"secret":              w.Secret,
+			"previous_expires_at": w.PreviousExpiresAt,
+		})
+	}
+}
+
+func validateWebhook(ctx context.Context, w models.WebhookEndpoint, allowInternal bool) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && !(allowInternal && u.Scheme == "http")) {
+		return fmt.Errorf("url must be an https URL")
+	}
+	if !allowInternal {
+		if err := checkPublicHost(ctx, u.Hostname()); err != nil {
+			return err
+		}
+	}
+	if w.MinSeverity != "" && !models.ValidSeverity(w.MinSeverity) {
+		return fmt.Errorf("unknown min_severity %q (want minor, moderate, severe or extreme)", w.MinSeverity)
+	}
+	return nil
+}
+
+// checkPublicHost rejects hosts that are or resolve to internal addresses.
+// The webhook channel checks again when it connects, as DNS may change.
+func checkPublicHost(ctx context.Context, host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("url host %s is internal", host)
+	}
+	addrs := []netip.Addr{}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else if addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host); err != nil {
+		return fmt.Errorf("url host %s does not resolve", host)
+	}
+	for _, addr := range addrs {
+		if channels.InternalAddress(addr) {
+			return fmt.Errorf("url host %s is internal", host)
+		}
+	}
+	return nil
+}
+
+func newWebhookSecret() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWebhookAdminRequiresToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		configured string
+		header     string
+		want       int
+	}{
+		{"not configured", "", "Bearer anything", http.StatusServiceUnavailable},
+		{"missing", "admin-token", "", http.StatusUnauthorized},
+		{"wrong", "admin-token", "Bearer guess", http.StatusUnauthorized},
+		{"valid", "admin-token", "Bearer admin-token", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			admin := r.Group("/webhooks", AdminTokenMiddleware(tt.configured))
+			admin.POST("", CreateWebhookHandler(memorystore.New(), false))
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url":"https://93.184.216.34/hooks/alerts"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url           string
+		allowInternal bool
+		ok            bool
+	}{
+		{"https://93.184.216.34/hook", false, true},
+		{"http://93.184.216.34/hook", false, false},
+		{"ftp://93.184.216.34/hook", false, false},
+		{"https://localhost:8443/hook", false, false},
+		{"https://api.localhost/hook", false, false},
+		{"https://127.0.0.1/hook", false, false},
+		{"https://10.0.0.5/hook", false, false},
+		{"https://192.168.1.10/hook", false, false},
+		{"https://169.254.169.254/latest/meta-data", false, false},
+		{"https://100.64.0.1/hook", false, false},
+		{"https://[::1]/hook", false, false},
+		{"https://[::ffff:10.0.0.5]/hook", false, false},
+		{"https://[fd00::1]/hook", false, false},
+		{"http://localhost:9000/hook", true, true},
+		{"ftp://localhost/hook", true, false},
+	}
+	for _, tt := range tests {
+		err := validateWebhook(context.Background(), models.WebhookEndpoint{URL: tt.url}, tt.allowInternal)
+		if (err == nil) != tt.ok {
+			t.Errorf("validateWebhook(%s, allowInternal=%v) = %v, want ok=%v", tt.url, tt.allowInternal, err, tt.ok)
+		}
+	}
+}
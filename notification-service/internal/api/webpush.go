@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"notification-service/internal/storage"
+	"notification-service/internal/webpush"
+	"notification-service/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VAPIDPublicKeyHandler serves GET /webpush/vapid-public-key, the
+// applicationServerKey the dashboard subscribes with.
+func VAPIDPublicKeyHandler(publicKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if publicKey == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "web push is not configured"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"public_key": publicKey})
+	}
+}
+
+// SubscribeWebPushHandler serves POST /recipients/:id/webpush. The body is
+// the browser's PushSubscription JSON; posting an already registered
+// endpoint updates its keys. Endpoints on internal hosts are refused unless
+// allowInternal is set.
+func SubscribeWebPushHandler(directory storage.RecipientStore, allowInternal bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var sub models.WebPushSubscription
+		if err := c.ShouldBindJSON(&sub); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if err := validateWebPush(c.Request.Context(), sub, allowInternal); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sub.CreatedAt = time.Now()
+		if err := directory.SaveWebPushSubscription(c.Request.Context(), c.Param("id"), sub); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, sub)
+	}
+}
+
+// ListWebPushHandler serves GET /recipients/:id/webpush.
+func ListWebPushHandler(directory storage.RecipientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r, err := directory.GetRecipient(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		subs := r.WebPush
+		if subs == nil {
+			subs = []models.WebPushSubscription{}
+		}
+		c.JSON(http.StatusOK, gin.H{"subscriptions": subs})
+	}
+}
+
+// UnsubscribeWebPushHandler serves DELETE /recipients/:id/webpush?endpoint=,
+// called when the browser unsubscribes.
+func UnsubscribeWebPushHandler(directory storage.RecipientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpoint := c.Query("endpoint")
+		if endpoint == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "endpoint is required"})
+			return
+		}
+		if err := directory.DeleteWebPushSubscription(c.Request.Context(), c.Param("id"), endpoint); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func validateWebPush(ctx context.Context, sub models.WebPushSubscription, allowInternal bool) error {
+	u, err := url.Parse(sub.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("endpoint must be an https URL")
+	}
+	if !allowInternal {
+		if err := checkPublicHost(ctx, u.Hostname()); err != nil {
+			return err
+		}
+	}
+	if _, err := webpush.ParseKeys(sub.Keys.P256dh, sub.Keys.Auth); err != nil {
+		return fmt.Errorf("invalid subscription keys: %v", err)
+	}
+	return nil
+}
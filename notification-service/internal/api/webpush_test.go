@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"notification-service/pkg/models"
+)
+
+func TestValidateWebPushEndpoint(t *testing.T) {
+	ua, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	enc := base64.RawURLEncoding.EncodeToString
+	keys := models.WebPushKeys{P256dh: enc(ua.PublicKey().Bytes()), Auth: enc(make([]byte, 16))}
+
+	tests := []struct {
+		endpoint      string
+		allowInternal bool
+		ok            bool
+	}{
+		{"https://93.184.216.34/wpush/v2/abc", false, true},
+		{"http://93.184.216.34/wpush/v2/abc", false, false},
+		{"https://127.0.0.1:8443/push", false, false},
+		{"https://169.254.169.254/latest/meta-data", false, false},
+		{"https://[fd00::1]/push", false, false},
+		{"https://localhost/push", false, false},
+		{"https://localhost:8443/push", true, true},
+	}
+	for _, tt := range tests {
+		sub := models.WebPushSubscription{Endpoint: tt.endpoint, Keys: keys}
+		err := validateWebPush(context.Background(), sub, tt.allowInternal)
+		if (err == nil) != tt.ok {
+			t.Errorf("validateWebPush(%s, allowInternal=%v) = %v, want ok=%v", tt.endpoint, tt.allowInternal, err, tt.ok)
+		}
+	}
+}
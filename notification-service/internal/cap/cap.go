@@ -0,0 +1,103 @@
+// Package cap parses and validates Common Alerting Protocol 1.2 documents
+// (OASIS CAP-V1.2) as published by INCOIS, IMD and other warning agencies.
+package cap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Namespace is the XML namespace every CAP 1.2 <alert> must declare.
+const Namespace = "urn:oasis:names:tc:emergency:cap:1.2"
+
+// Alert is the CAP <alert> element.
+type Alert struct {
+	XMLName     xml.Name `xml:"alert"`
+	Identifier  string   `xml:"identifier"`
+	Sender      string   `xml:"sender"`
+	Sent        string   `xml:"sent"`
+	Status      string   `xml:"status"`
+	MsgType     string   `xml:"msgType"`
+	Source      string   `xml:"source"`
+	Scope       string   `xml:"scope"`
+	Restriction string   `xml:"restriction"`
+	Addresses   string   `xml:"addresses"`
+	Codes       []string `xml:"code"`
+	Note        string   `xml:"note"`
+	References  string   `xml:"references"`
+	Incidents   string   `xml:"incidents"`
+	Infos       []Info   `xml:"info"`
+}
+
+// Info is the CAP <info> element; an alert carries one per language.
+type Info struct {
+	Language     string      `xml:"language"`
+	Categories   []string    `xml:"category"`
+	Event        string      `xml:"event"`
+	ResponseType []string    `xml:"responseType"`
+	Urgency      string      `xml:"urgency"`
+	Severity     string      `xml:"severity"`
+	Certainty    string      `xml:"certainty"`
+	Audience     string      `xml:"audience"`
+	EventCodes   []ValuePair `xml:"eventCode"`
+	Effective    string      `xml:"effective"`
+	Onset        string      `xml:"onset"`
+	Expires      string      `xml:"expires"`
+	SenderName   string      `xml:"senderName"`
+	Headline     string      `xml:"headline"`
+	Description  string      `xml:"description"`
+	Instruction  string      `xml:"instruction"`
+	Web          string      `xml:"web"`
+	Contact      string      `xml:"contact"`
+	Parameters   []ValuePair `xml:"parameter"`
+	Areas        []Area      `xml:"area"`
+}
+
+// Area is the CAP <area> element.
+type Area struct {
+	AreaDesc string      `xml:"areaDesc"`
+	Polygons []string    `xml:"polygon"`
+	Circles  []string    `xml:"circle"`
+	Geocodes []ValuePair `xml:"geocode"`
+	Altitude string      `xml:"altitude"`
+	Ceiling  string      `xml:"ceiling"`
+}
+
+// ValuePair is the valueName/value structure used by eventCode, parameter
+// and geocode.
+type ValuePair struct {
+	ValueName string `xml:"valueName"`
+	Value     string `xml:"value"`
+}
+
+// maxDocumentSize bounds how much of a request body Parse will read.
+const maxDocumentSize = 1 << 20
+
+// Parse decodes a CAP document and validates it; the returned error is a
+// *ValidationError when the XML is well-formed but breaks CAP rules.
+func Parse(r io.Reader) (*Alert, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxDocumentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read CAP document: %w", err)
+	}
+	if len(data) > maxDocumentSize {
+		return nil, fmt.Errorf("CAP document exceeds %d bytes", maxDocumentSize)
+	}
+
+	var alert Alert
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&alert); err != nil {
+		return nil, fmt.Errorf("malformed CAP XML: %w", err)
+	}
+	if alert.XMLName.Space != Namespace {
+		return nil, &ValidationError{Problems: []string{
+			fmt.Sprintf("alert: namespace %q is not %q", alert.XMLName.Space, Namespace),
+		}}
+	}
+	if err := alert.Validate(); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
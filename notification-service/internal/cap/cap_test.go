@@ -0,0 +1,208 @@
+package cap
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return string(data)
+}
+
+func parseFixture(t *testing.T, name string) *Alert {
+	t.Helper()
+	alert, err := Parse(strings.NewReader(readFixture(t, name)))
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", name, err)
+	}
+	return alert
+}
+
+func TestParseValidAlert(t *testing.T) {
+	alert := parseFixture(t, "tsunami_warning.xml")
+	event, err := alert.ToEvent()
+	if err != nil {
+		t.Fatalf("ToEvent: %v", err)
+	}
+
+	if event.ID != "INCOIS-TW-2025-0142" || event.Source != "cap" || event.Sender != "incois@incois.gov.in" {
+		t.Errorf("id/source/sender = %s/%s/%s", event.ID, event.Source, event.Sender)
+	}
+	if event.Type != "Tsunami Warning" || event.Severity != "extreme" || event.Urgency != "immediate" || event.Certainty != "observed" {
+		t.Errorf("type/severity/urgency/certainty = %s/%s/%s/%s", event.Type, event.Severity, event.Urgency, event.Certainty)
+	}
+	if event.MsgType != "alert" || len(event.References) != 0 {
+		t.Errorf("msgType/references = %s/%v, want alert and none", event.MsgType, event.References)
+	}
+	if want := []string{"kochi-port", "Fort Kochi control room"}; !slices.Equal(event.Recipients, want) {
+		t.Errorf("recipients = %q, want %q", event.Recipients, want)
+	}
+	sent := time.Date(2025, 11, 1, 4, 30, 0, 0, time.UTC)
+	if event.SentAt == nil || !event.SentAt.Equal(sent) {
+		t.Errorf("sent = %v, want %v", event.SentAt, sent)
+	}
+	if event.ExpiresAt == nil || !event.ExpiresAt.Equal(sent.Add(6*time.Hour)) {
+		t.Errorf("expires = %v, want 6h after sent", event.ExpiresAt)
+	}
+
+	if len(event.Areas) != 1 {
+		t.Fatalf("got %d areas, want 1", len(event.Areas))
+	}
+	area := event.Areas[0]
+	if area.Description != "Kochi coast" || len(area.Polygons) != 1 || len(area.Polygons[0]) != 5 ||
+		len(area.Circles) != 1 || area.Circles[0].RadiusKm != 15 || len(area.Geocodes) != 1 {
+		t.Errorf("area = %+v", area)
+	}
+}
+
+func TestParseMultiLanguageAlert(t *testing.T) {
+	event, err := parseFixture(t, "tsunami_warning.xml").ToEvent()
+	if err != nil {
+		t.Fatalf("ToEvent: %v", err)
+	}
+	// the English <info> is primary although it comes second
+	if event.Title != "Tsunami warning for the Kerala coast" || event.FallbackLanguage != "en-IN" {
+		t.Errorf("title/fallback = %q/%s, want the English info", event.Title, event.FallbackLanguage)
+	}
+	if event.Message != "Waves expected within 2 hours.\nMove to higher ground." {
+		t.Errorf("message = %q, want description then instruction", event.Message)
+	}
+	if want := "दो घंटे के भीतर लहरें आने की आशंका।\nऊँचे स्थान पर जाएँ।"; event.Messages["hi"] != want {
+		t.Errorf("hi message = %q, want %q", event.Messages["hi"], want)
+	}
+	if len(event.Messages) != 1 {
+		t.Errorf("translations = %v, want only hi", event.Messages)
+	}
+}
+
+func TestParseRejectsInvalidAlerts(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		edit    func(string) string
+		want    []string
+	}{
+		{
+			name:    "WrongNamespace",
+			fixture: "cap11_namespace.xml",
+			want:    []string{`namespace "urn:oasis:names:tc:emergency:cap:1.1"`},
+		},
+		{
+			name:    "MissingElements",
+			fixture: "missing_elements.xml",
+			want: []string{
+				"identifier: required",
+				"sent: required",
+				"info[0].event: required",
+				"info[0].urgency: required",
+				"info[0].area[0].areaDesc: required",
+				"info[0].area[0].polygon: needs at least 4 points",
+			},
+		},
+		{
+			name:    "UpdateWithoutReferences",
+			fixture: "tsunami_update.xml",
+			edit: func(doc string) string {
+				start, end := strings.Index(doc, "<references>"), strings.Index(doc, "</references>")
+				return doc[:start] + doc[end+len("</references>"):]
+			},
+			want: []string{"references: required for msgType Update"},
+		},
+		{
+			name:    "ZuluTime",
+			fixture: "tsunami_warning.xml",
+			edit: func(doc string) string {
+				return strings.Replace(doc, "2025-11-01T10:00:00+05:30", "2025-11-01T04:30:00Z", 1)
+			},
+			want: []string{"sent: \"2025-11-01T04:30:00Z\": timezone must be a numeric offset"},
+		},
+		{
+			name:    "UnknownMsgType",
+			fixture: "tsunami_warning.xml",
+			edit: func(doc string) string {
+				return strings.Replace(doc, "<msgType>Alert</msgType>", "<msgType>Warning</msgType>", 1)
+			},
+			want: []string{`msgType: "Warning" is not one of`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := readFixture(t, tt.fixture)
+			if tt.edit != nil {
+				doc = tt.edit(doc)
+			}
+			_, err := Parse(strings.NewReader(doc))
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Parse error = %v, want a ValidationError", err)
+			}
+			for _, want := range tt.want {
+				found := slices.ContainsFunc(verr.Problems, func(p string) bool { return strings.Contains(p, want) })
+				if !found {
+					t.Errorf("problems %q do not mention %q", verr.Problems, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRejectsMalformedXML(t *testing.T) {
+	_, err := Parse(strings.NewReader(`<alert xmlns="urn:oasis:names:tc:emergency:cap:1.2"><identifier>x`))
+	var verr *ValidationError
+	if err == nil || errors.As(err, &verr) {
+		t.Errorf("Parse error = %v, want a plain XML error", err)
+	}
+}
+
+func TestUpdateAndCancelMapping(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		msgType  string
+		refs     []string
+		allClear bool
+	}{
+		{"tsunami_update.xml", "update", []string{"INCOIS-TW-2025-0142"}, false},
+		{"tsunami_cancel.xml", "cancel", []string{"INCOIS-TW-2025-0142", "INCOIS-TW-2025-0143"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.msgType, func(t *testing.T) {
+			alert := parseFixture(t, tt.fixture)
+			event, err := alert.ToEvent()
+			if err != nil {
+				t.Fatalf("ToEvent: %v", err)
+			}
+			if event.MsgType != tt.msgType || !slices.Equal(event.References, tt.refs) {
+				t.Errorf("msgType/references = %s/%v, want %s/%v", event.MsgType, event.References, tt.msgType, tt.refs)
+			}
+			if got := alert.HasResponseType("AllClear"); got != tt.allClear {
+				t.Errorf("HasResponseType(AllClear) = %v, want %v", got, tt.allClear)
+			}
+		})
+	}
+}
+
+func TestExerciseIsLabelled(t *testing.T) {
+	doc := strings.Replace(readFixture(t, "tsunami_warning.xml"), "<status>Actual</status>", "<status>Exercise</status>", 1)
+	alert, err := Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	event, err := alert.ToEvent()
+	if err != nil {
+		t.Fatalf("ToEvent: %v", err)
+	}
+	if !strings.HasPrefix(event.Title, "EXERCISE: ") || !strings.HasPrefix(event.Message, "EXERCISE: ") ||
+		!strings.HasPrefix(event.Messages["hi"], "EXERCISE: ") {
+		t.Errorf("title/message/hi = %q/%q/%q, want each labelled", event.Title, event.Message, event.Messages["hi"])
+	}
+}
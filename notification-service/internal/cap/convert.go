@@ -0,0 +1,225 @@
+package cap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"notification-service/pkg/models"
+)
+
+// ParsePolygon parses a CAP polygon: space-separated "lat,lon" pairs, at
+// least four, with the first and last pair equal.
+func ParsePolygon(s string) ([]models.GeoPoint, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("needs at least 4 points, got %d", len(fields))
+	}
+	ring := make([]models.GeoPoint, 0, len(fields))
+	for _, f := range fields {
+		lat, lon, err := parsePoint(f)
+		if err != nil {
+			return nil, err
+		}
+		ring = append(ring, models.GeoPoint{Lat: lat, Lon: lon})
+	}
+	if ring[0] != ring[len(ring)-1] {
+		return nil, fmt.Errorf("first and last points must be equal")
+	}
+	return ring, nil
+}
+
+// ParseCircle parses a CAP circle: "lat,lon radius" with radius in km.
+func ParseCircle(s string) (models.Circle, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return models.Circle{}, fmt.Errorf("%q is not \"lat,lon radius\"", s)
+	}
+	lat, lon, err := parsePoint(fields[0])
+	if err != nil {
+		return models.Circle{}, err
+	}
+	r, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || r < 0 {
+		return models.Circle{}, fmt.Errorf("radius %q is not a non-negative number", fields[1])
+	}
+	return models.Circle{Center: models.GeoPoint{Lat: lat, Lon: lon}, RadiusKm: r}, nil
+}
+
+// PrimaryInfo picks the <info> block used for the event's text: the first
+// English one, else the first one. It returns nil if the alert has none.
+func (a *Alert) PrimaryInfo() *Info {
+	for i := range a.Infos {
+		lang := strings.ToLower(a.Infos[i].Language)
+		if lang == "" || strings.HasPrefix(lang, "en") {
+			return &a.Infos[i]
+		}
+	}
+	if len(a.Infos) > 0 {
+		return &a.Infos[0]
+	}
+	return nil
+}
+
+// AddressList splits the space-delimited <addresses> list; addresses that
+// contain spaces are enclosed in double quotes.
+func (a *Alert) AddressList() []string {
+	var out []string
+	s := strings.TrimSpace(a.Addresses)
+	for s != "" {
+		if s[0] == '"' {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				out = append(out, s[1:])
+				break
+			}
+			out = append(out, s[1:end+1])
+			s = strings.TrimSpace(s[end+2:])
+			continue
+		}
+		f := strings.Fields(s)[0]
+		out = append(out, f)
+		s = strings.TrimSpace(s[len(f):])
+	}
+	return out
+}
+
+// ReferencedIDs returns the identifiers named in <references>, which lists
+// earlier alerts as space-separated "sender,identifier,sent" triples.
+func (a *Alert) ReferencedIDs() []string {
+	var out []string
+	for _, ref := range strings.Fields(a.References) {
+		if parts := strings.Split(ref, ","); len(parts) == 3 {
+			out = append(out, parts[1])
+		}
+	}
+	return out
+}
+
+// HasResponseType reports whether any <info> carries the given responseType.
+func (a *Alert) HasResponseType(rt string) bool {
+	for _, info := range a.Infos {
+		for _, r := range info.ResponseType {
+			if r == rt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ToEvent maps a validated alert onto an Event. Channels and recipients are
+// not part of CAP; the caller fills them in. Test and Exercise alerts are
+// labelled so they cannot be mistaken for real warnings.
+func (a *Alert) ToEvent() (models.Event, error) {
+	event := models.Event{
+		ID:         a.Identifier,
+		Sender:     a.Sender,
+		Source:     "cap",
+		Recipients: a.AddressList(),
+		MsgType:    strings.ToLower(a.MsgType),
+		References: a.ReferencedIDs(),
+	}
+	if sent, err := ParseDateTime(a.Sent); err == nil {
+		event.SentAt = &sent
+	}
+
+	info := a.PrimaryInfo()
+	if info == nil {
+		return event, nil
+	}
+
+	event.Type = info.Event
+	event.Category = strings.Join(info.Categories, ",")
+	event.Severity = strings.ToLower(info.Severity)
+	event.Urgency = strings.ToLower(info.Urgency)
+	event.Certainty = strings.ToLower(info.Certainty)
+	event.Headline = info.Headline
+	event.Description = info.Description
+	event.Instruction = info.Instruction
+
+	event.Title = info.Headline
+	if event.Title == "" {
+		event.Title = info.Event
+	}
+	event.Message = infoText(info)
+
+	// other <info> blocks carry the same alert in other languages
+	event.FallbackLanguage = infoLanguage(info)
+	for i := range a.Infos {
+		other := &a.Infos[i]
+		lang := infoLanguage(other)
+		if other == info || strings.EqualFold(lang, event.FallbackLanguage) {
+			continue
+		}
+		if event.Messages == nil {
+			event.Messages = map[string]string{}
+		}
+		if _, dup := event.Messages[lang]; !dup {
+			event.Messages[lang] = infoText(other)
+		}
+	}
+
+	if info.Expires != "" {
+		exp, err := ParseDateTime(info.Expires)
+		if err != nil {
+			return event, fmt.Errorf("expires: %w", err)
+		}
+		event.ExpiresAt = &exp
+	}
+
+	for _, ca := range info.Areas {
+		area := models.Area{Description: ca.AreaDesc}
+		for _, p := range ca.Polygons {
+			ring, err := ParsePolygon(p)
+			if err != nil {
+				return event, fmt.Errorf("area %q: %w", ca.AreaDesc, err)
+			}
+			area.Polygons = append(area.Polygons, ring)
+		}
+		for _, c := range ca.Circles {
+			circle, err := ParseCircle(c)
+			if err != nil {
+				return event, fmt.Errorf("area %q: %w", ca.AreaDesc, err)
+			}
+			area.Circles = append(area.Circles, circle)
+		}
+		for _, g := range ca.Geocodes {
+			area.Geocodes = append(area.Geocodes, models.Geocode{Name: g.ValueName, Value: g.Value})
+		}
+		event.Areas = append(event.Areas, area)
+	}
+
+	if a.Status == "Test" || a.Status == "Exercise" {
+		label := strings.ToUpper(a.Status) + ": "
+		event.Title = label + event.Title
+		event.Message = label + event.Message
+		for lang, msg := range event.Messages {
+			event.Messages[lang] = label + msg
+		}
+	}
+	return event, nil
+}
+
+// infoText is the message body of one <info>: description then instruction.
+func infoText(info *Info) string {
+	text := info.Description
+	if text == "" {
+		text = info.Headline
+	}
+	if text == "" {
+		text = info.Event
+	}
+	if info.Instruction != "" {
+		text += "\n" + info.Instruction
+	}
+	return text
+}
+
+// infoLanguage is the <info> language; CAP defaults it to "en-US".
+func infoLanguage(info *Info) string {
+	if info.Language == "" {
+		return "en-US"
+	}
+	return info.Language
+}
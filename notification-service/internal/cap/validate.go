@@ -0,0 +1,184 @@
+package cap
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationError lists every CAP rule a document breaks.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid CAP alert: " + strings.Join(e.Problems, "; ")
+}
+
+// Enumerated values from the CAP 1.2 data dictionary.
+var (
+	statuses      = []string{"Actual", "Exercise", "System", "Test", "Draft"}
+	msgTypes      = []string{"Alert", "Update", "Cancel", "Ack", "Error"}
+	scopes        = []string{"Public", "Restricted", "Private"}
+	categories    = []string{"Geo", "Met", "Safety", "Security", "Rescue", "Fire", "Health", "Env", "Transport", "Infra", "CBRNE", "Other"}
+	responseTypes = []string{"Shelter", "Evacuate", "Prepare", "Execute", "Avoid", "Monitor", "Assess", "AllClear", "None"}
+	urgencies     = []string{"Immediate", "Expected", "Future", "Past", "Unknown"}
+	severities    = []string{"Extreme", "Severe", "Moderate", "Minor", "Unknown"}
+	certainties   = []string{"Observed", "Likely", "Possible", "Unlikely", "Unknown"}
+)
+
+// dateTimeLayout is the CAP dateTime form; the spec forbids "Z" and requires
+// an explicit numeric offset such as "+05:30".
+const dateTimeLayout = "2006-01-02T15:04:05-07:00"
+
+// ParseDateTime parses a CAP dateTime value.
+func ParseDateTime(v string) (time.Time, error) {
+	if strings.ContainsAny(v, "Zz") {
+		return time.Time{}, fmt.Errorf("%q: timezone must be a numeric offset, not Z", v)
+	}
+	t, err := time.Parse(dateTimeLayout, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q: want form 2006-01-02T15:04:05+05:30", v)
+	}
+	return t, nil
+}
+
+// Validate checks the alert against the CAP 1.2 rules.
+func (a *Alert) Validate() error {
+	v := &validator{}
+
+	v.required("identifier", a.Identifier)
+	v.noReservedChars("identifier", a.Identifier)
+	v.required("sender", a.Sender)
+	v.noReservedChars("sender", a.Sender)
+	v.dateTime("sent", a.Sent, true)
+	v.enum("status", a.Status, statuses, true)
+	v.enum("msgType", a.MsgType, msgTypes, true)
+	v.enum("scope", a.Scope, scopes, true)
+
+	if a.Scope == "Restricted" && strings.TrimSpace(a.Restriction) == "" {
+		v.add("restriction: required when scope is Restricted")
+	}
+	if a.Scope == "Private" && strings.TrimSpace(a.Addresses) == "" {
+		v.add("addresses: required when scope is Private")
+	}
+	for _, ref := range strings.Fields(a.References) {
+		if len(strings.Split(ref, ",")) != 3 {
+			v.add(fmt.Sprintf("references: %q is not sender,identifier,sent", ref))
+		}
+	}
+	if (a.MsgType == "Update" || a.MsgType == "Cancel") && a.References == "" {
+		v.add("references: required for msgType " + a.MsgType)
+	}
+	if (a.MsgType == "Alert" || a.MsgType == "Update") && len(a.Infos) == 0 {
+		v.add("info: at least one <info> block is required for msgType " + a.MsgType)
+	}
+
+	for i, info := range a.Infos {
+		v.info(fmt.Sprintf("info[%d]", i), info)
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(p string) {
+	v.problems = append(v.problems, p)
+}
+
+func (v *validator) required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.add(field + ": required")
+		return false
+	}
+	return true
+}
+
+func (v *validator) noReservedChars(field, value string) {
+	if strings.ContainsAny(value, " ,<&") {
+		v.add(field + ": must not contain spaces, commas, < or &")
+	}
+}
+
+func (v *validator) enum(field, value string, allowed []string, required bool) {
+	if value == "" {
+		if required {
+			v.add(field + ": required")
+		}
+		return
+	}
+	if !slices.Contains(allowed, value) {
+		v.add(fmt.Sprintf("%s: %q is not one of %s", field, value, strings.Join(allowed, ", ")))
+	}
+}
+
+func (v *validator) dateTime(field, value string, required bool) {
+	if value == "" {
+		if required {
+			v.add(field + ": required")
+		}
+		return
+	}
+	if _, err := ParseDateTime(value); err != nil {
+		v.add(field + ": " + err.Error())
+	}
+}
+
+func (v *validator) info(prefix string, info Info) {
+	if len(info.Categories) == 0 {
+		v.add(prefix + ".category: required")
+	}
+	for _, c := range info.Categories {
+		v.enum(prefix+".category", c, categories, true)
+	}
+	v.required(prefix+".event", info.Event)
+	for _, r := range info.ResponseType {
+		v.enum(prefix+".responseType", r, responseTypes, true)
+	}
+	v.enum(prefix+".urgency", info.Urgency, urgencies, true)
+	v.enum(prefix+".severity", info.Severity, severities, true)
+	v.enum(prefix+".certainty", info.Certainty, certainties, true)
+	v.dateTime(prefix+".effective", info.Effective, false)
+	v.dateTime(prefix+".onset", info.Onset, false)
+	v.dateTime(prefix+".expires", info.Expires, false)
+
+	for j, area := range info.Areas {
+		ap := fmt.Sprintf("%s.area[%d]", prefix, j)
+		v.required(ap+".areaDesc", area.AreaDesc)
+		for _, p := range area.Polygons {
+			if _, err := ParsePolygon(p); err != nil {
+				v.add(ap + ".polygon: " + err.Error())
+			}
+		}
+		for _, c := range area.Circles {
+			if _, err := ParseCircle(c); err != nil {
+				v.add(ap + ".circle: " + err.Error())
+			}
+		}
+	}
+}
+
+// parsePoint parses a CAP "lat,lon" pair (WGS84 decimal degrees).
+func parsePoint(s string) (lat, lon float64, err error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("point %q is not lat,lon", s)
+	}
+	lat, err1 := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lon, err2 := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, fmt.Errorf("point %q is not numeric", s)
+	}
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("point %q is out of range", s)
+	}
+	return lat, lon, nil
+}
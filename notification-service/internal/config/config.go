@@ -2,12 +2,78 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds minimal runtime settings; extend later as needed.
 type Config struct {
 	RedisURL string
 	Port     string
+
+	// StoreBackend selects where notifications and events are kept: "redis"
+	// (default), "memory" for local runs without infrastructure, or "sqlite" /
+	// "postgres" for long-term history. The SQL backends still use Redis for
+	// the intake queue.
+	StoreBackend string
+	// DatabaseURL is the SQLite file/URI or PostgreSQL URL for SQL backends.
+	DatabaseURL string
+
+	// EventWorkers is the number of consumers draining the intake queue.
+	EventWorkers int
+	// DispatchConcurrency is how many notifications of one event each
+	// consumer sends at once.
+	DispatchConcurrency int
+	// EventClaimIdle is how long a queued event may stay unacknowledged
+	// before another consumer takes it over.
+	EventClaimIdle time.Duration
+
+	// CAPDefaultChannels are used for CAP alerts posted without ?channels=.
+	CAPDefaultChannels []string
+	// CAPTestRecipients are the only recipients of CAP Test and Exercise
+	// alerts; without any such alerts are rejected.
+	CAPTestRecipients []string
+
+	// LifeSafetySeverities are the event severities that reach every
+	// recipient regardless of their subscriptions.
+	LifeSafetySeverities []string
+
+	// SMSLengthPolicy is "none", "truncate" or "shorten" (see smsenc) and
+	// applies to SMS bodies longer than SMSMaxSegments segments.
+	SMSLengthPolicy string
+	SMSMaxSegments  int
+
+	// PublicBaseURL is the externally reachable URL of this service, e.g.
+	// "https://alerts.example.org"; providers call back to it.
+	PublicBaseURL string
+	// TwilioAuthToken verifies the X-Twilio-Signature of Twilio callbacks.
+	TwilioAuthToken string
+	// SMSHelpText answers a HELP reply to an alert SMS.
+	SMSHelpText string
+
+	// AckSecret signs the acknowledgement links in alerts; without it (or
+	// PublicBaseURL) alerts carry no links.
+	AckSecret string
+	// EscalationPolicy lists, per severity, the channels to try in turn
+	// until the recipient acknowledges, e.g.
+	// "extreme=push,sms@5m,voice@15m;severe=push,sms@10m" (see ack).
+	EscalationPolicy string
+
+	// AdminToken is the bearer token of the administrative API (recipient
+	// directory, webhook endpoints, templates and suppressions); without it
+	// that API is disabled.
+	AdminToken string
+	// WebhookAllowInternal lets webhook endpoints use plain http and
+	// internal hosts such as localhost. For local development only.
+	WebhookAllowInternal bool
+	// WebPushAllowInternal lets web push subscriptions point at internal
+	// hosts. For local development only.
+	WebPushAllowInternal bool
+
+	// CORSAllowedOrigins lists origins (e.g. the dashboard) allowed to call
+	// the API from a browser; "*" allows any.
+	CORSAllowedOrigins []string
 }
 
 // Load reads from environment variables (fallbacks provided)
@@ -22,7 +88,75 @@ func Load() Config {
 		port = "8080"
 	}
 	return Config{
-		RedisURL: url,
-		Port:     port,
+		RedisURL:       url,
+		Port:           port,
+		StoreBackend:   stringEnv("STORE_BACKEND", "redis"),
+		DatabaseURL:    stringEnv("DATABASE_URL", "notifications.db"),
+		EventWorkers:   intEnv("EVENT_WORKERS", 4),
+		EventClaimIdle: durationEnv("EVENT_CLAIM_IDLE", 10*time.Minute),
+
+		DispatchConcurrency: intEnv("DISPATCH_CONCURRENCY", 32),
+
+		CAPDefaultChannels: listEnv("CAP_DEFAULT_CHANNELS"),
+		CAPTestRecipients:  listEnv("CAP_TEST_RECIPIENTS"),
+		CORSAllowedOrigins: listEnv("CORS_ALLOWED_ORIGINS"),
+
+		SMSLengthPolicy: stringEnv("SMS_LENGTH_POLICY", "shorten"),
+		SMSMaxSegments:  intEnv("SMS_MAX_SEGMENTS", 6),
+
+		PublicBaseURL:    os.Getenv("PUBLIC_BASE_URL"),
+		TwilioAuthToken:  os.Getenv("TWILIO_AUTH_TOKEN"),
+		AckSecret:        os.Getenv("ACK_SECRET"),
+		EscalationPolicy: os.Getenv("ESCALATION_POLICY"),
+		SMSHelpText:      stringEnv("SMS_HELP_TEXT", "VedSagar disaster alerts. Reply SAFE to confirm you are safe, STOP to stop alerts, START to resume. Reply with any other text to report what you see."),
+
+		LifeSafetySeverities: listEnvOr("LIFE_SAFETY_SEVERITIES", []string{"extreme", "severe"}),
+
+		AdminToken:           os.Getenv("ADMIN_TOKEN"),
+		WebhookAllowInternal: boolEnv("WEBHOOK_ALLOW_INTERNAL"),
+		WebPushAllowInternal: boolEnv("WEBPUSH_ALLOW_INTERNAL"),
+	}
+}
+
+func stringEnv(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+func listEnv(name string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func listEnvOr(name string, def []string) []string {
+	if v := listEnv(name); len(v) > 0 {
+		return v
+	}
+	return def
+}
+
+func intEnv(name string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(name)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+func boolEnv(name string) bool {
+	v, _ := strconv.ParseBool(os.Getenv(name))
+	return v
+}
+
+func durationEnv(name string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(name)); err == nil && v > 0 {
+		return v
 	}
+	return def
 }
@@ -0,0 +1,55 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+const chatTimeout = 15 * time.Second
+
+// chatResponse is what a chat platform answered to a JSON request.
+type chatResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// postJSON sends v as a JSON POST with the given headers and reads up to
+// 64 KiB of the answer. Errors never contain the URL, which may carry a
+// token.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, v any) (chatResponse, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return chatResponse{}, fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return chatResponse{}, withoutURL(err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return chatResponse{}, withoutURL(err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return chatResponse{status: resp.StatusCode, header: resp.Header, body: respBody}, nil
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with "…".
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
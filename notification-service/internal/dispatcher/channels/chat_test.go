@@ -0,0 +1,189 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"notification-service/pkg/models"
+)
+
+// failingTransport fails every request the way an unreachable host does.
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestChatRequestErrorsOmitSecretURLs(t *testing.T) {
+	client := &http.Client{Transport: failingTransport{}}
+	const botToken = "123456:AAF-secret-bot-token"
+	const slackHook = "https://hooks.slack.com/services/T000/B000/secret-hook-path"
+
+	tests := []struct {
+		name   string
+		send   func() models.DispatchResult
+		secret string
+	}{
+		{"telegram", func() models.DispatchResult {
+			h := NewTelegramHandlerWithConfig(TelegramConfig{BotToken: botToken, HTTPClient: client})
+			return h.Send(context.Background(), models.Notification{ID: "n", Recipient: "42", Message: "m"})
+		}, "secret-bot-token"},
+		{"slack webhook", func() models.DispatchResult {
+			h := NewSlackHandlerWithConfig(SlackConfig{HTTPClient: client})
+			return h.Send(context.Background(), models.Notification{ID: "n", Recipient: slackHook, Message: "m"})
+		}, "secret-hook-path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.send()
+			if res.Success || res.ErrorClass != models.ErrorTransient {
+				t.Fatalf("success=%v class=%s, want a transient failure", res.Success, res.ErrorClass)
+			}
+			if strings.Contains(res.Error, tt.secret) || !strings.Contains(res.Error, "connection refused") {
+				t.Errorf("error %q should give the cause without the URL", res.Error)
+			}
+		})
+	}
+}
+
+func TestTelegramFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		class      string
+		code       string
+		retryAfter time.Duration
+	}{
+		{"RateLimited", http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 7","parameters":{"retry_after":7}}`,
+			models.ErrorRateLimited, "429", 7 * time.Second},
+		{"BlockedByUser", http.StatusForbidden, `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`,
+			models.ErrorPermanent, "403", 0},
+		{"ChatNotFound", http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`,
+			models.ErrorPermanent, "400", 0},
+		{"BadToken", http.StatusUnauthorized, `{"ok":false,"error_code":401,"description":"Unauthorized"}`,
+			models.ErrorAuth, "401", 0},
+		{"BadGateway", http.StatusBadGateway, `<html>502</html>`, models.ErrorTransient, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			h := NewTelegramHandlerWithConfig(TelegramConfig{BotToken: "123:abc", BaseURL: srv.URL})
+			res := h.Send(context.Background(), models.Notification{ID: "n", Recipient: "42", Message: "m"})
+			if res.Success || res.ErrorClass != tt.class || res.ErrorCode != tt.code || res.RetryAfter != tt.retryAfter {
+				t.Errorf("Send = class %s, code %q, retry after %s, want %s, %q, %s (%s)",
+					res.ErrorClass, res.ErrorCode, res.RetryAfter, tt.class, tt.code, tt.retryAfter, res.Error)
+			}
+		})
+	}
+}
+
+// chatServer answers every request with status, header and body.
+func chatServer(t *testing.T, status int, header http.Header, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSlackFailures(t *testing.T) {
+	wait := http.Header{"Retry-After": {"30"}}
+	tests := []struct {
+		name       string
+		webhook    bool
+		status     int
+		header     http.Header
+		body       string
+		class      string
+		code       string
+		retryAfter time.Duration
+	}{
+		{"RateLimited", false, http.StatusTooManyRequests, wait, `{"ok":false,"error":"ratelimited"}`,
+			models.ErrorRateLimited, "ratelimited", 30 * time.Second},
+		{"ChannelNotFound", false, http.StatusOK, nil, `{"ok":false,"error":"channel_not_found"}`,
+			models.ErrorPermanent, "channel_not_found", 0},
+		{"InvalidAuth", false, http.StatusOK, nil, `{"ok":false,"error":"invalid_auth"}`,
+			models.ErrorAuth, "invalid_auth", 0},
+		{"Unauthorized", false, http.StatusUnauthorized, nil, `{"ok":false,"error":"not_authed"}`,
+			models.ErrorAuth, "not_authed", 0},
+		{"WebhookRateLimited", true, http.StatusTooManyRequests, wait, "rate_limited",
+			models.ErrorRateLimited, "rate_limited", 30 * time.Second},
+		{"WebhookRemoved", true, http.StatusNotFound, nil, "no_service",
+			models.ErrorPermanent, "no_service", 0},
+		{"WebhookArchivedChannel", true, http.StatusGone, nil, "channel_is_archived",
+			models.ErrorPermanent, "channel_is_archived", 0},
+		{"WebhookForbidden", true, http.StatusForbidden, nil, "invalid_token",
+			models.ErrorAuth, "invalid_token", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := chatServer(t, tt.status, tt.header, tt.body)
+			h := NewSlackHandlerWithConfig(SlackConfig{BotToken: "xoxb-test", BaseURL: srv.URL})
+			to := "C0123456789"
+			if tt.webhook {
+				to = srv.URL + "/services/T000/B000/hook"
+			}
+			res := h.Send(context.Background(), models.Notification{ID: "n", Recipient: to, Message: "m"})
+			if res.Success || res.ErrorClass != tt.class || res.ErrorCode != tt.code || res.RetryAfter != tt.retryAfter {
+				t.Errorf("Send = class %s, code %q, retry after %s, want %s, %q, %s (%s)",
+					res.ErrorClass, res.ErrorCode, res.RetryAfter, tt.class, tt.code, tt.retryAfter, res.Error)
+			}
+		})
+	}
+}
+
+func TestWhatsAppFailures(t *testing.T) {
+	wait := http.Header{"Retry-After": {"60"}}
+	tests := []struct {
+		name       string
+		status     int
+		header     http.Header
+		body       string
+		class      string
+		code       string
+		retryAfter time.Duration
+	}{
+		{"RateLimited", http.StatusTooManyRequests, wait, `{"error":{"message":"Rate limit hit","code":130429}}`,
+			models.ErrorRateLimited, "130429", time.Minute},
+		{"SpamRateLimited", http.StatusBadRequest, nil, `{"error":{"message":"Spam rate limit hit","code":131048}}`,
+			models.ErrorRateLimited, "131048", 0},
+		{"NotOnWhatsApp", http.StatusBadRequest, nil, `{"error":{"message":"Message undeliverable","code":131026}}`,
+			models.ErrorPermanent, "131026", 0},
+		{"TemplateMissing", http.StatusNotFound, nil, `{"error":{"message":"Template name does not exist in the translation","code":132001,"error_data":{"details":"template name (alerts) does not exist in hi"}}}`,
+			models.ErrorPermanent, "132001", 0},
+		{"ExpiredToken", http.StatusUnauthorized, nil, `{"error":{"message":"Error validating access token","type":"OAuthException","code":190}}`,
+			models.ErrorAuth, "190", 0},
+		{"Forbidden", http.StatusForbidden, nil, `Forbidden`, models.ErrorAuth, "", 0},
+		{"GatewayRateLimited", http.StatusTooManyRequests, wait, ``, models.ErrorRateLimited, "", time.Minute},
+		{"ServiceError", http.StatusInternalServerError, nil, `{"error":{"message":"Something went wrong","code":131000}}`,
+			models.ErrorTransient, "131000", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := chatServer(t, tt.status, tt.header, tt.body)
+			h := NewWhatsAppHandlerWithConfig(WhatsAppConfig{AccessToken: "token", PhoneNumberID: "1055",
+				Template: "alerts", BaseURL: srv.URL})
+			res := h.Send(context.Background(), models.Notification{ID: "n", Recipient: "+911234567890", Message: "m"})
+			if res.Success || res.ErrorClass != tt.class || res.ErrorCode != tt.code || res.RetryAfter != tt.retryAfter {
+				t.Errorf("Send = class %s, code %q, retry after %s, want %s, %q, %s (%s)",
+					res.ErrorClass, res.ErrorCode, res.RetryAfter, tt.class, tt.code, tt.retryAfter, res.Error)
+			}
+		})
+	}
+}
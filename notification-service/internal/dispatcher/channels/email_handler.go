@@ -2,33 +2,245 @@ package channels
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"notification-service/internal/logger"
 	"notification-service/pkg/models"
 )
 
-type EmailHandler struct{}
+// SMTP connection security modes.
+const (
+	SMTPStartTLS    = "starttls" // plain connect, then STARTTLS (port 587)
+	SMTPImplicitTLS = "tls"      // TLS from the first byte (port 465)
+	SMTPPlain       = "none"     // no TLS; only for local relays and tests
+)
+
+const smtpTimeout = 30 * time.Second
+
+// EmailConfig describes the SMTP relay the EmailHandler submits through.
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string // AUTH PLAIN is used when set
+	Password string
+	From     string // header From, e.g. "VedSagar Alerts <alerts@example.org>"
+	Security string // SMTPStartTLS (default), SMTPImplicitTLS or SMTPPlain
+	// TLSConfig overrides the default, which verifies the server as Host.
+	TLSConfig *tls.Config
+}
+
+// EmailHandler sends email through an SMTP relay.
+type EmailHandler struct {
+	cfg      EmailConfig
+	envelope string // bare address from cfg.From
+}
+
+// NewEmailHandler initializes EmailHandler from SMTP_* env vars
+func NewEmailHandler() *EmailHandler {
+	port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	return NewEmailHandlerWithConfig(EmailConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+		Security: os.Getenv("SMTP_SECURITY"),
+	})
+}
+
+// NewEmailHandlerWithConfig fills in defaults for an explicit configuration,
+// e.g. one pointing at a local SMTP server in tests.
+func NewEmailHandlerWithConfig(cfg EmailConfig) *EmailHandler {
+	if cfg.Security == "" {
+		cfg.Security = SMTPStartTLS
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+		if cfg.Security == SMTPImplicitTLS {
+			cfg.Port = 465
+		}
+	}
+	if cfg.TLSConfig == nil {
+		cfg.TLSConfig = &tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12}
+	}
+	envelope := cfg.From
+	if addr, err := mail.ParseAddress(cfg.From); err == nil {
+		envelope = addr.Address
+	}
+	return &EmailHandler{cfg: cfg, envelope: envelope}
+}
 
+// Send submits the notification as a MIME message. SMTP reply codes are
+// reported in the result: 5xx replies are permanent failures, except refused
+// credentials, while 4xx replies and connection problems are retried.
 func (h *EmailHandler) Send(ctx context.Context, notif models.Notification) models.DispatchResult {
-	select {
-	case <-time.After(150 * time.Millisecond):
-	case <-ctx.Done():
-		return models.DispatchResult{
-			NotificationID: notif.ID,
-			Success:        false,
-			Error:          "context cancelled",
-			Timestamp:      time.Now(),
+	result := models.DispatchResult{NotificationID: notif.ID}
+	if h.cfg.Host == "" || h.envelope == "" {
+		result.Error = "email not configured: SMTP_HOST and SMTP_FROM are required"
+		result.ErrorClass = models.ErrorAuth
+		result.Timestamp = time.Now()
+		return result
+	}
+
+	msg, err := buildEmail(h.cfg.From, notif, time.Now())
+	if err != nil {
+		result.Error = fmt.Sprintf("build message: %v", err)
+		result.ErrorClass = models.ErrorPermanent
+		result.Timestamp = time.Now()
+		return result
+	}
+
+	code, reply, err := h.deliver(ctx, notif.Recipient, msg)
+	result.APIStatusCode, result.APIResponse = code, reply
+	result.Timestamp = time.Now()
+	if err != nil {
+		logger.Error(fmt.Errorf("[EMAIL] Error sending to %s: %w", notif.Recipient, err))
+		result.Error = err.Error()
+		result.ErrorClass, result.ErrorCode = smtpFailureClass(code), enhancedStatus(reply)
+		return result
+	}
+
+	logger.Info(fmt.Sprintf("[EMAIL] Sent to %s: %d %s", notif.Recipient, code, reply))
+	result.Success = true
+	return result
+}
+
+// deliver runs one SMTP transaction and returns the last reply code and
+// text; code is 0 when no reply was received.
+func (h *EmailHandler) deliver(ctx context.Context, to string, msg []byte) (int, string, error) {
+	addr := net.JoinHostPort(h.cfg.Host, strconv.Itoa(h.cfg.Port))
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+
+	var conn net.Conn
+	var err error
+	if h.cfg.Security == SMTPImplicitTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: h.cfg.TLSConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("connect %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+	// unblock any pending read or write on shutdown
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, h.cfg.Host)
+	if err != nil {
+		return smtpFailure("greeting", err)
+	}
+	defer c.Close()
+
+	if err := c.Hello(localName()); err != nil {
+		return smtpFailure("EHLO", err)
+	}
+	if h.cfg.Security == SMTPStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return 0, "", errors.New("server does not offer STARTTLS")
+		}
+		if err := c.StartTLS(h.cfg.TLSConfig); err != nil {
+			return smtpFailure("STARTTLS", err)
+		}
+	}
+	if h.cfg.Username != "" {
+		auth := smtp.PlainAuth("", h.cfg.Username, h.cfg.Password, h.cfg.Host)
+		if err := c.Auth(auth); err != nil {
+			return smtpFailure("AUTH", err)
 		}
 	}
+	if err := c.Mail(h.envelope); err != nil {
+		return smtpFailure("MAIL FROM", err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return smtpFailure("RCPT TO", err)
+	}
 
-	logger.Info(fmt.Sprintf("[EMAIL] Sent to %s: %s", notif.Recipient, notif.Message))
+	// DATA by hand so the final reply, usually carrying the relay's queue
+	// ID, can be recorded
+	id, err := c.Text.Cmd("DATA")
+	if err != nil {
+		return smtpFailure("DATA", err)
+	}
+	c.Text.StartResponse(id)
+	_, _, err = c.Text.ReadResponse(354)
+	c.Text.EndResponse(id)
+	if err != nil {
+		return smtpFailure("DATA", err)
+	}
+	w := c.Text.DotWriter()
+	if _, err := w.Write(msg); err != nil {
+		return smtpFailure("DATA", err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpFailure("DATA", err)
+	}
+	code, reply, err := c.Text.ReadResponse(250)
+	if err != nil {
+		return smtpFailure("end of DATA", err)
+	}
+
+	_ = c.Quit()
+	return code, reply, nil
+}
+
+// smtpFailureClass classifies a failed SMTP transaction by its last reply
+// code, 0 when the server gave none.
+func smtpFailureClass(code int) string {
+	switch {
+	case code == 530 || code == 534 || code == 535 || code == 538:
+		// authentication required, too weak, or refused
+		return models.ErrorAuth
+	case code >= 500:
+		return models.ErrorPermanent
+	}
+	return models.ErrorTransient
+}
+
+// enhancedStatus returns the RFC 3463 status code a reply starts with, e.g.
+// "5.1.1" for an unknown mailbox, or "".
+func enhancedStatus(reply string) string {
+	code, _, _ := strings.Cut(reply, " ")
+	parts := strings.Split(code, ".")
+	if len(parts) != 3 || (parts[0] != "2" && parts[0] != "4" && parts[0] != "5") {
+		return ""
+	}
+	for _, p := range parts[1:] {
+		if _, err := strconv.Atoi(p); err != nil || len(p) > 3 {
+			return ""
+		}
+	}
+	return code
+}
+
+// smtpFailure extracts the reply code and text from an SMTP error.
+func smtpFailure(stage string, err error) (int, string, error) {
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		return tpErr.Code, tpErr.Msg, fmt.Errorf("%s: %d %s", stage, tpErr.Code, tpErr.Msg)
+	}
+	return 0, "", fmt.Errorf("%s: %w", stage, err)
+}
 
-	return models.DispatchResult{
-		NotificationID: notif.ID,
-		Success:        true,
-		Error:          "",
-		Timestamp:      time.Now(),
+func localName() string {
+	if h, err := os.Hostname(); err == nil && h != "" {
+		return h
 	}
+	return "localhost"
 }
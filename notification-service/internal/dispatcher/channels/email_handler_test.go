@@ -0,0 +1,279 @@
+package channels
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"notification-service/pkg/models"
+)
+
+// smtpServer is a minimal in-process SMTP relay offering STARTTLS and AUTH
+// PLAIN. Replies to AUTH, RCPT TO and the end of DATA can be scripted.
+type smtpServer struct {
+	ln  net.Listener
+	tls *tls.Config
+
+	authReply, rcptReply, dataReply string
+
+	mu       sync.Mutex
+	startTLS bool   // the session was upgraded before AUTH
+	auth     string // decoded AUTH PLAIN credentials
+	message  []byte
+}
+
+func newSMTPServer(t *testing.T, cert tls.Certificate) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &smtpServer{
+		ln:        ln,
+		tls:       &tls.Config{Certificates: []tls.Certificate{cert}},
+		authReply: "235 2.7.0 Authentication successful",
+		rcptReply: "250 2.1.5 Ok",
+		dataReply: "250 2.0.0 Ok: queued as 4F2A1",
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	text := textproto.NewConn(conn)
+	secure := false
+	reply := func(line string) { _ = text.PrintfLine("%s", line) }
+
+	reply("220 relay.test ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			if secure {
+				reply("250-relay.test\r\n250 AUTH PLAIN")
+			} else {
+				reply("250-relay.test\r\n250-STARTTLS\r\n250 AUTH PLAIN")
+			}
+		case "STARTTLS":
+			reply("220 2.0.0 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			text, secure = textproto.NewConn(tlsConn), true
+			s.mu.Lock()
+			s.startTLS = true
+			s.mu.Unlock()
+		case "AUTH":
+			_, creds, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(creds)
+			s.mu.Lock()
+			s.auth = string(decoded)
+			s.mu.Unlock()
+			reply(s.authReply)
+		case "MAIL":
+			reply("250 2.1.0 Ok")
+		case "RCPT":
+			reply(s.rcptReply)
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			msg, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.message = msg
+			s.mu.Unlock()
+			reply(s.dataReply)
+		case "QUIT":
+			reply("221 2.0.0 Bye")
+			return
+		default:
+			reply("502 5.5.2 Command not recognized")
+		}
+	}
+}
+
+// selfSigned returns a certificate for 127.0.0.1 and a pool trusting it.
+func selfSigned(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+// newTestEmail starts a relay and an EmailHandler that submits through it.
+func newTestEmail(t *testing.T) (*smtpServer, *EmailHandler) {
+	t.Helper()
+	cert, pool := selfSigned(t)
+	srv := newSMTPServer(t, cert)
+	port := srv.ln.Addr().(*net.TCPAddr).Port
+	h := NewEmailHandlerWithConfig(EmailConfig{
+		Host:      "127.0.0.1",
+		Port:      port,
+		Username:  "alerts",
+		Password:  "s3cret",
+		From:      "VedSagar Alerts <alerts@example.org>",
+		TLSConfig: &tls.Config{ServerName: "127.0.0.1", RootCAs: pool, MinVersion: tls.VersionTLS12},
+	})
+	return srv, h
+}
+
+func TestEmailSendsOverSTARTTLSWithAuth(t *testing.T) {
+	srv, h := newTestEmail(t)
+	notif := models.Notification{
+		ID:        "notif-1",
+		Recipient: "asha@example.org",
+		Channel:   "email",
+		Subject:   "Cyclone warning",
+		Message:   "Move to the shelter.",
+		HTMLBody:  `<p>Move to the shelter.</p><img src="cid:map">`,
+		Attachments: []models.Attachment{
+			{Filename: "map.png", ContentType: "image/png", ContentID: "map", Data: []byte("png")},
+			{Filename: "advisory.pdf", Data: []byte("%PDF-1.4")},
+		},
+	}
+
+	res := h.Send(context.Background(), notif)
+	if !res.Success {
+		t.Fatalf("Send failed: %s", res.Error)
+	}
+	if res.APIStatusCode != 250 || !strings.Contains(res.APIResponse, "queued as 4F2A1") {
+		t.Errorf("reply = %d %q, want the relay's final 250 reply", res.APIStatusCode, res.APIResponse)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if !srv.startTLS {
+		t.Error("session was not upgraded with STARTTLS")
+	}
+	if srv.auth != "\x00alerts\x00s3cret" {
+		t.Errorf("AUTH PLAIN credentials = %q", srv.auth)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(srv.message))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if got := msg.Header.Get("Subject"); got != "Cyclone warning" {
+		t.Errorf("Subject = %q", got)
+	}
+	// multipart/mixed{ multipart/related{ multipart/alternative{plain, html}, map.png }, advisory.pdf }
+	want := []string{
+		"multipart/mixed",
+		"multipart/related", "multipart/alternative", "text/plain", "text/html", "image/png",
+		"application/pdf",
+	}
+	if got := mimeTree(t, msg.Header.Get("Content-Type"), msg.Body); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("MIME tree = %v, want %v", got, want)
+	}
+}
+
+// mimeTree lists the media types of a MIME entity and its parts depth-first.
+func mimeTree(t *testing.T, contentType string, body io.Reader) []string {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("parse %q: %v", contentType, err)
+	}
+	types := []string{mediaType}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return types
+	}
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return types
+		}
+		if err != nil {
+			t.Fatalf("read %s part: %v", mediaType, err)
+		}
+		types = append(types, mimeTree(t, part.Header.Get("Content-Type"), bufio.NewReader(part))...)
+	}
+}
+
+func TestEmailClassifiesReplyCodes(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    func(*smtpServer)
+		wantClass string
+		wantCode  string
+	}{
+		{"credentials refused", func(s *smtpServer) { s.authReply = "535 5.7.8 Authentication credentials invalid" },
+			models.ErrorAuth, "5.7.8"},
+		{"unknown mailbox", func(s *smtpServer) { s.rcptReply = "550 5.1.1 No such user" },
+			models.ErrorPermanent, "5.1.1"},
+		{"greylisted", func(s *smtpServer) { s.rcptReply = "451 4.7.1 Try again later" },
+			models.ErrorTransient, "4.7.1"},
+		{"message too big", func(s *smtpServer) { s.dataReply = "552 5.3.4 Message too big" },
+			models.ErrorPermanent, "5.3.4"},
+		{"no enhanced code", func(s *smtpServer) { s.rcptReply = "554 Rejected" },
+			models.ErrorPermanent, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, h := newTestEmail(t)
+			tt.script(srv)
+			res := h.Send(context.Background(), models.Notification{ID: "n", Recipient: "ravi@example.org", Message: "m"})
+			if res.Success {
+				t.Fatal("Send succeeded, want a failure")
+			}
+			if res.ErrorClass != tt.wantClass || res.ErrorCode != tt.wantCode {
+				t.Errorf("class/code = %s/%q, want %s/%q (%s)", res.ErrorClass, res.ErrorCode, tt.wantClass, tt.wantCode, res.Error)
+			}
+		})
+	}
+}
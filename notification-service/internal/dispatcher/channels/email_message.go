@@ -0,0 +1,168 @@
+package channels
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"notification-service/pkg/models"
+)
+
+// mimePart is one encoded node of a MIME tree.
+type mimePart struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// buildEmail renders a notification as an RFC 5322 message. The body is
+// text/plain, or multipart/alternative when there is an HTML part, wrapped
+// in multipart/related for inline images and multipart/mixed for
+// attachments.
+func buildEmail(from string, notif models.Notification, now time.Time) ([]byte, error) {
+	var inline, attached []models.Attachment
+	for _, a := range notif.Attachments {
+		if a.ContentID != "" {
+			inline = append(inline, a)
+		} else {
+			attached = append(attached, a)
+		}
+	}
+
+	root, err := textPart("text/plain", notif.Message)
+	if err != nil {
+		return nil, err
+	}
+	if notif.HTMLBody != "" {
+		html, err := textPart("text/html", notif.HTMLBody)
+		if err != nil {
+			return nil, err
+		}
+		if root, err = multipartOf("alternative", root, html); err != nil {
+			return nil, err
+		}
+	}
+	if len(inline) > 0 {
+		parts := []mimePart{root}
+		for _, a := range inline {
+			parts = append(parts, attachmentPart(a, true))
+		}
+		if root, err = multipartOf("related", parts...); err != nil {
+			return nil, err
+		}
+	}
+	if len(attached) > 0 {
+		parts := []mimePart{root}
+		for _, a := range attached {
+			parts = append(parts, attachmentPart(a, false))
+		}
+		if root, err = multipartOf("mixed", parts...); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "From", from)
+	writeHeader(&buf, "To", notif.Recipient)
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", notif.Subject))
+	writeHeader(&buf, "Date", now.Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", fmt.Sprintf("<%s@%s>", notif.ID, domainOf(from)))
+	writeHeader(&buf, "MIME-Version", "1.0")
+	for _, k := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if v := root.header.Get(k); v != "" {
+			writeHeader(&buf, k, v)
+		}
+	}
+	buf.WriteString("\r\n")
+	buf.Write(root.body)
+	return buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key + ": " + value + "\r\n")
+}
+
+func textPart(mediaType, text string) (mimePart, error) {
+	var body bytes.Buffer
+	qp := quotedprintable.NewWriter(&body)
+	if _, err := qp.Write([]byte(text)); err != nil {
+		return mimePart{}, err
+	}
+	if err := qp.Close(); err != nil {
+		return mimePart{}, err
+	}
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", mediaType+"; charset=utf-8")
+	h.Set("Content-Transfer-Encoding", "quoted-printable")
+	return mimePart{header: h, body: body.Bytes()}, nil
+}
+
+func attachmentPart(a models.Attachment, inline bool) mimePart {
+	ctype := a.ContentType
+	if ctype == "" {
+		ctype = mime.TypeByExtension(filepath.Ext(a.Filename))
+	}
+	if ctype == "" {
+		ctype = "application/octet-stream"
+	}
+
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", mime.FormatMediaType(ctype, map[string]string{"name": a.Filename}))
+	h.Set("Content-Transfer-Encoding", "base64")
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+		h.Set("Content-ID", "<"+a.ContentID+">")
+	}
+	h.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+
+	// base64 in 76-character lines as RFC 2045 requires
+	encoded := base64.StdEncoding.EncodeToString(a.Data)
+	var body bytes.Buffer
+	for len(encoded) > 76 {
+		body.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	body.WriteString(encoded + "\r\n")
+	return mimePart{header: h, body: body.Bytes()}
+}
+
+func multipartOf(subtype string, parts ...mimePart) (mimePart, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		w, err := mw.CreatePart(p.header)
+		if err != nil {
+			return mimePart{}, err
+		}
+		if _, err := w.Write(p.body); err != nil {
+			return mimePart{}, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return mimePart{}, err
+	}
+	params := map[string]string{"boundary": mw.Boundary()}
+	if subtype == "related" {
+		// RFC 2387: name the type of the root part
+		root, _, _ := mime.ParseMediaType(parts[0].header.Get("Content-Type"))
+		params["type"] = root
+	}
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", mime.FormatMediaType("multipart/"+subtype, params))
+	return mimePart{header: h, body: body.Bytes()}, nil
+}
+
+// domainOf returns the domain of an address, for Message-ID.
+func domainOf(addr string) string {
+	if i := strings.LastIndexByte(addr, '@'); i >= 0 {
+		return strings.TrimRight(addr[i+1:], ">")
+	}
+	return "localhost"
+}
@@ -0,0 +1,74 @@
+package channels
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+// httpFailureClass classifies a request the provider answered with a
+// non-success status: refused credentials, throttling and server trouble
+// are retried, while any other rejection of the request is permanent.
+func httpFailureClass(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return models.ErrorAuth
+	case status == http.StatusTooManyRequests:
+		return models.ErrorRateLimited
+	case status == http.StatusRequestTimeout || status >= 500:
+		return models.ErrorTransient
+	case status >= 400:
+		return models.ErrorPermanent
+	}
+	return models.ErrorTransient
+}
+
+// lookupFailureClass classifies a failed store lookup of what a
+// notification addresses: gone for good, or a store problem to retry.
+func lookupFailureClass(err error) string {
+	if errors.Is(err, storage.ErrNotFound) {
+		return models.ErrorPermanent
+	}
+	return models.ErrorTransient
+}
+
+// retryAfter reads a Retry-After header, given in seconds or as an HTTP
+// date; 0 if absent.
+func retryAfter(h http.Header) time.Duration {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// rateLimited fills in a rate-limited failure and, when known, how long the
+// provider asked us to wait.
+func rateLimited(result *models.DispatchResult, provider string, wait time.Duration) {
+	result.ErrorClass, result.RetryAfter = models.ErrorRateLimited, wait
+	result.Error = provider + " rate limited"
+	if wait > 0 {
+		result.Error = fmt.Sprintf("%s rate limited, retry after %s", provider, wait)
+	}
+}
+
+// withoutURL drops the request URL from an HTTP client error before it is
+// logged or stored: the URL may be a credential, such as a Telegram bot
+// token, a Slack incoming-webhook URL or a push subscription endpoint.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
@@ -0,0 +1,261 @@
+package channels
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"notification-service/internal/logger"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	defaultMQTTTopic   = "vedsagar/alerts/{recipient}"
+	defaultMQTTQoS     = 1
+	mqttConnectTimeout = 10 * time.Second
+	mqttPublishTimeout = 15 * time.Second
+)
+
+// MQTTConfig describes the broker alerts are published to.
+type MQTTConfig struct {
+	BrokerURL string // tcp://, ssl://, ws:// or wss://
+	ClientID  string // must be unique per service instance; random if empty
+	Username  string
+	Password  string
+
+	// TopicPattern is expanded per notification. {recipient} is the
+	// address, e.g. "kochi/harbour-siren"; {severity}, {hazard} and {event}
+	// come from the alert.
+	TopicPattern string
+	QoS          byte // 1 (default) waits for PUBACK, 2 for PUBCOMP
+	// Retain keeps the last alert on each topic so a device that
+	// reconnects, e.g. after a power cut, shows it straight away.
+	Retain bool
+
+	ConnectTimeout time.Duration
+	PublishTimeout time.Duration
+}
+
+// MQTTHandler publishes alerts for sirens and warning displays on an MQTT
+// broker. A send succeeds once the broker confirms the publish, so QoS 0 is
+// not offered.
+type MQTTHandler struct {
+	cfg    MQTTConfig
+	events storage.EventStore
+
+	mu     sync.Mutex
+	client mqtt.Client
+}
+
+// NewMQTTHandler initializes MQTTHandler from MQTT_* env vars
+func NewMQTTHandler(events storage.EventStore) *MQTTHandler {
+	qos, _ := strconv.Atoi(os.Getenv("MQTT_QOS"))
+	retain, _ := strconv.ParseBool(os.Getenv("MQTT_RETAIN"))
+	return NewMQTTHandlerWithConfig(events, MQTTConfig{
+		BrokerURL:    os.Getenv("MQTT_BROKER_URL"),
+		ClientID:     os.Getenv("MQTT_CLIENT_ID"),
+		Username:     os.Getenv("MQTT_USERNAME"),
+		Password:     os.Getenv("MQTT_PASSWORD"),
+		TopicPattern: os.Getenv("MQTT_TOPIC_PATTERN"),
+		QoS:          byte(qos),
+		Retain:       retain,
+	})
+}
+
+// NewMQTTHandlerWithConfig fills in defaults for an explicit configuration.
+// The broker is connected to on the first send.
+func NewMQTTHandlerWithConfig(events storage.EventStore, cfg MQTTConfig) *MQTTHandler {
+	if cfg.ClientID == "" {
+		b := make([]byte, 4)
+		_, _ = rand.Read(b)
+		cfg.ClientID = "vedsagar-notify-" + hex.EncodeToString(b)
+	}
+	if cfg.TopicPattern == "" {
+		cfg.TopicPattern = defaultMQTTTopic
+	}
+	if cfg.QoS != 1 && cfg.QoS != 2 {
+		cfg.QoS = defaultMQTTQoS
+	}
+	if cfg.ConnectTimeout <= 0 {
+		cfg.ConnectTimeout = mqttConnectTimeout
+	}
+	if cfg.PublishTimeout <= 0 {
+		cfg.PublishTimeout = mqttPublishTimeout
+	}
+	return &MQTTHandler{cfg: cfg, events: events}
+}
+
+// mqttPayload is kept short for microcontrollers with little memory.
+type mqttPayload struct {
+	V        int    `json:"v"`
+	Event    string `json:"id"`
+	Notif    string `json:"n"` // the same across retries, for deduplication
+	Severity string `json:"sev,omitempty"`
+	Hazard   string `json:"haz,omitempty"`
+	Expires  int64  `json:"exp,omitempty"` // unix seconds; devices clear the alert after it
+	Sent     int64  `json:"ts"`
+	Message  string `json:"msg"`
+}
+
+func (h *MQTTHandler) Send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	result := h.send(ctx, notif)
+	result.NotificationID = notif.ID
+	result.Timestamp = time.Now()
+	if !result.Success {
+		logger.Error(fmt.Errorf("[MQTT] Error publishing to %s: %s", notif.Recipient, result.Error))
+		return result
+	}
+	logger.Info(fmt.Sprintf("[MQTT] Published to %s", result.APIResponse))
+	return result
+}
+
+func (h *MQTTHandler) send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	if h.cfg.BrokerURL == "" {
+		return models.DispatchResult{Error: "mqtt not configured: MQTT_BROKER_URL is required", ErrorClass: models.ErrorAuth}
+	}
+	hazard := ""
+	record, err := h.events.GetEvent(ctx, notif.EventID)
+	switch {
+	case err == nil:
+		hazard = record.Event.Type
+	case errors.Is(err, storage.ErrNotFound):
+	default:
+		return models.DispatchResult{Error: fmt.Sprintf("load event %s: %v", notif.EventID, err), ErrorClass: models.ErrorTransient}
+	}
+
+	topic, err := h.topic(notif, hazard)
+	if err != nil {
+		return models.DispatchResult{Error: err.Error(), ErrorClass: models.ErrorPermanent}
+	}
+	p := mqttPayload{
+		V:        1,
+		Event:    notif.EventID,
+		Notif:    notif.ID,
+		Severity: strings.ToLower(notif.Severity),
+		Hazard:   hazard,
+		Sent:     time.Now().Unix(),
+		Message:  notif.Message,
+	}
+	if notif.ExpiresAt != nil {
+		p.Expires = notif.ExpiresAt.Unix()
+	}
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("encode payload: %v", err), ErrorClass: models.ErrorPermanent}
+	}
+
+	client, err := h.connect()
+	if err != nil {
+		return models.DispatchResult{Error: err.Error()}
+	}
+	token := client.Publish(topic, h.cfg.QoS, h.cfg.Retain, payload)
+	if !token.WaitTimeout(h.cfg.PublishTimeout) {
+		return models.DispatchResult{Error: fmt.Sprintf("no broker confirmation within %s", h.cfg.PublishTimeout), ErrorClass: models.ErrorTransient}
+	}
+	if err := token.Error(); err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("publish: %v", err), ErrorClass: models.ErrorTransient}
+	}
+	// a completed QoS 1 or 2 token means PUBACK or PUBCOMP arrived
+	response := topic
+	if pt, ok := token.(*mqtt.PublishToken); ok {
+		response = fmt.Sprintf("%s#%d", topic, pt.MessageID())
+	}
+	return models.DispatchResult{Success: true, APIResponse: response}
+}
+
+// topic expands the topic pattern. Substituted values may not contain
+// wildcards; only the recipient may span several topic levels.
+func (h *MQTTHandler) topic(notif models.Notification, hazard string) (string, error) {
+	if notif.Recipient == "" || strings.ContainsAny(notif.Recipient, "+#") {
+		return "", fmt.Errorf("invalid mqtt address %q", notif.Recipient)
+	}
+	level := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return strings.NewReplacer("/", "_", "+", "_", "#", "_").Replace(strings.ToLower(s))
+	}
+	topic := strings.NewReplacer(
+		"{recipient}", strings.Trim(notif.Recipient, "/"),
+		"{severity}", level(notif.Severity),
+		"{hazard}", level(hazard),
+		"{event}", level(notif.EventID),
+	).Replace(h.cfg.TopicPattern)
+	return topic, nil
+}
+
+// connect returns the broker connection, dialling it on first use or after
+// an earlier attempt failed. Once connected the client reconnects by itself;
+// while it does, connect waits for it rather than dialling a second client
+// with the same client ID, which the broker would play off against the
+// first.
+func (h *MQTTHandler) connect() (mqtt.Client, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.client != nil {
+		// IsConnected is also true while an automatic reconnect is under way
+		if h.client.IsConnected() {
+			return h.client, h.awaitReconnect()
+		}
+		h.client.Disconnect(0)
+		h.client = nil
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(h.cfg.BrokerURL).
+		SetClientID(h.cfg.ClientID).
+		SetUsername(h.cfg.Username).
+		SetPassword(h.cfg.Password).
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		SetConnectTimeout(h.cfg.ConnectTimeout).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			logger.Error(fmt.Errorf("[MQTT] connection lost: %w", err))
+		})
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(h.cfg.ConnectTimeout) {
+		client.Disconnect(0)
+		return nil, fmt.Errorf("connect to %s: timed out", h.cfg.BrokerURL)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", h.cfg.BrokerURL, err)
+	}
+	logger.Info(fmt.Sprintf("[MQTT] Connected to %s as %s", h.cfg.BrokerURL, h.cfg.ClientID))
+	h.client = client
+	return client, nil
+}
+
+// awaitReconnect waits up to the connect timeout for an automatic
+// reconnect of h.client to finish.
+func (h *MQTTHandler) awaitReconnect() error {
+	deadline := time.Now().Add(h.cfg.ConnectTimeout)
+	for !h.client.IsConnectionOpen() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("reconnect to %s: timed out", h.cfg.BrokerURL)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return nil
+}
+
+// Close disconnects from the broker, letting in-flight publishes finish.
+func (h *MQTTHandler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.client != nil {
+		h.client.Disconnect(250)
+		h.client = nil
+	}
+}
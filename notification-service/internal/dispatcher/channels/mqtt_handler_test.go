@@ -0,0 +1,145 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+
+	mqttserver "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+// brokerLog records the connections and publishes an embedded broker sees.
+type brokerLog struct {
+	mqttserver.HookBase
+
+	mu        sync.Mutex
+	connects  int
+	published []packets.Packet
+}
+
+func (b *brokerLog) ID() string { return "broker-log" }
+
+func (b *brokerLog) Provides(hook byte) bool {
+	return hook == mqttserver.OnConnect || hook == mqttserver.OnPublished
+}
+
+func (b *brokerLog) OnConnect(cl *mqttserver.Client, pk packets.Packet) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.connects++
+	return nil
+}
+
+func (b *brokerLog) OnPublished(cl *mqttserver.Client, pk packets.Packet) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.published = append(b.published, pk)
+}
+
+func (b *brokerLog) counts() (connects, published int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.connects, len(b.published)
+}
+
+// startBroker runs an embedded MQTT broker on a free local port.
+func startBroker(t *testing.T) (*mqttserver.Server, *brokerLog, string) {
+	t.Helper()
+	server := mqttserver.New(&mqttserver.Options{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	log := &brokerLog{}
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatalf("add auth hook: %v", err)
+	}
+	if err := server.AddHook(log, nil); err != nil {
+		t.Fatalf("add log hook: %v", err)
+	}
+	tcp := listeners.NewTCP(listeners.Config{ID: "tcp", Address: "127.0.0.1:0"})
+	if err := server.AddListener(tcp); err != nil {
+		t.Fatalf("add listener: %v", err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server, log, "tcp://" + tcp.Address()
+}
+
+func TestMQTTPublishesAlerts(t *testing.T) {
+	_, log, url := startBroker(t)
+	ctx := context.Background()
+	mem := memorystore.New()
+	if err := mem.SaveEvent(ctx, models.EventRecord{Event: models.Event{ID: "evt-1", Type: "Tsunami"}}); err != nil {
+		t.Fatalf("SaveEvent: %v", err)
+	}
+	expires := time.Now().Add(time.Hour)
+	notif := models.Notification{ID: "notif-1", EventID: "evt-1", Recipient: "kochi/siren-1",
+		Severity: "Extreme", Message: "Move to high ground", ExpiresAt: &expires}
+
+	for _, qos := range []byte{1, 2} {
+		h := NewMQTTHandlerWithConfig(mem, MQTTConfig{BrokerURL: url, QoS: qos, Retain: true,
+			TopicPattern: "vs/{hazard}/{severity}/{recipient}"})
+		res := h.Send(ctx, notif)
+		h.Close()
+		if !res.Success {
+			t.Fatalf("QoS %d: %s", qos, res.Error)
+		}
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if len(log.published) != 2 {
+		t.Fatalf("broker saw %d publishes, want 2", len(log.published))
+	}
+	for i, pk := range log.published {
+		if pk.TopicName != "vs/tsunami/extreme/kochi/siren-1" || pk.FixedHeader.Qos != byte(i+1) || !pk.FixedHeader.Retain {
+			t.Errorf("publish %d: topic %s QoS %d retain %v", i, pk.TopicName, pk.FixedHeader.Qos, pk.FixedHeader.Retain)
+		}
+		var p mqttPayload
+		if err := json.Unmarshal(pk.Payload, &p); err != nil {
+			t.Fatalf("payload: %v", err)
+		}
+		if p.Notif != "notif-1" || p.Hazard != "Tsunami" || p.Expires != expires.Unix() || p.Message != notif.Message {
+			t.Errorf("payload = %+v", p)
+		}
+	}
+}
+
+func TestMQTTReusesReconnectingClient(t *testing.T) {
+	server, log, url := startBroker(t)
+	ctx := context.Background()
+	mem := memorystore.New()
+	h := NewMQTTHandlerWithConfig(mem, MQTTConfig{BrokerURL: url, ClientID: "notify-test", ConnectTimeout: 5 * time.Second})
+	defer h.Close()
+	notif := models.Notification{ID: "notif-1", EventID: "evt-1", Recipient: "kochi/siren-1", Message: "m"}
+
+	if res := h.Send(ctx, notif); !res.Success {
+		t.Fatalf("first send: %s", res.Error)
+	}
+	// the broker drops the connection, e.g. on a restart behind a load balancer
+	cl, ok := server.Clients.Get("notify-test")
+	if !ok {
+		t.Fatal("client not connected to the broker")
+	}
+	cl.Stop(errors.New("dropped by test"))
+
+	if res := h.Send(ctx, notif); !res.Success {
+		t.Fatalf("send after the connection dropped: %s", res.Error)
+	}
+	// a second client with the same ID would make the broker disconnect
+	// them in turn; give any such takeover time to show
+	time.Sleep(500 * time.Millisecond)
+	if connects, published := log.counts(); connects != 2 || published != 2 {
+		t.Errorf("broker saw %d connects and %d publishes, want 2 of each", connects, published)
+	}
+}
@@ -0,0 +1,184 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"notification-service/pkg/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// apnsTokenLifetime is how long a provider token is reused. APNs rejects
+// tokens older than an hour and refreshing more often than every 20 minutes.
+const apnsTokenLifetime = 50 * time.Minute
+
+// apnsClient sends through the APNs HTTP/2 provider API with token-based
+// (ES256 JWT) authentication.
+type apnsClient struct {
+	http     *http.Client
+	endpoint string
+	topic    string
+	keyID    string
+	teamID   string
+	key      *ecdsa.PrivateKey
+
+	mu     sync.Mutex
+	token  string
+	issued time.Time
+}
+
+func newAPNsClient(cfg PushConfig) (*apnsClient, error) {
+	key, err := jwt.ParseECPrivateKeyFromPEM(cfg.APNsKey)
+	if err != nil {
+		return nil, fmt.Errorf("parse APNs key: %w", err)
+	}
+	if cfg.APNsKeyID == "" || cfg.APNsTeamID == "" || cfg.APNsTopic == "" {
+		return nil, errors.New("APNs needs APNS_KEY_ID, APNS_TEAM_ID and APNS_TOPIC")
+	}
+	return &apnsClient{
+		http:     cfg.HTTPClient,
+		endpoint: strings.TrimRight(cfg.APNsEndpoint, "/"),
+		topic:    cfg.APNsTopic,
+		keyID:    cfg.APNsKeyID,
+		teamID:   cfg.APNsTeamID,
+		key:      key,
+	}, nil
+}
+
+type apnsAlert struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body"`
+}
+
+type apnsAPS struct {
+	Alert    apnsAlert `json:"alert"`
+	Sound    string    `json:"sound,omitempty"`
+	Category string    `json:"category,omitempty"`
+}
+
+// apnsAckCategory is the notification category the iOS app registers with
+// an acknowledge action.
+const apnsAckCategory = "VEDSAGAR_ACK"
+
+func (c *apnsClient) send(ctx context.Context, msg pushMessage) models.DispatchResult {
+	// custom keys sit next to "aps" at the top level of the payload
+	aps := apnsAPS{Alert: apnsAlert{Title: msg.Title, Body: msg.Body}, Sound: "default"}
+	if msg.Data["ack_url"] != "" {
+		aps.Category = apnsAckCategory
+	}
+	payload := map[string]any{"aps": aps}
+	for k, v := range msg.Data {
+		payload[k] = v
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("encode APNs payload: %v", err), ErrorClass: models.ErrorPermanent}
+	}
+
+	token, err := c.providerToken()
+	if err != nil {
+		return models.DispatchResult{Error: err.Error(), ErrorClass: models.ErrorAuth}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/3/device/"+msg.Token, bytes.NewReader(body))
+	if err != nil {
+		return models.DispatchResult{Error: err.Error(), ErrorClass: models.ErrorPermanent}
+	}
+	priority := "5"
+	if msg.Urgent {
+		priority = "10"
+	}
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("apns-topic", c.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", priority)
+	req.Header.Set("apns-expiration", apnsExpiration(msg.TTL))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("APNs request: %v", withoutURL(err)), ErrorClass: models.ErrorTransient}
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	result := models.DispatchResult{APIStatusCode: resp.StatusCode, APIResponse: string(respBody)}
+	if resp.StatusCode == http.StatusOK {
+		result.APIResponse = resp.Header.Get("apns-id")
+		result.Success = true
+		return result
+	}
+
+	var reply struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.Unmarshal(respBody, &reply)
+	result.Error = fmt.Sprintf("APNs %d %s", resp.StatusCode, reply.Reason)
+	result.ErrorCode = reply.Reason
+	result.ErrorClass = httpFailureClass(resp.StatusCode)
+
+	switch {
+	case resp.StatusCode == http.StatusGone || reply.Reason == "BadDeviceToken" || reply.Reason == "DeviceTokenNotForTopic":
+		// 410 means the app was uninstalled; the others that the token is
+		// not one of ours
+		result.DeadAddress, result.ErrorClass = true, models.ErrorPermanent
+	case reply.Reason == "ExpiredProviderToken" || reply.Reason == "InvalidProviderToken":
+		c.resetToken()
+		result.ErrorClass = models.ErrorAuth
+	case resp.StatusCode == http.StatusTooManyRequests:
+		// TooManyProviderTokenUpdates or TooManyRequests for this device
+		rateLimited(&result, "APNs", retryAfter(resp.Header))
+		result.Error += ": " + reply.Reason
+	case resp.StatusCode == http.StatusServiceUnavailable:
+		result.RetryAfter = retryAfter(resp.Header)
+	}
+	return result
+}
+
+// providerToken returns the cached provider JWT, signing a new one once it
+// is apnsTokenLifetime old.
+func (c *apnsClient) providerToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Since(c.issued) < apnsTokenLifetime {
+		return c.token, nil
+	}
+	now := time.Now()
+	t := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": c.teamID,
+		"iat": now.Unix(),
+	})
+	t.Header["kid"] = c.keyID
+	signed, err := t.SignedString(c.key)
+	if err != nil {
+		return "", fmt.Errorf("sign APNs provider token: %w", err)
+	}
+	c.token, c.issued = signed, now
+	return signed, nil
+}
+
+func (c *apnsClient) resetToken() {
+	c.mu.Lock()
+	c.token = ""
+	c.mu.Unlock()
+}
+
+// apnsExpiration converts a TTL into the apns-expiration header: a Unix time,
+// or 0 for a single delivery attempt.
+func apnsExpiration(ttl time.Duration) string {
+	if ttl <= 0 {
+		return "0"
+	}
+	return strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+}
@@ -0,0 +1,275 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"notification-service/pkg/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// serviceAccount is the subset of a Google service account key file used to
+// obtain OAuth access tokens.
+type serviceAccount struct {
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// fcmClient sends through the FCM HTTP v1 API, authenticating with an OAuth
+// access token obtained by a service-account JWT grant.
+type fcmClient struct {
+	http     *http.Client
+	sendURL  string
+	tokenURL string
+	account  serviceAccount
+	key      *rsa.PrivateKey
+
+	mu          sync.Mutex
+	accessToken string
+	expires     time.Time
+}
+
+func newFCMClient(cfg PushConfig) (*fcmClient, error) {
+	var sa serviceAccount
+	if err := json.Unmarshal(cfg.FCMCredentials, &sa); err != nil {
+		return nil, fmt.Errorf("parse FCM service account: %w", err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(sa.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("parse FCM service account key: %w", err)
+	}
+	project := cfg.FCMProjectID
+	if project == "" {
+		project = sa.ProjectID
+	}
+	if project == "" || sa.ClientEmail == "" {
+		return nil, errors.New("FCM service account needs project_id and client_email")
+	}
+	tokenURL := cfg.FCMTokenURL
+	if tokenURL == "" {
+		tokenURL = sa.TokenURI
+	}
+	if tokenURL == "" {
+		tokenURL = "https://oauth2.googleapis.com/token"
+	}
+	return &fcmClient{
+		http:     cfg.HTTPClient,
+		sendURL:  strings.TrimRight(cfg.FCMEndpoint, "/") + "/v1/projects/" + url.PathEscape(project) + "/messages:send",
+		tokenURL: tokenURL,
+		account:  sa,
+		key:      key,
+	}, nil
+}
+
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data"`
+	Android      fcmAndroid        `json:"android"`
+	APNs         fcmAPNs           `json:"apns"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body"`
+}
+
+type fcmAndroid struct {
+	Priority string `json:"priority"` // "HIGH" or "NORMAL"
+	TTL      string `json:"ttl"`      // duration in seconds, e.g. "3600s"
+}
+
+// fcmAPNs carries the APNs headers FCM uses for iOS devices registered
+// through Firebase.
+type fcmAPNs struct {
+	Headers map[string]string `json:"headers"`
+}
+
+// fcmError is the error body of the v1 API.
+type fcmError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			Type      string `json:"@type"`
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+func (c *fcmClient) send(ctx context.Context, msg pushMessage) models.DispatchResult {
+	priority, apnsPriority := "NORMAL", "5"
+	if msg.Urgent {
+		priority, apnsPriority = "HIGH", "10"
+	}
+	body, err := json.Marshal(fcmRequest{Message: fcmMessage{
+		Token:        msg.Token,
+		Notification: fcmNotification{Title: msg.Title, Body: msg.Body},
+		Data:         msg.Data,
+		Android: fcmAndroid{
+			Priority: priority,
+			TTL:      strconv.FormatInt(int64(msg.TTL/time.Second), 10) + "s",
+		},
+		APNs: fcmAPNs{Headers: map[string]string{
+			"apns-priority":   apnsPriority,
+			"apns-expiration": apnsExpiration(msg.TTL),
+		}},
+	}})
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("encode FCM message: %v", err), ErrorClass: models.ErrorPermanent}
+	}
+
+	token, err := c.token(ctx)
+	if err != nil {
+		return models.DispatchResult{Error: err.Error(), ErrorClass: models.ErrorAuth}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.sendURL, bytes.NewReader(body))
+	if err != nil {
+		return models.DispatchResult{Error: err.Error(), ErrorClass: models.ErrorPermanent}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("FCM request: %v", err), ErrorClass: models.ErrorTransient}
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	result := models.DispatchResult{APIStatusCode: resp.StatusCode, APIResponse: string(respBody)}
+	if resp.StatusCode == http.StatusOK {
+		var ok struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(respBody, &ok) == nil && ok.Name != "" {
+			result.APIResponse = ok.Name
+		}
+		result.Success = true
+		return result
+	}
+
+	var fe fcmError
+	_ = json.Unmarshal(respBody, &fe)
+	code := fe.Error.Status
+	for _, d := range fe.Error.Details {
+		if d.ErrorCode != "" {
+			code = d.ErrorCode
+		}
+	}
+	if code == "" {
+		code = http.StatusText(resp.StatusCode)
+	}
+	result.Error = fmt.Sprintf("FCM %d %s", resp.StatusCode, code)
+	if fe.Error.Message != "" {
+		result.Error += ": " + fe.Error.Message
+	}
+	result.ErrorCode = code
+	result.ErrorClass = httpFailureClass(resp.StatusCode)
+
+	switch {
+	case code == "UNREGISTERED" || code == "SENDER_ID_MISMATCH":
+		// the app was uninstalled or the token belongs to another project
+		result.DeadAddress, result.ErrorClass = true, models.ErrorPermanent
+	case code == "INVALID_ARGUMENT":
+		// a malformed token is reported like a malformed message
+		result.DeadAddress = strings.Contains(strings.ToLower(fe.Error.Message), "registration token")
+		result.ErrorClass = models.ErrorPermanent
+	case resp.StatusCode == http.StatusUnauthorized:
+		// the access token was revoked or expired early; fetch a new one
+		c.resetToken()
+	case code == "QUOTA_EXCEEDED" || resp.StatusCode == http.StatusTooManyRequests:
+		rateLimited(&result, "FCM", retryAfter(resp.Header))
+		result.Error += ": " + code
+	case resp.StatusCode == http.StatusServiceUnavailable:
+		// FCM asks to be left alone for a while when overloaded
+		result.RetryAfter = retryAfter(resp.Header)
+	}
+	return result
+}
+
+// token returns a cached OAuth access token, fetching a new one a minute
+// before the current one expires.
+func (c *fcmClient) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.accessToken != "" && time.Now().Before(c.expires) {
+		return c.accessToken, nil
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   c.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   c.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+	assertion := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if c.account.PrivateKeyID != "" {
+		assertion.Header["kid"] = c.account.PrivateKeyID
+	}
+	signed, err := assertion.SignedString(c.key)
+	if err != nil {
+		return "", fmt.Errorf("sign FCM token request: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signed},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("FCM token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("FCM token request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("FCM token request: %d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil || tok.AccessToken == "" {
+		return "", fmt.Errorf("FCM token request: unexpected response %q", string(body))
+	}
+	c.accessToken = tok.AccessToken
+	c.expires = now.Add(time.Duration(tok.ExpiresIn)*time.Second - time.Minute)
+	return c.accessToken, nil
+}
+
+func (c *fcmClient) resetToken() {
+	c.mu.Lock()
+	c.accessToken = ""
+	c.mu.Unlock()
+}
@@ -3,32 +3,234 @@ package channels
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
 	"time"
 
 	"notification-service/internal/logger"
 	"notification-service/pkg/models"
 )
 
-type PushHandler struct{}
+const (
+	defaultFCMEndpoint  = "https://fcm.googleapis.com"
+	defaultAPNsEndpoint = "https://api.push.apple.com"
+	defaultDeepLinkBase = "vedsagar://events/"
 
-func (h *PushHandler) Send(ctx context.Context, notif models.Notification) models.DispatchResult {
-	select {
-	case <-time.After(120 * time.Millisecond):
-	case <-ctx.Done():
-		return models.DispatchResult{
-			NotificationID: notif.ID,
-			Success:        false,
-			Error:          "context cancelled",
-			Timestamp:      time.Now(),
+	// defaultPushTTL bounds how long a push service holds an alert for an
+	// offline device when the event has no expiry.
+	defaultPushTTL = 24 * time.Hour
+	pushTimeout    = 30 * time.Second
+)
+
+// PushConfig describes the push services the PushHandler delivers through.
+// Either service may be left unconfigured.
+type PushConfig struct {
+	// FCMCredentials is a Google service account key (JSON) with the
+	// Firebase Cloud Messaging API enabled.
+	FCMCredentials []byte
+	FCMProjectID   string // defaults to the service account's project
+	FCMEndpoint    string // defaults to defaultFCMEndpoint
+	FCMTokenURL    string // defaults to the service account's token_uri
+
+	APNsKey      []byte // .p8 signing key (PEM)
+	APNsKeyID    string
+	APNsTeamID   string
+	APNsTopic    string // the app's bundle ID
+	APNsEndpoint string // defaults to defaultAPNsEndpoint; sandbox is https://api.sandbox.push.apple.com
+
+	// DeepLinkBase is prefixed to the event ID to form the link opened
+	// when the notification is tapped.
+	DeepLinkBase string
+
+	HTTPClient *http.Client
+}
+
+// PushHandler sends push notifications to Android devices through FCM HTTP
+// v1 and to iOS devices through APNs. Addresses are device tokens, prefixed
+// with "apns:" for APNs; bare or "fcm:" tokens go to FCM.
+type PushHandler struct {
+	cfg  PushConfig
+	fcm  *fcmClient
+	apns *apnsClient
+	err  error // configuration problem reported on every send
+}
+
+// NewPushHandler initializes PushHandler from FCM_* and APNS_* env vars
+func NewPushHandler() *PushHandler {
+	cfg := PushConfig{
+		FCMProjectID: os.Getenv("FCM_PROJECT_ID"),
+		FCMEndpoint:  os.Getenv("FCM_ENDPOINT"),
+		FCMTokenURL:  os.Getenv("FCM_TOKEN_URL"),
+		APNsKeyID:    os.Getenv("APNS_KEY_ID"),
+		APNsTeamID:   os.Getenv("APNS_TEAM_ID"),
+		APNsTopic:    os.Getenv("APNS_TOPIC"),
+		APNsEndpoint: os.Getenv("APNS_ENDPOINT"),
+		DeepLinkBase: os.Getenv("PUSH_DEEP_LINK_BASE"),
+	}
+
+	var errs []string
+	cfg.FCMCredentials = []byte(os.Getenv("FCM_SERVICE_ACCOUNT_JSON"))
+	if path := os.Getenv("FCM_SERVICE_ACCOUNT_FILE"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("read FCM_SERVICE_ACCOUNT_FILE: %v", err))
 		}
+		cfg.FCMCredentials = b
+	}
+	if path := os.Getenv("APNS_KEY_FILE"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("read APNS_KEY_FILE: %v", err))
+		}
+		cfg.APNsKey = b
+	}
+
+	h := NewPushHandlerWithConfig(cfg)
+	if len(errs) > 0 {
+		h.err = fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	if h.err != nil {
+		logger.Error(fmt.Errorf("[PUSH] configuration: %w", h.err))
+	}
+	return h
+}
+
+// NewPushHandlerWithConfig fills in defaults for an explicit configuration,
+// e.g. one pointing at local FCM and APNs endpoints in tests.
+func NewPushHandlerWithConfig(cfg PushConfig) *PushHandler {
+	if cfg.FCMEndpoint == "" {
+		cfg.FCMEndpoint = defaultFCMEndpoint
+	}
+	if cfg.APNsEndpoint == "" {
+		cfg.APNsEndpoint = defaultAPNsEndpoint
+	}
+	if cfg.DeepLinkBase == "" {
+		cfg.DeepLinkBase = defaultDeepLinkBase
+	}
+	if cfg.HTTPClient == nil {
+		// the default transport negotiates HTTP/2 over TLS, which APNs requires
+		cfg.HTTPClient = &http.Client{Timeout: pushTimeout}
 	}
 
-	logger.Info(fmt.Sprintf("[PUSH] Sent to device %s: %s", notif.Recipient, notif.Message))
+	h := &PushHandler{cfg: cfg}
+	var errs []string
+	if len(cfg.FCMCredentials) > 0 {
+		fcm, err := newFCMClient(cfg)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		h.fcm = fcm
+	}
+	if len(cfg.APNsKey) > 0 {
+		apns, err := newAPNsClient(cfg)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		h.apns = apns
+	}
+	if len(errs) > 0 {
+		h.err = fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return h
+}
+
+// pushMessage is the service-independent content of one push notification.
+type pushMessage struct {
+	Token  string
+	Title  string
+	Body   string
+	Data   map[string]string // event_id, notification_id, severity, deep_link, ack_url
+	Urgent bool              // delivered immediately, waking the device
+	TTL    time.Duration     // how long the service may hold it for an offline device
+}
+
+// Send delivers the notification to the device's push service. Tokens the
+// service reports as unregistered are flagged DeadAddress so the directory
+// stops using them; other rejections of the request are permanent, while
+// throttling, server errors and auth problems are retried.
+func (h *PushHandler) Send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	platform, token := splitPushAddress(notif.Recipient)
+	msg := h.message(notif, token, time.Now())
+
+	var result models.DispatchResult
+	switch {
+	case h.err != nil:
+		result.Error = fmt.Sprintf("push not configured: %v", h.err)
+		result.ErrorClass = models.ErrorAuth
+	case token == "":
+		result.Error = "empty device token"
+		result.ErrorClass = models.ErrorPermanent
+	case platform == "apns" && h.apns == nil:
+		result.Error = "push not configured: APNS_KEY_FILE, APNS_KEY_ID, APNS_TEAM_ID and APNS_TOPIC are required"
+		result.ErrorClass = models.ErrorAuth
+	case platform == "apns":
+		result = h.apns.send(ctx, msg)
+	case h.fcm == nil:
+		result.Error = "push not configured: FCM_SERVICE_ACCOUNT_FILE or FCM_SERVICE_ACCOUNT_JSON is required"
+		result.ErrorClass = models.ErrorAuth
+	default:
+		result = h.fcm.send(ctx, msg)
+	}
+	result.NotificationID = notif.ID
+	result.Timestamp = time.Now()
+
+	if !result.Success {
+		logger.Error(fmt.Errorf("[PUSH] Error sending to %s device %s: %s", platform, shortToken(token), result.Error))
+		return result
+	}
+	logger.Info(fmt.Sprintf("[PUSH] Sent to %s device %s: %s", platform, shortToken(token), result.APIResponse))
+	return result
+}
+
+// message builds the push content: priority and lifetime follow the event's
+// severity and expiry, and the data payload lets the app open the event and,
+// for alerts that ask for it, show an acknowledge button posting to ack_url.
+func (h *PushHandler) message(notif models.Notification, token string, now time.Time) pushMessage {
+	msg := pushMessage{
+		Token: token,
+		Title: notif.Subject,
+		Body:  notif.Message,
+		Data: map[string]string{
+			"event_id":        notif.EventID,
+			"notification_id": notif.ID,
+			"severity":        notif.Severity,
+			"deep_link":       h.cfg.DeepLinkBase + url.PathEscape(notif.EventID),
+		},
+		Urgent: models.SeverityAtLeast(notif.Severity, "severe"),
+		TTL:    pushTTL(notif, now),
+	}
+	if notif.AckURL != "" {
+		msg.Data["ack_url"] = notif.AckURL
+	}
+	return msg
+}
+
+// pushTTL is how long a push service may hold the notification: until the
+// event expires, or defaultPushTTL.
+func pushTTL(notif models.Notification, now time.Time) time.Duration {
+	if notif.ExpiresAt == nil {
+		return defaultPushTTL
+	}
+	return max(notif.ExpiresAt.Sub(now).Truncate(time.Second), 0)
+}
+
+// splitPushAddress separates the platform prefix from a device token. FCM
+// tokens may themselves contain colons, so only known prefixes are split.
+func splitPushAddress(addr string) (platform, token string) {
+	for _, p := range []string{"apns", "fcm"} {
+		if t, ok := strings.CutPrefix(addr, p+":"); ok {
+			return p, t
+		}
+	}
+	return "fcm", addr
+}
 
-	return models.DispatchResult{
-		NotificationID: notif.ID,
-		Success:        true,
-		Error:          "",
-		Timestamp:      time.Now(),
+// shortToken abbreviates a device token for logs.
+func shortToken(token string) string {
+	if len(token) <= 12 {
+		return token
 	}
+	return token[:6] + "…" + token[len(token)-6:]
 }
@@ -0,0 +1,323 @@
+package channels
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"notification-service/pkg/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeFCM serves the OAuth token endpoint and the FCM v1 send endpoint.
+// reply answers each send; it sees the access token the send carried.
+type fakeFCM struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	grants int
+	reply  func(w http.ResponseWriter, accessToken string)
+}
+
+func newFakeFCM(t *testing.T) *fakeFCM {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	f := &fakeFCM{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			http.Error(w, "bad grant", http.StatusBadRequest)
+			return
+		}
+		claims := jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(r.Form.Get("assertion"), claims, func(*jwt.Token) (any, error) {
+			return &key.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"RS256"}))
+		if err != nil || claims["iss"] != "push@vedsagar.iam.gserviceaccount.com" || claims["scope"] != fcmScope {
+			http.Error(w, "bad assertion", http.StatusUnauthorized)
+			return
+		}
+		f.mu.Lock()
+		f.grants++
+		n := f.grants
+		f.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": fmt.Sprintf("access-%d", n), "expires_in": 3600})
+	})
+	mux.HandleFunc("POST /v1/projects/vedsagar/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		reply := f.reply
+		f.mu.Unlock()
+		reply(w, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeFCM) handler(t *testing.T) *PushHandler {
+	t.Helper()
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(f.key)})
+	creds, _ := json.Marshal(map[string]string{
+		"project_id":   "vedsagar",
+		"private_key":  string(keyPEM),
+		"client_email": "push@vedsagar.iam.gserviceaccount.com",
+		"token_uri":    f.URL + "/token",
+	})
+	h := NewPushHandlerWithConfig(PushConfig{FCMCredentials: creds, FCMEndpoint: f.URL, HTTPClient: f.Client()})
+	if h.err != nil {
+		t.Fatalf("configure FCM: %v", h.err)
+	}
+	return h
+}
+
+func (f *fakeFCM) answer(reply func(w http.ResponseWriter, accessToken string)) {
+	f.mu.Lock()
+	f.reply = reply
+	f.mu.Unlock()
+}
+
+// fcmReply writes an FCM v1 error body.
+func fcmReply(status int, errorCode, message string, header http.Header) func(http.ResponseWriter, string) {
+	return func(w http.ResponseWriter, _ string) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{
+			"code": status, "message": message, "status": "ERROR",
+			"details": []map[string]string{{"@type": "type.googleapis.com/google.firebase.fcm.v1.FcmError", "errorCode": errorCode}},
+		}})
+	}
+}
+
+func pushNotification(recipient string) models.Notification {
+	return models.Notification{ID: "notif-1", EventID: "evt-1", Recipient: recipient, Channel: "push",
+		Severity: "severe", Subject: "Cyclone warning", Message: "Move to a shelter"}
+}
+
+func TestFCMSendsWithCachedAccessToken(t *testing.T) {
+	f := newFakeFCM(t)
+	var tokens []string
+	f.answer(func(w http.ResponseWriter, accessToken string) {
+		tokens = append(tokens, accessToken)
+		_, _ = w.Write([]byte(`{"name":"projects/vedsagar/messages/1"}`))
+	})
+	h := f.handler(t)
+
+	for range 2 {
+		res := h.Send(context.Background(), pushNotification("fcm:device-token-1"))
+		if !res.Success || res.APIResponse != "projects/vedsagar/messages/1" {
+			t.Fatalf("Send = %+v", res)
+		}
+	}
+	if f.grants != 1 || tokens[0] != "access-1" || tokens[1] != "access-1" {
+		t.Errorf("%d grants, sends carried %v; want one token reused", f.grants, tokens)
+	}
+}
+
+func TestFCMRefreshesRevokedAccessToken(t *testing.T) {
+	f := newFakeFCM(t)
+	f.answer(func(w http.ResponseWriter, accessToken string) {
+		if accessToken == "access-1" {
+			fcmReply(http.StatusUnauthorized, "THIRD_PARTY_AUTH_ERROR", "token revoked", nil)(w, accessToken)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"projects/vedsagar/messages/2"}`))
+	})
+	h := f.handler(t)
+
+	res := h.Send(context.Background(), pushNotification("device-token-1"))
+	if res.Success || res.ErrorClass != models.ErrorAuth {
+		t.Fatalf("first Send = %+v, want an auth failure", res)
+	}
+	res = h.Send(context.Background(), pushNotification("device-token-1"))
+	if !res.Success || f.grants != 2 {
+		t.Errorf("retry = %+v after %d grants, want success with a fresh token", res, f.grants)
+	}
+}
+
+func TestFCMClassifiesFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		reply      func(http.ResponseWriter, string)
+		class      string
+		dead       bool
+		retryAfter time.Duration
+	}{
+		{"Unregistered", fcmReply(http.StatusNotFound, "UNREGISTERED", "Requested entity was not found.", nil), models.ErrorPermanent, true, 0},
+		{"MalformedToken", fcmReply(http.StatusBadRequest, "INVALID_ARGUMENT", "The registration token is not a valid FCM registration token", nil), models.ErrorPermanent, true, 0},
+		{"BadMessage", fcmReply(http.StatusBadRequest, "INVALID_ARGUMENT", "Invalid value at 'message.android.ttl'", nil), models.ErrorPermanent, false, 0},
+		{"QuotaExceeded", fcmReply(http.StatusTooManyRequests, "QUOTA_EXCEEDED", "quota", http.Header{"Retry-After": {"30"}}), models.ErrorRateLimited, false, 30 * time.Second},
+		{"Unavailable", fcmReply(http.StatusServiceUnavailable, "UNAVAILABLE", "overloaded", http.Header{"Retry-After": {"120"}}), models.ErrorTransient, false, 2 * time.Minute},
+		{"InternalError", fcmReply(http.StatusInternalServerError, "INTERNAL", "oops", nil), models.ErrorTransient, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFCM(t)
+			f.answer(tt.reply)
+			res := f.handler(t).Send(context.Background(), pushNotification("device-token-1"))
+			if res.Success || res.ErrorClass != tt.class || res.DeadAddress != tt.dead || res.RetryAfter != tt.retryAfter {
+				t.Errorf("Send = %+v, want class %s, dead %v, retry after %s", res, tt.class, tt.dead, tt.retryAfter)
+			}
+		})
+	}
+}
+
+// fakeAPNs is an HTTP/2 APNs provider endpoint. reply answers each send;
+// it sees the provider token the send carried.
+type fakeAPNs struct {
+	*httptest.Server
+	key *ecdsa.PrivateKey
+
+	mu     sync.Mutex
+	tokens []string
+	reply  func(w http.ResponseWriter)
+}
+
+func newFakeAPNs(t *testing.T) *fakeAPNs {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate EC key: %v", err)
+	}
+	f := &fakeAPNs{key: key}
+	f.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			http.Error(w, "APNs requires HTTP/2", http.StatusHTTPVersionNotSupported)
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "bearer ")
+		parsed, err := jwt.Parse(token, func(*jwt.Token) (any, error) { return &key.PublicKey, nil },
+			jwt.WithValidMethods([]string{"ES256"}))
+		if err != nil || parsed.Header["kid"] != "KEY123" || r.Header.Get("apns-topic") != "in.vedsagar.app" ||
+			!strings.HasPrefix(r.URL.Path, "/3/device/") {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"reason":"InvalidProviderToken"}`))
+			return
+		}
+		f.mu.Lock()
+		f.tokens = append(f.tokens, token)
+		reply := f.reply
+		f.mu.Unlock()
+		reply(w)
+	}))
+	f.EnableHTTP2 = true
+	f.StartTLS()
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeAPNs) handler(t *testing.T) *PushHandler {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(f.key)
+	if err != nil {
+		t.Fatalf("marshal EC key: %v", err)
+	}
+	h := NewPushHandlerWithConfig(PushConfig{
+		APNsKey:      pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}),
+		APNsKeyID:    "KEY123",
+		APNsTeamID:   "TEAM456",
+		APNsTopic:    "in.vedsagar.app",
+		APNsEndpoint: f.URL,
+		HTTPClient:   f.Client(),
+	})
+	if h.err != nil {
+		t.Fatalf("configure APNs: %v", h.err)
+	}
+	return h
+}
+
+func (f *fakeAPNs) answer(reply func(w http.ResponseWriter)) {
+	f.mu.Lock()
+	f.reply = reply
+	f.mu.Unlock()
+}
+
+func apnsReply(status int, reason string, header http.Header) func(http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"reason":"` + reason + `"}`))
+	}
+}
+
+func TestAPNsSendsOverHTTP2(t *testing.T) {
+	f := newFakeAPNs(t)
+	f.answer(func(w http.ResponseWriter) {
+		w.Header().Set("apns-id", "apns-1")
+	})
+	h := f.handler(t)
+
+	for range 2 {
+		res := h.Send(context.Background(), pushNotification("apns:device-token-1"))
+		if !res.Success || res.APIResponse != "apns-1" {
+			t.Fatalf("Send = %+v", res)
+		}
+	}
+	if len(f.tokens) != 2 || f.tokens[0] != f.tokens[1] {
+		t.Errorf("provider tokens %d, want one reused", len(f.tokens))
+	}
+}
+
+func TestAPNsRefreshesExpiredProviderToken(t *testing.T) {
+	f := newFakeAPNs(t)
+	f.answer(apnsReply(http.StatusForbidden, "ExpiredProviderToken", nil))
+	h := f.handler(t)
+
+	res := h.Send(context.Background(), pushNotification("apns:device-token-1"))
+	if res.Success || res.ErrorClass != models.ErrorAuth {
+		t.Fatalf("first Send = %+v, want an auth failure", res)
+	}
+	f.answer(func(http.ResponseWriter) {})
+	if res := h.Send(context.Background(), pushNotification("apns:device-token-1")); !res.Success {
+		t.Fatalf("retry = %+v", res)
+	}
+	if len(f.tokens) != 2 || f.tokens[0] == f.tokens[1] {
+		t.Errorf("retry reused the expired provider token")
+	}
+}
+
+func TestAPNsClassifiesFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		reply      func(http.ResponseWriter)
+		class      string
+		dead       bool
+		retryAfter time.Duration
+	}{
+		{"Unregistered", apnsReply(http.StatusGone, "Unregistered", nil), models.ErrorPermanent, true, 0},
+		{"BadDeviceToken", apnsReply(http.StatusBadRequest, "BadDeviceToken", nil), models.ErrorPermanent, true, 0},
+		{"PayloadTooLarge", apnsReply(http.StatusRequestEntityTooLarge, "PayloadTooLarge", nil), models.ErrorPermanent, false, 0},
+		{"TooManyRequests", apnsReply(http.StatusTooManyRequests, "TooManyRequests", http.Header{"Retry-After": {"60"}}), models.ErrorRateLimited, false, time.Minute},
+		{"ServiceUnavailable", apnsReply(http.StatusServiceUnavailable, "ServiceUnavailable", http.Header{"Retry-After": {"90"}}), models.ErrorTransient, false, 90 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeAPNs(t)
+			f.answer(tt.reply)
+			res := f.handler(t).Send(context.Background(), pushNotification("apns:device-token-1"))
+			if res.Success || res.ErrorClass != tt.class || res.DeadAddress != tt.dead || res.RetryAfter != tt.retryAfter {
+				t.Errorf("Send = %+v, want class %s, dead %v, retry after %s", res, tt.class, tt.dead, tt.retryAfter)
+			}
+		})
+	}
+}
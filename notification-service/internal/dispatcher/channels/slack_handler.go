@@ -0,0 +1,190 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"notification-service/internal/logger"
+	"notification-service/pkg/models"
+)
+
+const (
+	defaultSlackBase = "https://slack.com"
+	slackMaxText     = 40000 // chat.postMessage truncates beyond this
+)
+
+// slackPermanentErrors are chat.postMessage and incoming webhook errors a
+// retry cannot fix.
+var slackPermanentErrors = []string{
+	"channel_not_found", "not_in_channel", "is_archived", "channel_is_archived",
+	"msg_too_long", "no_text", "invalid_blocks", "invalid_payload",
+	"restricted_action", "action_prohibited", "no_service", "no_service_id",
+	"posting_to_general_channel_denied", "user_not_found",
+}
+
+// slackAuthErrors are token problems a config fix resolves.
+var slackAuthErrors = []string{
+	"not_authed", "invalid_auth", "account_inactive", "token_revoked",
+	"token_expired", "missing_scope", "no_permission",
+}
+
+// SlackConfig describes the Slack app alerts are posted as.
+type SlackConfig struct {
+	BotToken   string // xoxb- token with chat:write, for channel IDs
+	BaseURL    string // Web API base, defaults to defaultSlackBase
+	HTTPClient *http.Client
+}
+
+// SlackHandler posts alerts to Slack. An address that is an https URL is an
+// incoming webhook; anything else is a channel or user ID posted to with
+// chat.postMessage using the bot token.
+type SlackHandler struct {
+	cfg SlackConfig
+}
+
+// NewSlackHandler initializes SlackHandler from SLACK_* env vars
+func NewSlackHandler() *SlackHandler {
+	return NewSlackHandlerWithConfig(SlackConfig{
+		BotToken: os.Getenv("SLACK_BOT_TOKEN"),
+		BaseURL:  os.Getenv("SLACK_API_BASE"),
+	})
+}
+
+// NewSlackHandlerWithConfig fills in defaults for an explicit configuration.
+func NewSlackHandlerWithConfig(cfg SlackConfig) *SlackHandler {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultSlackBase
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: chatTimeout}
+	}
+	return &SlackHandler{cfg: cfg}
+}
+
+func (h *SlackHandler) Send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	var result models.DispatchResult
+	if isSlackWebhook(notif.Recipient) {
+		result = h.sendWebhook(ctx, notif)
+	} else {
+		result = h.postMessage(ctx, notif)
+	}
+	result.NotificationID = notif.ID
+	result.Timestamp = time.Now()
+	if !result.Success {
+		logger.Error(fmt.Errorf("[SLACK] Error sending to %s: %s", slackTarget(notif.Recipient), result.Error))
+		return result
+	}
+	logger.Info(fmt.Sprintf("[SLACK] Sent to %s", slackTarget(notif.Recipient)))
+	return result
+}
+
+// slackMessage is the body both APIs accept: text is the fallback for
+// notifications, blocks what the channel shows.
+func slackMessage(notif models.Notification) map[string]any {
+	text := truncateRunes(notif.Message, slackMaxText)
+	msg := map[string]any{"text": text}
+	if notif.Subject != "" {
+		msg["text"] = notif.Subject + ": " + text
+		msg["blocks"] = []map[string]any{
+			{"type": "header", "text": map[string]any{"type": "plain_text", "text": truncateRunes(notif.Subject, 150)}},
+			{"type": "section", "text": map[string]any{"type": "plain_text", "text": truncateRunes(notif.Message, 3000)}},
+		}
+	}
+	return msg
+}
+
+// sendWebhook posts to an incoming webhook, which answers "ok" or an error
+// code in plain text.
+func (h *SlackHandler) sendWebhook(ctx context.Context, notif models.Notification) models.DispatchResult {
+	resp, err := postJSON(ctx, h.cfg.HTTPClient, notif.Recipient, nil, slackMessage(notif))
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("slack request: %v", err), ErrorClass: models.ErrorTransient}
+	}
+	body := strings.TrimSpace(string(resp.body))
+	result := models.DispatchResult{APIStatusCode: resp.status, APIResponse: body}
+	if resp.status == http.StatusOK {
+		result.Success = true
+		return result
+	}
+	return slackFailure(result, resp, body)
+}
+
+// slackPostResponse is the chat.postMessage answer.
+type slackPostResponse struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error"`
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+func (h *SlackHandler) postMessage(ctx context.Context, notif models.Notification) models.DispatchResult {
+	if h.cfg.BotToken == "" {
+		return models.DispatchResult{Error: "slack not configured: SLACK_BOT_TOKEN is required for channel IDs", ErrorClass: models.ErrorAuth}
+	}
+	msg := slackMessage(notif)
+	msg["channel"] = notif.Recipient
+	msg["unfurl_links"] = false
+	resp, err := postJSON(ctx, h.cfg.HTTPClient, h.cfg.BaseURL+"/api/chat.postMessage",
+		map[string]string{"Authorization": "Bearer " + h.cfg.BotToken}, msg)
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("slack request: %v", err), ErrorClass: models.ErrorTransient}
+	}
+
+	var pr slackPostResponse
+	_ = json.Unmarshal(resp.body, &pr)
+	if resp.status == http.StatusOK && pr.OK {
+		// channel and ts identify the message for later edits
+		return models.DispatchResult{Success: true, APIStatusCode: resp.status, APIResponse: pr.Channel + "/" + pr.TS}
+	}
+	result := models.DispatchResult{APIStatusCode: resp.status, APIResponse: string(resp.body)}
+	return slackFailure(result, resp, pr.Error)
+}
+
+// slackFailure classifies a failed request by HTTP status and Slack error
+// code.
+func slackFailure(result models.DispatchResult, resp chatResponse, code string) models.DispatchResult {
+	if code == "" {
+		code = http.StatusText(resp.status)
+	}
+	switch {
+	case resp.status == http.StatusTooManyRequests || code == "ratelimited" || code == "rate_limited":
+		rateLimited(&result, "slack", retryAfter(resp.header))
+	case slices.Contains(slackPermanentErrors, code):
+		result.Error = "slack: " + code
+		result.ErrorClass = models.ErrorPermanent
+	case slices.Contains(slackAuthErrors, code):
+		result.Error = "slack: " + code
+		result.ErrorClass = models.ErrorAuth
+	default:
+		// a webhook URL that no longer exists is a permanent 404
+		result.Error = fmt.Sprintf("slack %d: %s", resp.status, code)
+		result.ErrorClass = httpFailureClass(resp.status)
+	}
+	if resp.status != http.StatusOK || code != http.StatusText(resp.status) {
+		result.ErrorCode = code
+	}
+	return result
+}
+
+func isSlackWebhook(address string) bool {
+	return strings.HasPrefix(address, "https://") || strings.HasPrefix(address, "http://")
+}
+
+// slackTarget names an address for logs without the secret part of a
+// webhook URL.
+func slackTarget(address string) string {
+	if !isSlackWebhook(address) {
+		return address
+	}
+	if i := strings.Index(address, "/services/"); i >= 0 {
+		return address[:i] + "/services/…"
+	}
+	return "incoming webhook"
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 	"time"
 
 	"notification-service/internal/logger"
@@ -13,53 +14,104 @@ import (
 	openapi "github.com/twilio/twilio-go/rest/api/v2010"
 )
 
+// SMSStatusPath is where Twilio reports the delivery status of an alert SMS,
+// relative to the public base URL.
+const SMSStatusPath = "/sms/status"
+
+// smsRateLimitErrors are carrier error codes for messages dropped because
+// too many were sent at once.
+var smsRateLimitErrors = []string{"30001", "30022"}
+
+// smsPermanentErrors are Twilio carrier error codes a resend cannot fix.
+var smsPermanentErrors = map[string]string{
+	"30004": "message blocked by recipient or carrier",
+	"30005": "unknown or inactive number",
+	"30006": "landline or unreachable carrier",
+}
+
 // SMSHandler sends SMS via Twilio
 type SMSHandler struct {
 	client       *twilio.RestClient
 	fromPhoneNum string
+	callbackBase string // public base URL for status callbacks; "" disables them
 }
 
 // NewSMSHandler initializes SMSHandler with Twilio credentials from env vars
 func NewSMSHandler() *SMSHandler {
-	accountSID := os.Getenv("TWILIO_ACCOUNT_SID")
-	authToken := os.Getenv("TWILIO_AUTH_TOKEN")
-	from := os.Getenv("TWILIO_PHONE_NUMBER")
-
-	client := twilio.NewRestClientWithParams(twilio.ClientParams{
-		Username: accountSID,
-		Password: authToken,
-	})
+	return NewSMSHandlerWithClient(newTwilioClient(), os.Getenv("TWILIO_PHONE_NUMBER"), os.Getenv("PUBLIC_BASE_URL"))
+}
 
-	return &SMSHandler{
-		client:       client,
-		fromPhoneNum: from,
-	}
+// NewSMSHandlerWithClient uses an explicit Twilio client, e.g. one pointed
+// at a local fake in tests.
+func NewSMSHandlerWithClient(client *twilio.RestClient, from, callbackBase string) *SMSHandler {
+	return &SMSHandler{client: client, fromPhoneNum: from, callbackBase: callbackBase}
 }
 
-// Send sends SMS using Twilio API and returns DispatchResult
+// Send sends SMS using Twilio API and returns DispatchResult. With a
+// callback URL configured the result is Pending: Twilio reports whether the
+// message reached the handset to the status callback.
 func (h *SMSHandler) Send(ctx context.Context, notif models.Notification) models.DispatchResult {
 	params := &openapi.CreateMessageParams{}
 	params.SetTo(notif.Recipient)
 	params.SetFrom(h.fromPhoneNum)
 	params.SetBody(notif.Message)
+	status := callbackURL(h.callbackBase, SMSStatusPath, notif.ID)
+	if status != "" {
+		params.SetStatusCallback(status)
+	}
 
 	resp, err := h.client.Api.CreateMessage(params)
 	if err != nil {
 		logger.Error(fmt.Errorf("[SMS] Error sending to %s: %w", notif.Recipient, err))
-		return models.DispatchResult{
-			NotificationID: notif.ID,
-			Success:        false,
-			Error:          err.Error(),
-			Timestamp:      time.Now(),
-		}
+		result := twilioFailure(err)
+		result.NotificationID = notif.ID
+		result.Timestamp = time.Now()
+		return result
 	}
 
-	logger.Info(fmt.Sprintf("[SMS] Sent to %s, SID=%s", notif.Recipient, *resp.Sid))
+	sid := ""
+	if resp.Sid != nil {
+		sid = *resp.Sid
+	}
+	logger.Info(fmt.Sprintf("[SMS] Sent to %s, SID=%s", notif.Recipient, sid))
 
 	return models.DispatchResult{
-		NotificationID: notif.ID,
-		Success:        true,
-		Error:          "",
-		Timestamp:      time.Now(),
+		NotificationID:    notif.ID,
+		Success:           true,
+		Pending:           status != "",
+		ProviderMessageID: sid,
+		Timestamp:         time.Now(),
+	}
+}
+
+// SMSStatusResult maps the final MessageStatus of a status callback onto a
+// dispatch result. Carrier failures are retried unless the error code says
+// the number cannot receive the message.
+func SMSStatusResult(notifID, messageStatus, errorCode string) models.DispatchResult {
+	result := models.DispatchResult{NotificationID: notifID, APIResponse: "sms " + messageStatus, Timestamp: time.Now()}
+	if errorCode != "" {
+		result.APIResponse += " (" + errorCode + ")"
+	}
+	switch messageStatus {
+	case "delivered", "read":
+		result.Success = true
+		return result
+	case "canceled":
+		result.Error = "message canceled"
+		result.ErrorClass = models.ErrorPermanent
+		return result
+	}
+	// "undelivered" or "failed"
+	result.Error = "sms " + messageStatus
+	result.ErrorClass, result.ErrorCode = models.ErrorTransient, errorCode
+	if errorCode != "" {
+		result.Error = fmt.Sprintf("sms %s: carrier error %s", messageStatus, errorCode)
+	}
+	if reason, ok := smsPermanentErrors[errorCode]; ok {
+		result.Error += ", " + reason
+		result.ErrorClass = models.ErrorPermanent
+	} else if slices.Contains(smsRateLimitErrors, errorCode) {
+		result.ErrorClass = models.ErrorRateLimited
 	}
+	return result
 }
@@ -0,0 +1,128 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"notification-service/internal/logger"
+	"notification-service/pkg/models"
+)
+
+const (
+	defaultTelegramBase = "https://api.telegram.org"
+	telegramMaxText     = 4096 // characters per message
+)
+
+// TelegramConfig describes the bot alerts are sent as.
+type TelegramConfig struct {
+	BotToken   string
+	BaseURL    string // defaults to defaultTelegramBase
+	HTTPClient *http.Client
+}
+
+// TelegramHandler sends alerts through the Telegram Bot API. Addresses are
+// chat IDs: a user who started the bot, or a group or channel it posts to.
+type TelegramHandler struct {
+	cfg TelegramConfig
+}
+
+// NewTelegramHandler initializes TelegramHandler from TELEGRAM_* env vars
+func NewTelegramHandler() *TelegramHandler {
+	return NewTelegramHandlerWithConfig(TelegramConfig{
+		BotToken: os.Getenv("TELEGRAM_BOT_TOKEN"),
+		BaseURL:  os.Getenv("TELEGRAM_API_BASE"),
+	})
+}
+
+// NewTelegramHandlerWithConfig fills in defaults for an explicit configuration.
+func NewTelegramHandlerWithConfig(cfg TelegramConfig) *TelegramHandler {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultTelegramBase
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: chatTimeout}
+	}
+	return &TelegramHandler{cfg: cfg}
+}
+
+// telegramResponse is the envelope of every Bot API answer.
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Result      struct {
+		MessageID int64 `json:"message_id"`
+	} `json:"result"`
+	Parameters struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+func (h *TelegramHandler) Send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	result := h.send(ctx, notif)
+	result.NotificationID = notif.ID
+	result.Timestamp = time.Now()
+	if !result.Success {
+		logger.Error(fmt.Errorf("[TELEGRAM] Error sending to %s: %s", notif.Recipient, result.Error))
+		return result
+	}
+	logger.Info(fmt.Sprintf("[TELEGRAM] Sent to %s, message_id=%s", notif.Recipient, result.APIResponse))
+	return result
+}
+
+func (h *TelegramHandler) send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	if h.cfg.BotToken == "" {
+		return models.DispatchResult{Error: "telegram not configured: TELEGRAM_BOT_TOKEN is required", ErrorClass: models.ErrorAuth}
+	}
+	text := notif.Message
+	if notif.Subject != "" {
+		text = notif.Subject + "\n\n" + text
+	}
+	req := map[string]any{
+		"chat_id":                  notif.Recipient,
+		"text":                     truncateRunes(text, telegramMaxText),
+		"disable_web_page_preview": true,
+	}
+	resp, err := postJSON(ctx, h.cfg.HTTPClient, h.cfg.BaseURL+"/bot"+h.cfg.BotToken+"/sendMessage", nil, req)
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("telegram request: %v", err), ErrorClass: models.ErrorTransient}
+	}
+
+	var tr telegramResponse
+	_ = json.Unmarshal(resp.body, &tr)
+	if resp.status == http.StatusOK && tr.OK {
+		return models.DispatchResult{Success: true, APIStatusCode: resp.status, APIResponse: strconv.FormatInt(tr.Result.MessageID, 10)}
+	}
+
+	result := models.DispatchResult{APIStatusCode: resp.status, APIResponse: string(resp.body)}
+	if tr.ErrorCode != 0 {
+		result.ErrorCode = strconv.Itoa(tr.ErrorCode)
+	}
+	desc := tr.Description
+	if desc == "" {
+		desc = http.StatusText(resp.status)
+	}
+	switch resp.status {
+	case http.StatusTooManyRequests:
+		rateLimited(&result, "telegram", time.Duration(tr.Parameters.RetryAfter)*time.Second)
+	case http.StatusBadRequest, http.StatusForbidden:
+		// unknown chat, or the user blocked the bot or left the group
+		result.Error = fmt.Sprintf("telegram %d: %s", resp.status, desc)
+		result.ErrorClass = models.ErrorPermanent
+	case http.StatusUnauthorized, http.StatusNotFound:
+		// a bad bot token, which a config fix resolves
+		result.Error = fmt.Sprintf("telegram %d: %s", resp.status, desc)
+		result.ErrorClass = models.ErrorAuth
+	default:
+		result.Error = fmt.Sprintf("telegram %d: %s", resp.status, desc)
+		result.ErrorClass = httpFailureClass(resp.status)
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package channels
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"notification-service/pkg/models"
+
+	twilio "github.com/twilio/twilio-go"
+	"github.com/twilio/twilio-go/client"
+)
+
+// twilioPermanentErrors are Twilio API error codes for a message or call a
+// retry cannot fix: the number is invalid, unreachable or has opted out.
+var twilioPermanentErrors = map[int]string{
+	13223: "invalid phone number format",
+	13224: "invalid phone number",
+	21211: "invalid 'To' phone number",
+	21214: "'To' number cannot be reached",
+	21217: "phone number does not appear to be valid",
+	21408: "sending to this region is not enabled",
+	21610: "recipient unsubscribed by replying STOP",
+	21612: "'To' number cannot be reached from the 'From' number",
+	21614: "'To' number is not a mobile number",
+}
+
+// twilioAuthErrors are refused credentials, an inactive account or a sender
+// number the account may not use; a config fix resolves them.
+var twilioAuthErrors = []int{20003, 20005, 21222, 21224, 21606, 21659}
+
+// twilioRateLimitErrors mean "slow down".
+var twilioRateLimitErrors = []int{14107, 20429}
+
+// newTwilioClient creates a Twilio REST client from TWILIO_ACCOUNT_SID and
+// TWILIO_AUTH_TOKEN.
+func newTwilioClient() *twilio.RestClient {
+	return twilio.NewRestClientWithParams(twilio.ClientParams{
+		Username: os.Getenv("TWILIO_ACCOUNT_SID"),
+		Password: os.Getenv("TWILIO_AUTH_TOKEN"),
+	})
+}
+
+// twilioFailure classifies an error returned by the Twilio REST API. Errors
+// without an API answer, e.g. timeouts, are transient.
+func twilioFailure(err error) models.DispatchResult {
+	result := models.DispatchResult{Error: err.Error(), ErrorClass: models.ErrorTransient}
+	var apiErr *client.TwilioRestError
+	if !errors.As(err, &apiErr) {
+		return result
+	}
+	result.APIStatusCode, result.ErrorCode = apiErr.Status, strconv.Itoa(apiErr.Code)
+	result.Error = fmt.Sprintf("twilio error %d: %s", apiErr.Code, apiErr.Message)
+	switch reason, permanent := twilioPermanentErrors[apiErr.Code]; {
+	case permanent:
+		result.Error = fmt.Sprintf("twilio error %d: %s", apiErr.Code, reason)
+		result.ErrorClass = models.ErrorPermanent
+	case slices.Contains(twilioAuthErrors, apiErr.Code):
+		result.ErrorClass = models.ErrorAuth
+	case slices.Contains(twilioRateLimitErrors, apiErr.Code) || apiErr.Status == http.StatusTooManyRequests:
+		rateLimited(&result, "twilio", 0)
+		result.Error += fmt.Sprintf(" (code %d)", apiErr.Code)
+	default:
+		result.ErrorClass = httpFailureClass(apiErr.Status)
+	}
+	return result
+}
+
+// callbackURL is the URL a provider calls back on for one notification, or
+// "" when no public base URL is configured.
+func callbackURL(base, path, notifID string) string {
+	if base == "" {
+		return ""
+	}
+	return strings.TrimRight(base, "/") + path + "?notification_id=" + url.QueryEscape(notifID)
+}
@@ -0,0 +1,52 @@
+package channels
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"notification-service/pkg/models"
+
+	"github.com/twilio/twilio-go/client"
+)
+
+func TestTwilioFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		class  string
+		code   string
+		status int
+	}{
+		{"InvalidNumber", &client.TwilioRestError{Code: 21211, Status: http.StatusBadRequest, Message: "The 'To' number is not valid"},
+			models.ErrorPermanent, "21211", http.StatusBadRequest},
+		{"Unsubscribed", &client.TwilioRestError{Code: 21610, Status: http.StatusBadRequest, Message: "Attempt to send to unsubscribed recipient"},
+			models.ErrorPermanent, "21610", http.StatusBadRequest},
+		{"QueueFull", &client.TwilioRestError{Code: 14107, Status: http.StatusBadRequest, Message: "rate limit exceeded"},
+			models.ErrorRateLimited, "14107", http.StatusBadRequest},
+		{"TooManyRequests", &client.TwilioRestError{Code: 20429, Status: http.StatusTooManyRequests, Message: "Too Many Requests"},
+			models.ErrorRateLimited, "20429", http.StatusTooManyRequests},
+		{"BadCredentials", &client.TwilioRestError{Code: 20003, Status: http.StatusUnauthorized, Message: "Authenticate"},
+			models.ErrorAuth, "20003", http.StatusUnauthorized},
+		{"SenderNotAllowed", &client.TwilioRestError{Code: 21606, Status: http.StatusBadRequest, Message: "The From phone number is not a valid, SMS-capable number"},
+			models.ErrorAuth, "21606", http.StatusBadRequest},
+		{"UnknownServerError", &client.TwilioRestError{Code: 20500, Status: http.StatusInternalServerError, Message: "Internal Server Error"},
+			models.ErrorTransient, "20500", http.StatusInternalServerError},
+		{"UnknownClientError", &client.TwilioRestError{Code: 21999, Status: http.StatusBadRequest, Message: "something new"},
+			models.ErrorPermanent, "21999", http.StatusBadRequest},
+		{"Timeout", errors.New("Post \"https://api.twilio.com\": context deadline exceeded"),
+			models.ErrorTransient, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := twilioFailure(tt.err)
+			if res.Success || res.ErrorClass != tt.class || res.ErrorCode != tt.code || res.APIStatusCode != tt.status {
+				t.Errorf("twilioFailure = class %s, code %q, status %d, want %s, %q, %d (%s)",
+					res.ErrorClass, res.ErrorCode, res.APIStatusCode, tt.class, tt.code, tt.status, res.Error)
+			}
+			if res.RetryAfter != 0 {
+				t.Errorf("RetryAfter = %s, want none from the REST API", res.RetryAfter)
+			}
+		})
+	}
+}
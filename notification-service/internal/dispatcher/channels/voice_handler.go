@@ -0,0 +1,245 @@
+package channels
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"notification-service/internal/logger"
+	"notification-service/pkg/models"
+
+	twilio "github.com/twilio/twilio-go"
+	openapi "github.com/twilio/twilio-go/rest/api/v2010"
+)
+
+// Callback paths the voice handler points Twilio at, relative to the public
+// base URL.
+const (
+	VoiceGatherPath = "/voice/gather"
+	VoiceStatusPath = "/voice/status"
+)
+
+const (
+	defaultVoiceRepeat  = 2
+	voiceRingTimeout    = 30 // seconds
+	voiceGatherTimeout  = 8  // seconds to wait for a keypress after the message
+	defaultVoiceLangTag = "en-IN"
+)
+
+// VoiceConfig describes how calls are placed.
+type VoiceConfig struct {
+	From string // caller ID, a Twilio number
+	// CallbackBase is the public base URL Twilio calls back on for
+	// keypresses and call outcomes. Without it calls are fire-and-forget.
+	CallbackBase string
+	Repeat       int    // times the message is spoken; defaults to 2
+	Voice        string // optional TwiML voice, e.g. "Google.hi-IN-Standard-A"
+}
+
+// VoiceHandler calls the recipient through Twilio and reads the alert out
+// with text-to-speech, asking for a keypress to acknowledge it. The call's
+// outcome arrives later on the status callback.
+type VoiceHandler struct {
+	client *twilio.RestClient
+	cfg    VoiceConfig
+}
+
+// NewVoiceHandler initializes VoiceHandler with Twilio credentials from env vars
+func NewVoiceHandler() *VoiceHandler {
+	from := os.Getenv("TWILIO_VOICE_NUMBER")
+	if from == "" {
+		from = os.Getenv("TWILIO_PHONE_NUMBER")
+	}
+	repeat, _ := strconv.Atoi(os.Getenv("VOICE_REPEAT"))
+	return NewVoiceHandlerWithClient(newTwilioClient(), VoiceConfig{
+		From:         from,
+		CallbackBase: os.Getenv("PUBLIC_BASE_URL"),
+		Repeat:       repeat,
+		Voice:        os.Getenv("VOICE_NAME"),
+	})
+}
+
+// NewVoiceHandlerWithClient uses an explicit Twilio client, e.g. one pointed
+// at a local fake in tests.
+func NewVoiceHandlerWithClient(client *twilio.RestClient, cfg VoiceConfig) *VoiceHandler {
+	if cfg.Repeat <= 0 {
+		cfg.Repeat = defaultVoiceRepeat
+	}
+	return &VoiceHandler{client: client, cfg: cfg}
+}
+
+// Send places the call. With a callback URL configured the result is
+// Pending: whether the call was answered, and acknowledged, is reported to
+// the status and gather callbacks.
+func (h *VoiceHandler) Send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	gather := callbackURL(h.cfg.CallbackBase, VoiceGatherPath, notif.ID)
+	params := &openapi.CreateCallParams{}
+	params.SetTo(notif.Recipient)
+	params.SetFrom(h.cfg.From)
+	params.SetTwiml(VoiceTwiML(notif.Message, notif.Language, h.cfg.Voice, gather, h.cfg.Repeat))
+	params.SetTimeout(voiceRingTimeout)
+	if status := callbackURL(h.cfg.CallbackBase, VoiceStatusPath, notif.ID); status != "" {
+		params.SetStatusCallback(status)
+		params.SetStatusCallbackMethod("POST")
+		params.SetStatusCallbackEvent([]string{"completed"})
+	}
+
+	resp, err := h.client.Api.CreateCall(params)
+	if err != nil {
+		logger.Error(fmt.Errorf("[VOICE] Error calling %s: %w", notif.Recipient, err))
+		result := twilioFailure(err)
+		result.NotificationID = notif.ID
+		result.Timestamp = time.Now()
+		return result
+	}
+
+	sid := ""
+	if resp.Sid != nil {
+		sid = *resp.Sid
+	}
+	logger.Info(fmt.Sprintf("[VOICE] Calling %s, SID=%s", notif.Recipient, sid))
+
+	return models.DispatchResult{
+		NotificationID:    notif.ID,
+		Success:           true,
+		Pending:           gather != "",
+		APIResponse:       sid,
+		ProviderMessageID: sid,
+		Timestamp:         time.Now(),
+	}
+}
+
+// VoiceCallResult maps the final CallStatus of a status callback onto a
+// dispatch result. An unanswered or busy line is retried; a call that was
+// answered counts as delivered even without a keypress.
+func VoiceCallResult(notifID, callStatus string) models.DispatchResult {
+	result := models.DispatchResult{NotificationID: notifID, APIResponse: "call " + callStatus, Timestamp: time.Now()}
+	switch callStatus {
+	case "completed":
+		result.Success = true
+	case "busy":
+		result.Error, result.ErrorClass = "line busy", models.ErrorTransient
+	case "no-answer":
+		result.Error, result.ErrorClass = "no answer", models.ErrorTransient
+	case "canceled":
+		result.Error = "call canceled"
+		result.ErrorClass = models.ErrorPermanent
+	default: // "failed", e.g. an unreachable number
+		result.Error, result.ErrorClass = "call "+callStatus, models.ErrorTransient
+	}
+	return result
+}
+
+// VoiceTwiML builds the call script: the message read out Repeat times in the
+// recipient's language inside a <Gather> that takes a single keypress as
+// acknowledgement. Without a gather URL the message is only read out.
+func VoiceTwiML(message, language, voice, gatherURL string, repeat int) string {
+	lang := voiceLanguage(language)
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><Response>`)
+	if gatherURL != "" {
+		fmt.Fprintf(&b, `<Gather input="dtmf" numDigits="1" timeout="%d" method="POST" action="%s">`, voiceGatherTimeout, xmlEscape(gatherURL))
+	}
+	for i := 0; i < repeat; i++ {
+		if i > 0 {
+			b.WriteString(`<Pause length="1"/>`)
+		}
+		writeSay(&b, message, lang, voice)
+	}
+	if gatherURL != "" {
+		prompt, promptLang := voicePrompt(lang, "ack")
+		writeSay(&b, prompt, promptLang, voice)
+		b.WriteString(`</Gather>`)
+	}
+	b.WriteString(`</Response>`)
+	return b.String()
+}
+
+// VoiceAckTwiML is the reply to an acknowledging keypress.
+func VoiceAckTwiML(language string) string {
+	lang := voiceLanguage(language)
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><Response>`)
+	thanks, thanksLang := voicePrompt(lang, "thanks")
+	writeSay(&b, thanks, thanksLang, "")
+	b.WriteString(`<Hangup/></Response>`)
+	return b.String()
+}
+
+func writeSay(b *strings.Builder, text, lang, voice string) {
+	b.WriteString(`<Say language="` + xmlEscape(lang) + `"`)
+	if voice != "" {
+		b.WriteString(` voice="` + xmlEscape(voice) + `"`)
+	}
+	b.WriteString(`>` + xmlEscape(text) + `</Say>`)
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// voiceLanguages maps message languages to text-to-speech locales. Indian
+// languages are spoken with Indian voices; anything without a voice falls
+// back to Indian English.
+var voiceLanguages = map[string]string{
+	"en": "en-IN", "hi": "hi-IN", "bn": "bn-IN", "ta": "ta-IN", "te": "te-IN",
+	"mr": "mr-IN", "gu": "gu-IN", "kn": "kn-IN", "ml": "ml-IN", "pa": "pa-IN",
+}
+
+func voiceLanguage(language string) string {
+	if language == "" {
+		return defaultVoiceLangTag
+	}
+	base, region, _ := strings.Cut(language, "-")
+	tag, ok := voiceLanguages[strings.ToLower(base)]
+	if !ok {
+		return defaultVoiceLangTag
+	}
+	if isRegionSubtag(region) {
+		return strings.ToLower(base) + "-" + strings.ToUpper(region)
+	}
+	return tag
+}
+
+// isRegionSubtag reports whether s is a BCP 47 region: two letters or three
+// digits.
+func isRegionSubtag(s string) bool {
+	switch len(s) {
+	case 2:
+		return isASCIILetter(s[0]) && isASCIILetter(s[1])
+	case 3:
+		return isDigit(s[0]) && isDigit(s[1]) && isDigit(s[2])
+	}
+	return false
+}
+
+func isASCIILetter(c byte) bool { return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' }
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+// voicePrompts are the fixed phrases of the call script by locale.
+var voicePrompts = map[string]map[string]string{
+	"en-IN": {
+		"ack":    "Press any key to confirm you have heard this alert.",
+		"thanks": "Thank you. Your confirmation has been recorded. Stay safe.",
+	},
+	"hi-IN": {
+		"ack":    "इस चेतावनी को सुनने की पुष्टि के लिए कोई भी बटन दबाएँ।",
+		"thanks": "धन्यवाद। आपकी पुष्टि दर्ज कर ली गई है। सुरक्षित रहें।",
+	},
+}
+
+// voicePrompt returns a phrase and the locale it is in, English where the
+// locale has no translation.
+func voicePrompt(lang, key string) (string, string) {
+	if p, ok := voicePrompts[lang]; ok {
+		return p[key], lang
+	}
+	return voicePrompts[defaultVoiceLangTag][key], defaultVoiceLangTag
+}
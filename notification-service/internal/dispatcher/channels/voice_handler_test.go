@@ -0,0 +1,48 @@
+package channels
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestVoiceTwiMLLanguage(t *testing.T) {
+	tests := []struct {
+		language string
+		want     string
+	}{
+		{"", "en-IN"},
+		{"ta", "ta-IN"},
+		{"hi-in", "hi-IN"},
+		{"en-GB", "en-GB"},
+		{"en-419", "en-419"},
+		{"es-ES", "en-IN"},
+		{`en-"><Play>https://attacker.example/x.mp3</Play><Say language="en`, "en-IN"},
+		{"en-IN-x-twain", "en-IN"},
+	}
+	for _, tt := range tests {
+		twiml := VoiceTwiML("Cyclone warning", tt.language, "", "", 1)
+		var resp struct {
+			Say []struct {
+				Language string `xml:"language,attr"`
+			}
+			Play []string
+		}
+		if err := xml.Unmarshal([]byte(twiml), &resp); err != nil {
+			t.Fatalf("%q: TwiML does not parse: %v\n%s", tt.language, err, twiml)
+		}
+		if len(resp.Play) != 0 || len(resp.Say) != 1 || resp.Say[0].Language != tt.want {
+			t.Errorf("%q: got %s, want a single <Say language=%q>", tt.language, twiml, tt.want)
+		}
+	}
+}
+
+func TestVoiceTwiMLEscapesMessage(t *testing.T) {
+	twiml := VoiceTwiML(`Tides > 3m & rising <now>`, "en", `Polly.Aditi"`, "https://example.org/gather?n=1&t=x", 2)
+	if strings.Contains(twiml, "<now>") || strings.Count(twiml, "<Say") != 3 {
+		t.Errorf("TwiML = %s", twiml)
+	}
+	if err := xml.Unmarshal([]byte(twiml), new(struct{})); err != nil {
+		t.Errorf("TwiML does not parse: %v", err)
+	}
+}
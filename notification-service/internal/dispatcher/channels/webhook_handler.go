@@ -0,0 +1,291 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"notification-service/internal/logger"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+// Headers sent with every webhook request. The signature header holds one
+// "v1=<hex>" entry per signing secret, comma-separated, each an HMAC-SHA256
+// of "<timestamp>.<body>". Receivers should reject stale timestamps and
+// deduplicate on the idempotency key, which is the same across retries.
+const (
+	WebhookSignatureHeader   = "X-VedSagar-Signature"
+	WebhookTimestampHeader   = "X-VedSagar-Timestamp"
+	WebhookIdempotencyHeader = "Idempotency-Key"
+
+	// WebhookPayloadVersion is bumped on incompatible payload changes.
+	WebhookPayloadVersion = "1"
+
+	webhookTimeout = 15 * time.Second
+)
+
+// WebhookHandler posts alerts as signed JSON to partner endpoints.
+// Notifications address an endpoint by its ID; the URL and secrets are
+// looked up on every send so a rotation applies to pending retries.
+type WebhookHandler struct {
+	webhooks storage.WebhookStore
+	events   storage.EventStore
+	client   *http.Client
+}
+
+// NewWebhookHandler initializes WebhookHandler with a default HTTP client
+// that refuses to connect to internal addresses (see InternalAddress), so a
+// registered endpoint cannot reach services behind the firewall, unless
+// allowInternal is set for local development.
+func NewWebhookHandler(webhooks storage.WebhookStore, events storage.EventStore, allowInternal bool) *WebhookHandler {
+	client := &http.Client{Timeout: webhookTimeout}
+	if !allowInternal {
+		client = publicOnlyClient(webhookTimeout)
+	}
+	return NewWebhookHandlerWithClient(webhooks, events, client)
+}
+
+// publicOnlyClient returns an HTTP client that refuses to connect to
+// internal addresses, for URLs that come from outside the service.
+func publicOnlyClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would connect on our behalf, past the address check
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: timeout, Control: refuseInternal}).DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// InternalAddress reports whether addr is loopback, private, link-local,
+// shared (carrier-grade NAT), unspecified or otherwise not a public unicast
+// address.
+func InternalAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return true
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// nonPublicPrefixes are the non-public ranges netip does not classify.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+var errInternalAddress = errors.New("refusing to connect to internal address")
+
+// refuseInternal is a net.Dialer Control hook; it sees the resolved address,
+// so DNS names pointing inside are refused too.
+func refuseInternal(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if InternalAddress(ap.Addr()) {
+		return fmt.Errorf("%w %s", errInternalAddress, ap.Addr())
+	}
+	return nil
+}
+
+// NewWebhookHandlerWithClient uses an explicit HTTP client.
+func NewWebhookHandlerWithClient(webhooks storage.WebhookStore, events storage.EventStore, client *http.Client) *WebhookHandler {
+	return &WebhookHandler{webhooks: webhooks, events: events, client: client}
+}
+
+// WebhookPayload is the versioned body of a webhook request.
+type WebhookPayload struct {
+	Version      string              `json:"version"`
+	Type         string              `json:"type"` // "alert", or "cancel" when the referenced alert was withdrawn
+	ID           string              `json:"id"`   // the notification ID, also the idempotency key
+	SentAt       time.Time           `json:"sent_at"`
+	Event        WebhookEvent        `json:"event"`
+	Notification WebhookNotification `json:"notification"`
+}
+
+// WebhookEvent is the alert as partners see it: the event without its
+// recipient list or attachment contents.
+type WebhookEvent struct {
+	ID          string              `json:"id"`
+	Type        string              `json:"type"`
+	Title       string              `json:"title,omitempty"`
+	Message     string              `json:"message,omitempty"`
+	Severity    string              `json:"severity,omitempty"`
+	Urgency     string              `json:"urgency,omitempty"`
+	Certainty   string              `json:"certainty,omitempty"`
+	Category    string              `json:"category,omitempty"`
+	Headline    string              `json:"headline,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Instruction string              `json:"instruction,omitempty"`
+	Sender      string              `json:"sender,omitempty"`
+	Source      string              `json:"source,omitempty"`
+	SentAt      *time.Time          `json:"sent_at,omitempty"`
+	ExpiresAt   *time.Time          `json:"expires_at,omitempty"`
+	Areas       []models.Area       `json:"areas,omitempty"`
+	Messages    map[string]string   `json:"messages,omitempty"`
+	References  []string            `json:"references,omitempty"`
+	Attachments []WebhookAttachment `json:"attachments,omitempty"`
+}
+
+// WebhookAttachment names an attachment of the event.
+type WebhookAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int    `json:"size"`
+}
+
+// WebhookNotification is the rendered message of this delivery.
+type WebhookNotification struct {
+	Language string `json:"language,omitempty"`
+	Subject  string `json:"subject,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Send posts the notification to its endpoint. A 2xx is delivered; 408, 429
+// and 5xx are retried; any other status is a permanent failure.
+func (h *WebhookHandler) Send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	result := h.send(ctx, notif)
+	result.NotificationID = notif.ID
+	result.Timestamp = time.Now()
+	if !result.Success {
+		logger.Error(fmt.Errorf("[WEBHOOK] Error sending to %s: %s", notif.Recipient, result.Error))
+		return result
+	}
+	logger.Info(fmt.Sprintf("[WEBHOOK] Sent to %s: %d", notif.Recipient, result.APIStatusCode))
+	return result
+}
+
+func (h *WebhookHandler) send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	endpoint, err := h.webhooks.GetWebhook(ctx, notif.Recipient)
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("lookup endpoint: %v", err), ErrorClass: lookupFailureClass(err)}
+	}
+	if endpoint.Disabled {
+		return models.DispatchResult{Error: "endpoint disabled", ErrorClass: models.ErrorPermanent}
+	}
+
+	now := time.Now()
+	body, err := h.payload(ctx, notif, now)
+	if err != nil {
+		return models.DispatchResult{Error: err.Error(), ErrorClass: models.ErrorTransient}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return models.DispatchResult{Error: err.Error(), ErrorClass: models.ErrorPermanent}
+	}
+	ts := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "VedSagar-Webhook/"+WebhookPayloadVersion)
+	req.Header.Set(WebhookTimestampHeader, ts)
+	req.Header.Set(WebhookIdempotencyHeader, notif.ID)
+	sigs := []string{}
+	for _, secret := range endpoint.SigningSecrets(now) {
+		sigs = append(sigs, "v1="+SignWebhook(secret, ts, body))
+	}
+	req.Header.Set(WebhookSignatureHeader, strings.Join(sigs, ","))
+
+	resp, err := h.client.Do(req)
+	if errors.Is(err, errInternalAddress) {
+		return models.DispatchResult{Error: fmt.Sprintf("webhook request: %v", withoutURL(err)), ErrorClass: models.ErrorPermanent}
+	}
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("webhook request: %v", withoutURL(err)), ErrorClass: models.ErrorTransient}
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+
+	result := models.DispatchResult{APIStatusCode: resp.StatusCode, APIResponse: string(respBody)}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		result.Success = true
+		return result
+	}
+	result.Error = fmt.Sprintf("webhook %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	result.ErrorClass = httpFailureClass(resp.StatusCode)
+	if result.ErrorClass == models.ErrorRateLimited {
+		rateLimited(&result, "webhook", retryAfter(resp.Header))
+	}
+	return result
+}
+
+// payload builds the request body. The event is loaded from the event
+// store; if it is gone the body carries what the notification knows.
+func (h *WebhookHandler) payload(ctx context.Context, notif models.Notification, now time.Time) ([]byte, error) {
+	p := WebhookPayload{
+		Version: WebhookPayloadVersion,
+		Type:    "alert",
+		ID:      notif.ID,
+		SentAt:  now.UTC(),
+		Event:   WebhookEvent{ID: notif.EventID, Severity: notif.Severity},
+		Notification: WebhookNotification{
+			Language: notif.Language,
+			Subject:  notif.Subject,
+			Message:  notif.Message,
+		},
+	}
+	record, err := h.events.GetEvent(ctx, notif.EventID)
+	switch {
+	case err == nil:
+		p.Event = webhookEvent(record.Event)
+		if record.Event.MsgType != "" {
+			p.Type = record.Event.MsgType
+		}
+		if record.Event.AllClear {
+			p.Type = "cancel"
+		}
+	case errors.Is(err, storage.ErrNotFound):
+	default:
+		return nil, fmt.Errorf("load event %s: %w", notif.EventID, err)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(p); err != nil {
+		return nil, fmt.Errorf("encode payload: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func webhookEvent(e models.Event) WebhookEvent {
+	out := WebhookEvent{
+		ID: e.ID, Type: e.Type, Title: e.Title, Message: e.Message, Severity: e.Severity,
+		Urgency: e.Urgency, Certainty: e.Certainty, Category: e.Category, Headline: e.Headline,
+		Description: e.Description, Instruction: e.Instruction, Sender: e.Sender, Source: e.Source,
+		SentAt: e.SentAt, ExpiresAt: e.ExpiresAt, Areas: e.Areas, Messages: e.Messages, References: e.References,
+	}
+	for _, a := range e.Attachments {
+		out.Attachments = append(out.Attachments, WebhookAttachment{Filename: a.Filename, ContentType: a.ContentType, Size: len(a.Data)})
+	}
+	return out
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>" under
+// secret, the value of a "v1=" signature entry.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
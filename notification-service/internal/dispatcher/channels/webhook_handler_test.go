@@ -0,0 +1,89 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+)
+
+func TestWebhookRefusesInternalAddresses(t *testing.T) {
+	received := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	mem := memorystore.New()
+	if err := mem.SaveWebhook(ctx, models.WebhookEndpoint{ID: "whk-1", URL: srv.URL, Secret: "s"}); err != nil {
+		t.Fatalf("SaveWebhook: %v", err)
+	}
+	notif := models.Notification{ID: "notif-1", EventID: "evt-1", Recipient: "whk-1", Channel: "webhook", Message: "m"}
+
+	res := NewWebhookHandler(mem, mem, false).Send(ctx, notif)
+	if res.Success || res.ErrorClass != models.ErrorPermanent {
+		t.Errorf("send to %s: success=%v class=%s, want a permanent failure", srv.URL, res.Success, res.ErrorClass)
+	}
+	if received != 0 {
+		t.Errorf("internal endpoint received %d requests", received)
+	}
+
+	if res := NewWebhookHandler(mem, mem, true).Send(ctx, notif); !res.Success {
+		t.Errorf("send with internal addresses allowed: %s", res.Error)
+	}
+}
+
+func TestWebhookPayloadType(t *testing.T) {
+	var got WebhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	mem := memorystore.New()
+	if err := mem.SaveWebhook(ctx, models.WebhookEndpoint{ID: "whk-1", URL: srv.URL, Secret: "s"}); err != nil {
+		t.Fatalf("SaveWebhook: %v", err)
+	}
+	events := []models.Event{
+		{ID: "evt-1", Type: "flood", Title: "Flood warning", Message: "River rising"},
+		{ID: "allclear-1", Type: "flood", Title: "All clear: Flood warning", Message: "Withdrawn",
+			References: []string{"evt-1"}, AllClear: true},
+	}
+	for _, ev := range events {
+		if err := mem.SaveEvent(ctx, models.EventRecord{Event: ev, Status: "processing"}); err != nil {
+			t.Fatalf("SaveEvent: %v", err)
+		}
+	}
+	h := NewWebhookHandler(mem, mem, true)
+
+	tests := []struct {
+		eventID string
+		want    string
+	}{
+		{"evt-1", "alert"},
+		{"allclear-1", "cancel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.eventID, func(t *testing.T) {
+			got = WebhookPayload{}
+			notif := models.Notification{ID: "notif-" + tt.eventID, EventID: tt.eventID, Recipient: "whk-1", Channel: "webhook", Message: "m"}
+			if res := h.Send(ctx, notif); !res.Success {
+				t.Fatalf("Send: %s", res.Error)
+			}
+			if got.Type != tt.want || got.Event.ID != tt.eventID {
+				t.Errorf("payload type %q for event %s, want %q", got.Type, got.Event.ID, tt.want)
+			}
+		})
+	}
+	if len(got.Event.References) != 1 || got.Event.References[0] != "evt-1" {
+		t.Errorf("cancel references %v, want the withdrawn evt-1", got.Event.References)
+	}
+}
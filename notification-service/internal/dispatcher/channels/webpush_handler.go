@@ -0,0 +1,235 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"notification-service/internal/logger"
+	"notification-service/internal/storage"
+	"notification-service/internal/webpush"
+	"notification-service/pkg/models"
+)
+
+// defaultEventURLBase is resolved by the service worker against the
+// dashboard's origin.
+const defaultEventURLBase = "/events/"
+
+// WebPushConfig describes how the WebPushHandler identifies itself to
+// browser push services.
+type WebPushConfig struct {
+	VAPID        *webpush.VAPID
+	EventURLBase string // prefixed to the event ID to form the URL opened on click
+	HTTPClient   *http.Client
+	// AllowInternal lets the default client reach internal addresses. The
+	// endpoints come from browsers, so only for local development.
+	AllowInternal bool
+}
+
+// WebPushHandler sends browser notifications to the Web Push subscriptions
+// stored on directory recipients. Notifications address a subscription by its
+// endpoint; the keys to encrypt for are looked up in the directory.
+type WebPushHandler struct {
+	cfg       WebPushConfig
+	directory storage.RecipientStore
+	err       error // configuration problem reported on every send
+}
+
+// NewWebPushHandler initializes WebPushHandler from WEBPUSH_* env vars
+func NewWebPushHandler(directory storage.RecipientStore, allowInternal bool) *WebPushHandler {
+	cfg := WebPushConfig{EventURLBase: os.Getenv("WEBPUSH_EVENT_URL_BASE"), AllowInternal: allowInternal}
+	var err error
+	if key := os.Getenv("WEBPUSH_VAPID_PRIVATE_KEY"); key != "" {
+		cfg.VAPID, err = webpush.NewVAPID(key, os.Getenv("WEBPUSH_SUBJECT"))
+	}
+	h := NewWebPushHandlerWithConfig(directory, cfg)
+	if err != nil {
+		h.err = err
+		logger.Error(fmt.Errorf("[WEBPUSH] configuration: %w", err))
+	}
+	return h
+}
+
+// NewWebPushHandlerWithConfig fills in defaults for an explicit configuration.
+func NewWebPushHandlerWithConfig(directory storage.RecipientStore, cfg WebPushConfig) *WebPushHandler {
+	if cfg.EventURLBase == "" {
+		cfg.EventURLBase = defaultEventURLBase
+	}
+	if cfg.HTTPClient == nil && cfg.AllowInternal {
+		cfg.HTTPClient = &http.Client{Timeout: pushTimeout}
+	} else if cfg.HTTPClient == nil {
+		cfg.HTTPClient = publicOnlyClient(pushTimeout)
+	}
+	h := &WebPushHandler{cfg: cfg, directory: directory}
+	if cfg.VAPID == nil {
+		h.err = errors.New("WEBPUSH_VAPID_PRIVATE_KEY is required")
+	}
+	return h
+}
+
+// PublicKey is the VAPID key browsers subscribe with, or "" when web push is
+// not configured.
+func (h *WebPushHandler) PublicKey() string {
+	if h.cfg.VAPID == nil {
+		return ""
+	}
+	return h.cfg.VAPID.PublicKey()
+}
+
+// webPushPayload is what the dashboard's service worker receives in its
+// push event.
+type webPushPayload struct {
+	Title          string `json:"title,omitempty"`
+	Body           string `json:"body"`
+	EventID        string `json:"event_id"`
+	NotificationID string `json:"notification_id"`
+	Severity       string `json:"severity,omitempty"`
+	URL            string `json:"url"`
+	AckURL         string `json:"ack_url,omitempty"` // posted to by the worker's acknowledge action
+}
+
+// Send encrypts the notification for the subscription and posts it to the
+// push service. A 404 or 410 means the browser unsubscribed, so the result
+// is flagged DeadAddress and the subscription removed.
+func (h *WebPushHandler) Send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	result := h.send(ctx, notif)
+	result.NotificationID = notif.ID
+	result.Timestamp = time.Now()
+	if !result.Success {
+		logger.Error(fmt.Errorf("[WEBPUSH] Error sending to %s: %s", notif.Recipient, result.Error))
+		return result
+	}
+	logger.Info(fmt.Sprintf("[WEBPUSH] Sent to %s: %d", notif.Recipient, result.APIStatusCode))
+	return result
+}
+
+func (h *WebPushHandler) send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	if h.err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("web push not configured: %v", h.err), ErrorClass: models.ErrorAuth}
+	}
+	if notif.RecipientID == "" {
+		return models.DispatchResult{Error: "web push needs a directory recipient", ErrorClass: models.ErrorPermanent}
+	}
+	profile, err := h.directory.GetRecipient(ctx, notif.RecipientID)
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("lookup recipient: %v", err), ErrorClass: lookupFailureClass(err)}
+	}
+	sub, ok := profile.FindWebPush(notif.Recipient)
+	if !ok {
+		return models.DispatchResult{Error: "subscription no longer registered", ErrorClass: models.ErrorPermanent}
+	}
+	now := time.Now()
+	if sub.ExpirationTime != nil && now.UnixMilli() >= *sub.ExpirationTime {
+		return models.DispatchResult{Error: "subscription expired", ErrorClass: models.ErrorPermanent, DeadAddress: true}
+	}
+	keys, err := webpush.ParseKeys(sub.Keys.P256dh, sub.Keys.Auth)
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("subscription keys: %v", err), ErrorClass: models.ErrorPermanent, DeadAddress: true}
+	}
+
+	payload, err := h.payload(notif)
+	if err != nil {
+		return models.DispatchResult{Error: err.Error(), ErrorClass: models.ErrorPermanent}
+	}
+	body, err := webpush.Encrypt(payload, keys)
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("encrypt: %v", err), ErrorClass: models.ErrorPermanent}
+	}
+	auth, err := h.cfg.VAPID.Authorization(sub.Endpoint, now)
+	if err != nil {
+		return models.DispatchResult{Error: err.Error(), ErrorClass: models.ErrorPermanent, DeadAddress: true}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return models.DispatchResult{Error: err.Error(), ErrorClass: models.ErrorPermanent, DeadAddress: true}
+	}
+	urgency := "normal"
+	if models.SeverityAtLeast(notif.Severity, "severe") {
+		urgency = "high"
+	}
+	req.Header.Set("Authorization", auth)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.FormatInt(int64(pushTTL(notif, now)/time.Second), 10))
+	req.Header.Set("Urgency", urgency)
+
+	resp, err := h.cfg.HTTPClient.Do(req)
+	if errors.Is(err, errInternalAddress) {
+		return models.DispatchResult{Error: fmt.Sprintf("push service request: %v", withoutURL(err)), ErrorClass: models.ErrorPermanent, DeadAddress: true}
+	}
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("push service request: %v", withoutURL(err)), ErrorClass: models.ErrorTransient}
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	result := models.DispatchResult{APIStatusCode: resp.StatusCode, APIResponse: string(respBody)}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if loc := resp.Header.Get("Location"); loc != "" {
+			result.APIResponse = loc // the push message resource
+		}
+		result.Success = true
+		return result
+	}
+
+	result.Error = fmt.Sprintf("push service %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	result.ErrorClass = httpFailureClass(resp.StatusCode)
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusGone:
+		// the subscription expired or the user revoked permission
+		result.DeadAddress = true
+	case http.StatusTooManyRequests:
+		rateLimited(&result, "push service", retryAfter(resp.Header))
+	}
+	return result
+}
+
+// payload encodes the notification for the service worker, shortening the
+// body if the whole would not fit one encrypted record.
+func (h *WebPushHandler) payload(notif models.Notification) ([]byte, error) {
+	p := webPushPayload{
+		Title:          notif.Subject,
+		Body:           notif.Message,
+		EventID:        notif.EventID,
+		NotificationID: notif.ID,
+		Severity:       notif.Severity,
+		URL:            h.cfg.EventURLBase + url.PathEscape(notif.EventID),
+		AckURL:         notif.AckURL,
+	}
+	for {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false) // keeps the body close to its raw size
+		if err := enc.Encode(p); err != nil {
+			return nil, fmt.Errorf("encode payload: %w", err)
+		}
+		b := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+		over := len(b) - webpush.MaxPayload
+		if over <= 0 {
+			return b, nil
+		}
+		if p.Body == "" {
+			return nil, fmt.Errorf("payload of %d bytes is too large", len(b))
+		}
+		// drop at least as many bytes as are over, on a rune boundary
+		cut := max(len(p.Body)-over-len("…"), 0)
+		for cut > 0 && !utf8.RuneStart(p.Body[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			p.Body = ""
+		} else {
+			p.Body = p.Body[:cut] + "…"
+		}
+	}
+}
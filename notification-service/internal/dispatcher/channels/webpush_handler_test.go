@@ -0,0 +1,85 @@
+package channels
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/internal/webpush"
+	"notification-service/pkg/models"
+)
+
+// subscribeBrowser registers a browser subscription at endpoint for
+// recipient "r1" and returns the notification addressed to it.
+func subscribeBrowser(t *testing.T, mem *memorystore.MemoryStore, endpoint string) models.Notification {
+	t.Helper()
+	ctx := context.Background()
+	ua, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	enc := base64.RawURLEncoding.EncodeToString
+	if err := mem.SaveRecipient(ctx, models.Recipient{ID: "r1"}); err != nil {
+		t.Fatalf("SaveRecipient: %v", err)
+	}
+	sub := models.WebPushSubscription{Endpoint: endpoint,
+		Keys: models.WebPushKeys{P256dh: enc(ua.PublicKey().Bytes()), Auth: enc(make([]byte, 16))}}
+	if err := mem.SaveWebPushSubscription(ctx, "r1", sub); err != nil {
+		t.Fatalf("SaveWebPushSubscription: %v", err)
+	}
+	return models.Notification{ID: "notif-1", EventID: "evt-1", RecipientID: "r1", Recipient: endpoint,
+		Channel: "webpush", Severity: "Severe", Subject: "Flood", Message: "Move to high ground"}
+}
+
+func TestWebPushSendsEncryptedMessage(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Header().Set("Location", "https://push.example/m/1")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	mem := memorystore.New()
+	notif := subscribeBrowser(t, mem, srv.URL+"/sub/1")
+	vapid, err := webpush.GenerateVAPID("mailto:ops@example.org")
+	if err != nil {
+		t.Fatalf("GenerateVAPID: %v", err)
+	}
+
+	h := NewWebPushHandlerWithConfig(mem, WebPushConfig{VAPID: vapid, HTTPClient: srv.Client()})
+	res := h.Send(context.Background(), notif)
+	if !res.Success || res.APIResponse != "https://push.example/m/1" {
+		t.Fatalf("Send = %+v", res)
+	}
+	if got.Header.Get("Content-Encoding") != "aes128gcm" || got.Header.Get("Urgency") != "high" {
+		t.Errorf("headers = %v", got.Header)
+	}
+}
+
+func TestWebPushRefusesInternalEndpoints(t *testing.T) {
+	received := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	mem := memorystore.New()
+	notif := subscribeBrowser(t, mem, srv.URL+"/internal/admin")
+	vapid, err := webpush.GenerateVAPID("mailto:ops@example.org")
+	if err != nil {
+		t.Fatalf("GenerateVAPID: %v", err)
+	}
+
+	res := NewWebPushHandlerWithConfig(mem, WebPushConfig{VAPID: vapid}).Send(context.Background(), notif)
+	if res.Success || res.ErrorClass != models.ErrorPermanent || !res.DeadAddress {
+		t.Errorf("send to %s: %+v, want a permanent failure retiring the subscription", srv.URL, res)
+	}
+	if received != 0 {
+		t.Errorf("internal endpoint received %d requests", received)
+	}
+}
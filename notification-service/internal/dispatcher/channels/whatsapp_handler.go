@@ -0,0 +1,208 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"notification-service/internal/logger"
+	"notification-service/pkg/models"
+)
+
+const (
+	defaultWhatsAppBase     = "https://graph.facebook.com"
+	defaultWhatsAppVersion  = "v21.0"
+	defaultWhatsAppLanguage = "en"
+	whatsAppMaxParam        = 1024 // characters per template parameter
+)
+
+// Cloud API error codes that mean "slow down"; the request may be retried.
+var whatsAppRateLimitCodes = []int{4, 80007, 130429, 131048, 131056}
+
+// Cloud API error codes for an expired or under-privileged access token, or
+// a business account that may not send; a config fix resolves them.
+var whatsAppAuthCodes = []int{0, 3, 10, 190, 200, 131005}
+
+// Cloud API error codes a retry cannot fix: bad template or parameters, or
+// a number that cannot receive WhatsApp messages.
+var whatsAppPermanentCodes = []int{
+	100, 131008, 131009, 131021, 131026, 131030, 131031, 131051,
+	132000, 132001, 132005, 132007, 132012, 132015, 132016, 132018, 133010,
+}
+
+// WhatsAppConfig describes the business number and approved template alerts
+// are sent with. Business-initiated messages must use a template; its body
+// must have a single {{1}} parameter, which receives the alert text.
+type WhatsAppConfig struct {
+	AccessToken   string
+	PhoneNumberID string // the sending number's ID, not the number itself
+	Template      string
+	// Languages the template is approved in, e.g. ["en", "hi", "ta"];
+	// other recipients get DefaultLanguage.
+	Languages       []string
+	DefaultLanguage string // defaults to "en"
+	BaseURL         string // Graph API base, defaults to defaultWhatsAppBase
+	APIVersion      string // defaults to defaultWhatsAppVersion
+	HTTPClient      *http.Client
+}
+
+// WhatsAppHandler sends alerts as WhatsApp template messages through the
+// WhatsApp Business Cloud API. Addresses are phone numbers.
+type WhatsAppHandler struct {
+	cfg WhatsAppConfig
+}
+
+// NewWhatsAppHandler initializes WhatsAppHandler from WHATSAPP_* env vars
+func NewWhatsAppHandler() *WhatsAppHandler {
+	cfg := WhatsAppConfig{
+		AccessToken:     os.Getenv("WHATSAPP_ACCESS_TOKEN"),
+		PhoneNumberID:   os.Getenv("WHATSAPP_PHONE_NUMBER_ID"),
+		Template:        os.Getenv("WHATSAPP_TEMPLATE"),
+		DefaultLanguage: os.Getenv("WHATSAPP_TEMPLATE_LANGUAGE"),
+		BaseURL:         os.Getenv("WHATSAPP_API_BASE"),
+		APIVersion:      os.Getenv("WHATSAPP_API_VERSION"),
+	}
+	for _, l := range strings.Split(os.Getenv("WHATSAPP_TEMPLATE_LANGUAGES"), ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			cfg.Languages = append(cfg.Languages, l)
+		}
+	}
+	return NewWhatsAppHandlerWithConfig(cfg)
+}
+
+// NewWhatsAppHandlerWithConfig fills in defaults for an explicit configuration.
+func NewWhatsAppHandlerWithConfig(cfg WhatsAppConfig) *WhatsAppHandler {
+	if cfg.DefaultLanguage == "" {
+		cfg.DefaultLanguage = defaultWhatsAppLanguage
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultWhatsAppBase
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = defaultWhatsAppVersion
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: chatTimeout}
+	}
+	return &WhatsAppHandler{cfg: cfg}
+}
+
+// whatsAppResponse covers both the success and the error answer.
+type whatsAppResponse struct {
+	Messages []struct {
+		ID string `json:"id"`
+	} `json:"messages"`
+	Error *struct {
+		Message   string `json:"message"`
+		Type      string `json:"type"`
+		Code      int    `json:"code"`
+		Subcode   int    `json:"error_subcode"`
+		ErrorData struct {
+			Details string `json:"details"`
+		} `json:"error_data"`
+	} `json:"error"`
+}
+
+func (h *WhatsAppHandler) Send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	result := h.send(ctx, notif)
+	result.NotificationID = notif.ID
+	result.Timestamp = time.Now()
+	if !result.Success {
+		logger.Error(fmt.Errorf("[WHATSAPP] Error sending to %s: %s", notif.Recipient, result.Error))
+		return result
+	}
+	logger.Info(fmt.Sprintf("[WHATSAPP] Sent to %s, ID=%s", notif.Recipient, result.APIResponse))
+	return result
+}
+
+func (h *WhatsAppHandler) send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	if h.cfg.AccessToken == "" || h.cfg.PhoneNumberID == "" || h.cfg.Template == "" {
+		return models.DispatchResult{Error: "whatsapp not configured: WHATSAPP_ACCESS_TOKEN, WHATSAPP_PHONE_NUMBER_ID and WHATSAPP_TEMPLATE are required", ErrorClass: models.ErrorAuth}
+	}
+	req := map[string]any{
+		"messaging_product": "whatsapp",
+		"recipient_type":    "individual",
+		"to":                strings.TrimPrefix(notif.Recipient, "+"),
+		"type":              "template",
+		"template": map[string]any{
+			"name":     h.cfg.Template,
+			"language": map[string]string{"code": h.language(notif.Language)},
+			"components": []map[string]any{{
+				"type":       "body",
+				"parameters": []map[string]string{{"type": "text", "text": whatsAppParam(notif.Message)}},
+			}},
+		},
+	}
+	url := fmt.Sprintf("%s/%s/%s/messages", h.cfg.BaseURL, h.cfg.APIVersion, h.cfg.PhoneNumberID)
+	resp, err := postJSON(ctx, h.cfg.HTTPClient, url, map[string]string{"Authorization": "Bearer " + h.cfg.AccessToken}, req)
+	if err != nil {
+		return models.DispatchResult{Error: fmt.Sprintf("whatsapp request: %v", err), ErrorClass: models.ErrorTransient}
+	}
+
+	var wr whatsAppResponse
+	_ = json.Unmarshal(resp.body, &wr)
+	if resp.status == http.StatusOK && wr.Error == nil && len(wr.Messages) > 0 {
+		// the wamid identifies the message in delivery status webhooks
+		return models.DispatchResult{Success: true, APIStatusCode: resp.status, APIResponse: wr.Messages[0].ID}
+	}
+
+	result := models.DispatchResult{APIStatusCode: resp.status, APIResponse: string(resp.body)}
+	if wr.Error == nil {
+		result.Error = fmt.Sprintf("whatsapp %d %s", resp.status, http.StatusText(resp.status))
+		result.ErrorClass = httpFailureClass(resp.status)
+		if result.ErrorClass == models.ErrorRateLimited {
+			rateLimited(&result, "whatsapp", retryAfter(resp.header))
+		}
+		return result
+	}
+	e := wr.Error
+	detail := e.Message
+	if e.ErrorData.Details != "" {
+		detail += ": " + e.ErrorData.Details
+	}
+	result.ErrorCode = strconv.Itoa(e.Code)
+	switch {
+	case resp.status == http.StatusTooManyRequests || slices.Contains(whatsAppRateLimitCodes, e.Code):
+		rateLimited(&result, "whatsapp", retryAfter(resp.header))
+		result.Error += fmt.Sprintf(" (code %d)", e.Code)
+	case slices.Contains(whatsAppPermanentCodes, e.Code):
+		result.Error = fmt.Sprintf("whatsapp error %d: %s", e.Code, detail)
+		result.ErrorClass = models.ErrorPermanent
+	case slices.Contains(whatsAppAuthCodes, e.Code):
+		result.Error = fmt.Sprintf("whatsapp error %d: %s", e.Code, detail)
+		result.ErrorClass = models.ErrorAuth
+	default:
+		// service errors (131000, 131016) and the like
+		result.Error = fmt.Sprintf("whatsapp error %d: %s", e.Code, detail)
+		result.ErrorClass = models.ErrorTransient
+	}
+	return result
+}
+
+// language picks the template language for a recipient's language, e.g.
+// "en-IN" matches "en_IN" or "en".
+func (h *WhatsAppHandler) language(lang string) string {
+	lang = strings.ReplaceAll(lang, "-", "_")
+	base, _, _ := strings.Cut(lang, "_")
+	for _, want := range []string{lang, base} {
+		for _, l := range h.cfg.Languages {
+			if want != "" && strings.EqualFold(l, want) {
+				return l
+			}
+		}
+	}
+	return h.cfg.DefaultLanguage
+}
+
+// whatsAppParam fits the alert text to a template parameter, which may not
+// contain newlines, tabs or more than four consecutive spaces.
+func whatsAppParam(text string) string {
+	return truncateRunes(strings.Join(strings.Fields(text), " "), whatsAppMaxParam)
+}
@@ -2,112 +2,742 @@ package dispatcher
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"html"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"notification-service/internal/ack"
 	"notification-service/internal/dispatcher/channels"
+	"notification-service/internal/ids"
 	"notification-service/internal/logger"
+	"notification-service/internal/metrics"
+	"notification-service/internal/smsenc"
 	"notification-service/internal/storage"
+	"notification-service/internal/templating"
 	"notification-service/pkg/models"
 )
 
 type Dispatcher struct {
-	handlers map[string]ChannelHandler
-	store    storage.NotificationStore
+	handlers  map[string]ChannelHandler
+	store     storage.NotificationStore
+	directory storage.RecipientStore
+	templates *templating.Engine
+	smsPolicy smsenc.Policy
+
+	suppressions storage.SuppressionStore
+	events       storage.EventStore
+
+	acks       storage.AckStore
+	ackLinks   *ack.Signer
+	escalation ack.Policies
+
+	concurrency int
 }
 
+// defaultConcurrency is how many notifications of one event are sent at
+// once unless SetConcurrency says otherwise.
+const defaultConcurrency = 32
+
 func NewDispatcher(store storage.NotificationStore) *Dispatcher {
 	return &Dispatcher{
 		handlers: map[string]ChannelHandler{
 			"sms":   channels.NewSMSHandler(),
-			"email": &channels.EmailHandler{},
-			"push":  &channels.PushHandler{},
+			"email": channels.NewEmailHandler(),
+			"push":  channels.NewPushHandler(),
+			"voice": channels.NewVoiceHandler(),
+
+			"telegram": channels.NewTelegramHandler(),
+			"slack":    channels.NewSlackHandler(),
+			"whatsapp": channels.NewWhatsAppHandler(),
 		},
-		store: store,
+		store:       store,
+		concurrency: defaultConcurrency,
 	}
 }
 
+// Store returns the notification store the dispatcher records results in.
+func (d *Dispatcher) Store() storage.NotificationStore {
+	return d.store
+}
+
+// Register adds or replaces the handler for a channel name.
+func (d *Dispatcher) Register(channel string, handler ChannelHandler) {
+	d.handlers[channel] = handler
+}
+
+// SetDirectory enables resolving event recipients through the recipient
+// directory. Without one every recipient is treated as a raw address.
+func (d *Dispatcher) SetDirectory(directory storage.RecipientStore) {
+	d.directory = directory
+}
+
+// SetSuppressions enables skipping addresses whose owners opted out, e.g.
+// by replying STOP.
+func (d *Dispatcher) SetSuppressions(suppressions storage.SuppressionStore) {
+	d.suppressions = suppressions
+}
+
+// SetEvents lets a running dispatch see its event being cancelled, so the
+// notifications it has not sent yet are not sent, or updated, so they carry
+// the new text.
+func (d *Dispatcher) SetEvents(events storage.EventStore) {
+	d.events = events
+}
+
+// SetAcknowledgements enables acknowledgement tracking. Alerts whose
+// severity has an escalation policy carry ack links, signed by links when
+// it is not nil, and send their later channels only to recipients who have
+// not acknowledged by then.
+func (d *Dispatcher) SetAcknowledgements(acks storage.AckStore, links *ack.Signer, policies ack.Policies) {
+	d.acks, d.ackLinks, d.escalation = acks, links, policies
+}
+
+// SetTemplates enables rendering events that name a message template.
+func (d *Dispatcher) SetTemplates(engine *templating.Engine) {
+	d.templates = engine
+}
+
+// SetSMSPolicy sets how over-long SMS bodies are shortened. The zero Policy
+// only counts segments.
+func (d *Dispatcher) SetSMSPolicy(policy smsenc.Policy) {
+	d.smsPolicy = policy
+}
+
+// SetConcurrency limits how many notifications of one event are sent at
+// once; each event consumer dispatches its own event within this limit.
+func (d *Dispatcher) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	d.concurrency = n
+}
+
+// FitSMS applies the SMS length policy to a message body.
+func (d *Dispatcher) FitSMS(text string) (string, smsenc.Info) {
+	return d.smsPolicy.Apply(text)
+}
+
 func (d *Dispatcher) DispatchEvent(ctx context.Context, event models.Event) []models.DispatchResult {
+	return d.dispatch(ctx, event, nil)
+}
+
+// ResumeEvent dispatches an event whose earlier dispatch was interrupted, e.g.
+// by a crash before the queue entry was acknowledged. Recipients and channels
+// that already have a notification are left alone, except those still
+// "pending", whose send may never have happened; they are sent again under
+// their existing ID.
+func (d *Dispatcher) ResumeEvent(ctx context.Context, event models.Event) ([]models.DispatchResult, error) {
+	earlier := map[string]models.Notification{}
+	const pageSize = 500
+	for offset := 0; ; offset += pageSize {
+		page, total, err := d.store.ListNotificationsByEvent(ctx, event.ID, offset, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("list notifications of %s: %w", event.ID, err)
+		}
+		for _, n := range page {
+			earlier[dispatchKey(n.Channel, n.Recipient)] = n
+		}
+		if len(page) == 0 || offset+pageSize >= total {
+			break
+		}
+	}
+	return d.dispatch(ctx, event, earlier), nil
+}
+
+// dispatchKey identifies one delivery of an event: a channel and the address
+// it goes to.
+func dispatchKey(channel, address string) string {
+	return channel + "\x00" + address
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, event models.Event, earlier map[string]models.Notification) []models.DispatchResult {
 	results := []models.DispatchResult{}
 	var wg sync.WaitGroup
 	var mu sync.Mutex
+	// an event targeting a whole district must not start a goroutine and a
+	// provider connection per recipient at once
+	sem := make(chan struct{}, d.concurrency)
 
+	policy := d.escalation.For(event.Severity)
+	watch := &eventWatch{d: d, base: event, current: event, tmpl: d.loadTemplate(ctx, event)}
+
+	channels := []string{}
 	for _, channel := range event.Channels {
-		handler, exists := d.handlers[channel]
-		if !exists {
+		if _, exists := d.handlers[channel]; !exists {
 			logger.Info(fmt.Sprintf("Unknown channel: %s, skipping", channel))
 			continue
 		}
-		for _, recipient := range event.Recipients {
-			wg.Add(1)
-			go func(ch string, rec string) {
-				defer wg.Done()
-
-				notif := models.Notification{
-					ID:        fmt.Sprintf("notif-%d", time.Now().UnixNano()),
-					EventID:   event.ID,
-					Recipient: rec,
-					Channel:   ch,
-					Message:   event.Message,
-					Status:    "pending",
-					Timestamp: time.Now(),
-				}
+		channels = append(channels, channel)
+	}
 
-				// Save initial record
-				if err := d.store.SaveNotification(ctx, notif); err != nil {
-					logger.Error(fmt.Errorf("save notification: %w", err))
+	for _, recipient := range event.Recipients {
+		profile := d.lookupRecipient(ctx, recipient)
+		for _, channel := range channels {
+			if allowed, ok := event.RecipientChannels[recipient]; ok && !slices.Contains(allowed, channel) {
+				continue
+			}
+			addresses, recipientID := []string{recipient}, ""
+			if profile != nil {
+				addresses, recipientID = profile.AddressesFor(channel), profile.ID
+				if len(addresses) == 0 {
+					logger.Info(fmt.Sprintf("Recipient %s has no %s address, skipping", recipient, channel))
+					continue
+				}
+			}
+			for _, addr := range addresses {
+				prev, seen := earlier[dispatchKey(channel, addr)]
+				if seen && prev.Status != "pending" {
+					continue
 				}
+				wg.Add(1)
+				sem <- struct{}{}
+				go func(ch string, rec string) {
+					defer wg.Done()
+					defer func() { <-sem }()
 
-				result := handler.Send(ctx, notif)
-
-				if result.Success {
-					_ = d.store.UpdateNotificationStatus(ctx, notif.ID, "success", "")
-					logger.Info(fmt.Sprintf("✓ Dispatch success: %s to %s via %s", event.Title, rec, ch))
-				} else {
-					// increment attempts and persist last error
-					newAttempts, err := d.store.IncrementAttempts(ctx, notif.ID, result.Error)
-					if err != nil {
-						logger.Error(fmt.Errorf("increment attempts: %w", err))
-						// fallback: still schedule a retry to avoid losing it
-						newAttempts = 1
+					event, tmpl, cancelled := watch.Current(ctx)
+					notif := models.Notification{
+						ID:          ids.New("notif"),
+						EventID:     event.ID,
+						Recipient:   rec,
+						RecipientID: recipientID,
+						Channel:     ch,
+						Severity:    event.Severity,
+						Status:      "pending",
+						Timestamp:   time.Now(),
+						ExpiresAt:   event.ExpiresAt,
 					}
-
-					// determine max retries (use stored value if present, else fallback)
-					maxRetries := notif.MaxRetries
-					if maxRetries == 0 {
-						maxRetries = 5 // default if not set via model/env
+					if seen {
+						notif.ID, notif.Timestamp = prev.ID, prev.Timestamp
 					}
+					if ch == "email" {
+						notif.Attachments = event.Attachments
+					}
+					d.fillContent(&notif, tmpl, event, profile)
 
-					// if we've hit or exceeded max retries, mark permanent failure
-					if newAttempts >= maxRetries {
-						_ = d.store.UpdateNotificationStatus(ctx, notif.ID, "failed_permanent", result.Error)
-						_ = d.store.RemoveFromRetryQueue(ctx, notif.ID)
-						logger.Info(fmt.Sprintf("✗ Permanent failure: %s to %s via %s - %s", event.Title, rec, ch, result.Error))
+					var result models.DispatchResult
+					if cancelled {
+						notif.Status = "cancelled"
+						notif.Error = "event cancelled before dispatch"
+						if err := d.store.SaveNotification(ctx, notif); err != nil {
+							logger.Error(fmt.Errorf("save notification: %w", err))
+						}
+						metrics.Inc(statusMetric, "channel", ch, "status", "cancelled")
+						result = models.DispatchResult{NotificationID: notif.ID, Error: notif.Error, Timestamp: time.Now()}
+					} else if notif.Expired(notif.Timestamp) {
+						// keep a record so the query API shows who was skipped
+						notif.Status = "expired"
+						notif.Error = "alert expired before dispatch"
+						if err := d.store.SaveNotification(ctx, notif); err != nil {
+							logger.Error(fmt.Errorf("save notification: %w", err))
+						}
+						metrics.Inc(statusMetric, "channel", ch, "status", "expired")
+						result = models.DispatchResult{NotificationID: notif.ID, Error: notif.Error, Timestamp: time.Now()}
+					} else if delay, _ := policy.Delay(ch); delay > 0 {
+						result = d.scheduleEscalation(ctx, notif, delay)
 					} else {
-						// compute exponential backoff
-						baseSeconds := int64(300)  // 5 minutes base
-						maxBackoff := int64(86400) // cap backoff at 24 hours
-						delay := baseSeconds << uint(newAttempts-1)
-						if delay > maxBackoff {
-							delay = maxBackoff
+						// Save initial record
+						if err := d.store.SaveNotification(ctx, notif); err != nil {
+							logger.Error(fmt.Errorf("save notification: %w", err))
 						}
-						nextRetry := time.Now().Add(time.Duration(delay) * time.Second)
 
-						_ = d.store.UpdateNotificationStatus(ctx, notif.ID, "failed", result.Error)
-						_ = d.store.ScheduleRetry(ctx, notif.ID, nextRetry, result.Error)
-						logger.Info(fmt.Sprintf("✗ Dispatch failed: %s to %s via %s - %s (attempt %d/%d) will retry at %s",
-							event.Title, rec, ch, result.Error, newAttempts, maxRetries, nextRetry.Format(time.RFC3339)))
+						result = d.Send(ctx, notif)
+						d.RecordResult(ctx, notif, result)
 					}
-				}
 
-				mu.Lock()
-				results = append(results, result)
-				mu.Unlock()
-			}(channel, recipient)
+					mu.Lock()
+					results = append(results, result)
+					mu.Unlock()
+				}(channel, addr)
+			}
 		}
 	}
 
 	wg.Wait()
 	return results
 }
+
+// eventCheckInterval is how long a running dispatch trusts its copy of the
+// event before reading the event record again.
+var eventCheckInterval = time.Second
+
+// eventWatch tells a running dispatch whether its event has been cancelled
+// and what it currently says, reading the event record at most once per
+// eventCheckInterval rather than once per recipient. When an update lands
+// mid-dispatch, notifications created after it carry the new text. A
+// failing read does not hold back an alert.
+type eventWatch struct {
+	d    *Dispatcher
+	base models.Event // as the stages resolved it, recipients included
+
+	mu        sync.Mutex
+	checked   time.Time
+	updatedAt time.Time
+	current   models.Event
+	tmpl      *templating.Compiled
+	cancelled bool
+}
+
+// Current returns the event to render notifications from, its template and
+// whether it has been cancelled.
+func (w *eventWatch) Current(ctx context.Context) (models.Event, *templating.Compiled, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.d.events == nil || w.base.ID == "" || w.cancelled || time.Since(w.checked) < eventCheckInterval {
+		return w.current, w.tmpl, w.cancelled
+	}
+	w.checked = time.Now()
+	record, err := w.d.events.GetEvent(ctx, w.base.ID)
+	if err != nil {
+		return w.current, w.tmpl, w.cancelled
+	}
+	if record.Status == "cancelled" {
+		w.cancelled = true
+	}
+	if !record.UpdatedAt.Equal(w.updatedAt) {
+		w.updatedAt = record.UpdatedAt
+		w.current = withRecipientsOf(record.Event, w.base)
+		w.tmpl = w.d.loadTemplate(ctx, w.current)
+	}
+	return w.current, w.tmpl, w.cancelled
+}
+
+// withRecipientsOf returns the stored event with the recipients and
+// channels the stages resolved for the dispatch, which the stored copy does
+// not have.
+func withRecipientsOf(stored, resolved models.Event) models.Event {
+	stored.Channels = resolved.Channels
+	stored.Recipients = resolved.Recipients
+	stored.RecipientChannels = resolved.RecipientChannels
+	return stored
+}
+
+// Rerender rebuilds the content of a notification from an amended event the
+// same way DispatchEvent built it, template and ack link included.
+func (d *Dispatcher) Rerender(ctx context.Context, event models.Event, notif models.Notification) models.Notification {
+	var profile *models.Recipient
+	if notif.RecipientID != "" {
+		profile = d.lookupRecipient(ctx, notif.RecipientID)
+	}
+	d.fillContent(&notif, d.loadTemplate(ctx, event), event, profile)
+	return notif
+}
+
+// fillContent renders the event for the notification's channel and address
+// and sets its message, subject, HTML body, language and SMS segment info.
+func (d *Dispatcher) fillContent(notif *models.Notification, tmpl *templating.Compiled, event models.Event, profile *models.Recipient) {
+	content, language := render(tmpl, event, profile, notif.Channel, notif.Recipient)
+	notif.Subject, notif.HTMLBody, notif.Language = content.Subject, content.HTML, language
+
+	var info smsenc.Info
+	notif.Message, info = d.ComposeText(*notif, content.Text)
+	notif.SMSEncoding, notif.SMSSegments = "", 0
+	if notif.Channel == "sms" {
+		notif.SMSEncoding, notif.SMSSegments = info.Encoding, info.Segments
+	}
+	if link := d.ackLink(*notif); link != "" && notif.HTMLBody != "" {
+		notif.HTMLBody = withHTMLAckLink(notif.HTMLBody, link)
+	}
+}
+
+// scheduleEscalation holds back a notification the event's escalation policy
+// sends later. The retry worker sends it when due, unless the recipient has
+// acknowledged the alert by then.
+func (d *Dispatcher) scheduleEscalation(ctx context.Context, notif models.Notification, delay time.Duration) models.DispatchResult {
+	at := notif.Timestamp.Add(delay)
+	if notif.Expired(at) {
+		notif.Status, notif.Error = "skipped", "alert expires before escalation"
+	} else {
+		notif.Status = "scheduled"
+	}
+	if err := d.store.SaveNotification(ctx, notif); err != nil {
+		logger.Error(fmt.Errorf("save notification: %w", err))
+	}
+	metrics.Inc(statusMetric, "channel", notif.Channel, "status", notif.Status)
+	if notif.Status == "skipped" {
+		return models.DispatchResult{NotificationID: notif.ID, Error: notif.Error, Timestamp: time.Now()}
+	}
+
+	if err := d.store.ScheduleRetry(ctx, notif.ID, at, ""); err != nil {
+		logger.Error(fmt.Errorf("schedule escalation for %s: %w", notif.ID, err))
+	}
+	logger.Info(fmt.Sprintf("⏲ Escalation scheduled: %s to %s via %s at %s unless acknowledged",
+		notif.ID, notif.Recipient, notif.Channel, at.Format(time.RFC3339)))
+	return models.DispatchResult{NotificationID: notif.ID, Success: true, Pending: true, Timestamp: time.Now()}
+}
+
+// ComposeText finishes the plain-text body of a notification: alerts that
+// ask to be acknowledged end in their ack link on SMS and email, and SMS
+// bodies are fitted to the length policy around it.
+func (d *Dispatcher) ComposeText(notif models.Notification, text string) (string, smsenc.Info) {
+	link := d.ackLink(notif)
+	switch notif.Channel {
+	case "sms":
+		suffix := ""
+		if link != "" {
+			suffix = "\nConfirm: " + link
+		}
+		return d.smsPolicy.ApplySuffix(text, suffix)
+	case "email":
+		if link != "" {
+			text += "\n\nConfirm you received this alert: " + link
+		}
+	}
+	return text, smsenc.Info{}
+}
+
+// withHTMLAckLink adds the ack link to an HTML email body, inside its body
+// element when it has one.
+func withHTMLAckLink(body, link string) string {
+	p := `<p><a href="` + html.EscapeString(link) + `">Confirm you received this alert</a></p>`
+	if i := strings.LastIndex(strings.ToLower(body), "</body>"); i >= 0 {
+		return body[:i] + p + body[i:]
+	}
+	return body + p
+}
+
+// ackLink is the signed link that acknowledges the notification, or "" when
+// its severity asks for no acknowledgement or links are not configured.
+func (d *Dispatcher) ackLink(notif models.Notification) string {
+	if d.ackLinks == nil || d.escalation.For(notif.Severity) == nil {
+		return ""
+	}
+	return d.ackLinks.Link(notif.ID)
+}
+
+// loadTemplate returns the event's compiled template, or nil when it has
+// none or it cannot be loaded; the event's own message is sent then.
+func (d *Dispatcher) loadTemplate(ctx context.Context, event models.Event) *templating.Compiled {
+	if event.Template == "" {
+		return nil
+	}
+	if d.templates == nil {
+		logger.Info(fmt.Sprintf("Event %s names template %s but templates are not configured", event.ID, event.Template))
+		return nil
+	}
+	tmpl, err := d.templates.Load(ctx, event.Template, event.TemplateVersion)
+	if err != nil {
+		logger.Error(fmt.Errorf("load template for event %s, sending plain message: %w", event.ID, err))
+		return nil
+	}
+	return tmpl
+}
+
+// render builds the content of one notification in the recipient's
+// preferred language and reports the language used. Without a template, or
+// if it fails, the event's title and message are sent.
+func render(tmpl *templating.Compiled, event models.Event, profile *models.Recipient, channel, address string) (templating.Rendered, string) {
+	data := templating.Data{Event: event, Address: address}
+	if profile != nil {
+		data.Recipient = *profile
+	}
+	data.Event.Message, data.Language = event.MessageFor(data.Recipient.Language)
+
+	plain := templating.Rendered{Text: data.Event.Message}
+	switch channel {
+	case "email", "push", "webpush", "telegram", "slack":
+		plain.Subject = event.Title
+	}
+	if tmpl == nil {
+		return plain, data.Language
+	}
+
+	content, err := tmpl.Render(channel, data)
+	if err != nil {
+		logger.Error(fmt.Errorf("event %s: %w", event.ID, err))
+		return plain, data.Language
+	}
+	if content.Subject == "" {
+		content.Subject = plain.Subject
+	}
+	return content, data.Language
+}
+
+// lookupRecipient returns the directory profile for an event recipient, or
+// nil when the recipient is a raw address that is sent verbatim.
+func (d *Dispatcher) lookupRecipient(ctx context.Context, recipient string) *models.Recipient {
+	if d.directory == nil {
+		return nil
+	}
+	profile, err := d.directory.GetRecipient(ctx, recipient)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			logger.Error(fmt.Errorf("lookup recipient %s, sending as raw address: %w", recipient, err))
+		}
+		return nil
+	}
+	return profile
+}
+
+// Send delivers an already-saved notification through its channel handler
+// without creating a new record; the retry worker uses it for re-sends.
+// Addresses on the suppression list fail permanently without a send. Alerts
+// that ask to be acknowledged reach the handler with their AckURL set.
+func (d *Dispatcher) Send(ctx context.Context, notif models.Notification) models.DispatchResult {
+	handler, exists := d.handlers[notif.Channel]
+	if !exists {
+		return models.DispatchResult{
+			NotificationID: notif.ID,
+			Success:        false,
+			Error:          "unknown channel: " + notif.Channel,
+			ErrorClass:     models.ErrorPermanent,
+			Timestamp:      time.Now(),
+		}
+	}
+	notif.AckURL = d.ackLink(notif)
+	if d.suppressed(ctx, notif) {
+		return models.DispatchResult{
+			NotificationID: notif.ID,
+			Success:        false,
+			Error:          "recipient opted out of " + notif.Channel,
+			ErrorClass:     models.ErrorPermanent,
+			Timestamp:      time.Now(),
+		}
+	}
+	result := handler.Send(ctx, notif)
+	if !result.Success && result.ErrorClass == "" {
+		result.ErrorClass = models.ErrorTransient
+	}
+	return result
+}
+
+// suppressed reports whether the notification's address is on the
+// suppression list. A failing lookup does not hold back an alert.
+func (d *Dispatcher) suppressed(ctx context.Context, notif models.Notification) bool {
+	if d.suppressions == nil {
+		return false
+	}
+	ok, err := d.suppressions.IsSuppressed(ctx, notif.Channel, notif.Recipient)
+	if err != nil {
+		logger.Error(fmt.Errorf("check suppression of %s: %w", notif.Recipient, err))
+		return false
+	}
+	return ok
+}
+
+// RecordResult persists the outcome of a send attempt, or of a delivery
+// reported later by provider callback: success, a hand-over still awaiting
+// its outcome, a retry scheduled with exponential backoff (or after the
+// provider's Retry-After when it gave one), or permanent failure once the
+// notification has used up its retries or the failure is classed permanent.
+// A retry that would land after the notification expires is not scheduled;
+// the notification expires instead.
+func (d *Dispatcher) RecordResult(ctx context.Context, notif models.Notification, result models.DispatchResult) {
+	if result.APIStatusCode != 0 || result.APIResponse != "" {
+		if err := d.store.UpdateAPIResponse(ctx, notif.ID, result.APIStatusCode, result.APIResponse); err != nil {
+			logger.Error(fmt.Errorf("record api response: %w", err))
+		}
+	}
+	if result.ProviderMessageID != "" {
+		if err := d.store.SetProviderMessageID(ctx, notif.ID, result.ProviderMessageID); err != nil {
+			logger.Error(fmt.Errorf("record provider message id: %w", err))
+		}
+	}
+
+	if result.DeadAddress {
+		d.markDeadAddress(ctx, notif)
+	}
+
+	if result.Success && result.Pending {
+		_ = d.store.RemoveFromRetryQueue(ctx, notif.ID)
+		if d.setStatus(ctx, notif, "queued", "") {
+			logger.Info(fmt.Sprintf("→ Dispatch queued: %s to %s via %s, awaiting outcome", notif.ID, notif.Recipient, notif.Channel))
+		}
+		return
+	}
+	if result.Success {
+		_ = d.store.RemoveFromRetryQueue(ctx, notif.ID)
+		if d.setStatus(ctx, notif, "success", "") {
+			logger.Info(fmt.Sprintf("✓ Dispatch success: %s to %s via %s", notif.ID, notif.Recipient, notif.Channel))
+		}
+		return
+	}
+
+	// increment attempts and persist last error
+	newAttempts, err := d.store.IncrementAttempts(ctx, notif.ID, result.Error)
+	if err != nil {
+		logger.Error(fmt.Errorf("increment attempts: %w", err))
+		// fallback: assume one more attempt so the retry is not lost
+		newAttempts = notif.Attempts + 1
+	}
+
+	// determine max retries (use stored value if present, else fallback)
+	maxRetries := notif.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = 5 // default if not set via model/env
+	}
+
+	// if we've hit or exceeded max retries, or the provider rejected the
+	// notification outright, mark permanent failure
+	if newAttempts >= maxRetries || result.IsPermanent() {
+		_ = d.store.RemoveFromRetryQueue(ctx, notif.ID)
+		if d.setStatus(ctx, notif, "failed_permanent", result.Error) {
+			logger.Info(fmt.Sprintf("✗ Permanent failure: %s to %s via %s - %s", notif.ID, notif.Recipient, notif.Channel, result.Error))
+		}
+		return
+	}
+
+	// compute exponential backoff, or wait as long as a rate-limiting or
+	// unavailable provider asked
+	baseSeconds := int64(300)  // 5 minutes base
+	maxBackoff := int64(86400) // cap backoff at 24 hours
+	delay := time.Duration(min(baseSeconds<<uint(newAttempts-1), maxBackoff)) * time.Second
+	if result.RetryAfter > 0 {
+		delay = min(result.RetryAfter, time.Duration(maxBackoff)*time.Second)
+	}
+	nextRetry := time.Now().Add(delay)
+
+	if notif.Expired(nextRetry) {
+		d.MarkExpired(ctx, notif)
+		return
+	}
+
+	if !d.setStatus(ctx, notif, "failed", result.Error) {
+		// cancelled or otherwise settled while it was being sent
+		_ = d.store.RemoveFromRetryQueue(ctx, notif.ID)
+		return
+	}
+	if err := d.store.ScheduleRetry(ctx, notif.ID, nextRetry, result.Error); err != nil {
+		logger.Error(fmt.Errorf("schedule retry failed for %s: %w", notif.ID, err))
+		return
+	}
+	logger.Info(fmt.Sprintf("✗ Dispatch failed: %s to %s via %s - %s [%s] (attempt %d/%d) will retry at %s",
+		notif.ID, notif.Recipient, notif.Channel, result.Error, result.ErrorClass, newAttempts, maxRetries, nextRetry.Format(time.RFC3339)))
+}
+
+// markDeadAddress retires a push token or browser subscription the provider
+// no longer recognises so later events skip it. Raw addresses are not in the
+// directory.
+func (d *Dispatcher) markDeadAddress(ctx context.Context, notif models.Notification) {
+	if d.directory == nil || notif.RecipientID == "" {
+		return
+	}
+	var err error
+	switch notif.Channel {
+	case "push":
+		err = d.directory.MarkPushTokenDead(ctx, notif.RecipientID, notif.Recipient)
+	case "webpush":
+		err = d.directory.DeleteWebPushSubscription(ctx, notif.RecipientID, notif.Recipient)
+		if errors.Is(err, storage.ErrNotFound) {
+			return // already unsubscribed
+		}
+	default:
+		return
+	}
+	if err != nil {
+		logger.Error(fmt.Errorf("retire dead %s address of %s: %w", notif.Channel, notif.RecipientID, err))
+		return
+	}
+	logger.Info(fmt.Sprintf("Dead %s address of recipient %s retired", notif.Channel, notif.RecipientID))
+}
+
+// deliveryStages orders the statuses a handed-over notification passes
+// through before its outcome is known.
+var deliveryStages = map[string]int{"pending": 0, "queued": 1, "sent": 2}
+
+// Progress records an intermediate delivery status reported by a provider,
+// "queued" or "sent". Reports that arrive out of order, or after the outcome
+// is known, are ignored.
+func (d *Dispatcher) Progress(ctx context.Context, notif models.Notification, status string) {
+	current, inFlight := deliveryStages[notif.Status]
+	next, ok := deliveryStages[status]
+	if !ok || !inFlight || next <= current {
+		return
+	}
+	d.setStatus(ctx, notif, status, "")
+	logger.Info(fmt.Sprintf("→ Dispatch %s: %s to %s via %s", status, notif.ID, notif.Recipient, notif.Channel))
+}
+
+// Acknowledge records that the recipient confirmed they received the
+// notification, e.g. by a keypress during a voice call. The first
+// acknowledgement also counts for the recipient's other notifications of
+// the event, which are no longer escalated or retried. Only notifications
+// handed over to the provider (storage.HandedOver) can be acknowledged; it
+// reports false, changing nothing, for one that was cancelled, expired,
+// failed for good or never sent.
+func (d *Dispatcher) Acknowledge(ctx context.Context, notif models.Notification, via string) bool {
+	ok, err := d.store.TransitionNotificationStatus(ctx, notif.ID, storage.HandedOver, "acknowledged", "")
+	if err != nil {
+		logger.Error(fmt.Errorf("acknowledge %s: %w", notif.ID, err))
+		return false
+	}
+	if !ok {
+		logger.Info(fmt.Sprintf("Not acknowledging %s via %s: it was not handed over", notif.ID, via))
+		return false
+	}
+	metrics.Inc(statusMetric, "channel", notif.Channel, "status", "acknowledged")
+	_ = d.store.RemoveFromRetryQueue(ctx, notif.ID)
+	if d.acks != nil {
+		_, err := d.acks.RecordAck(ctx, models.Ack{
+			EventID:        notif.EventID,
+			Recipient:      notif.RecipientKey(),
+			NotificationID: notif.ID,
+			Channel:        notif.Channel,
+			Via:            via,
+			AckedAt:        time.Now(),
+		})
+		if err != nil {
+			logger.Error(fmt.Errorf("record ack of %s: %w", notif.ID, err))
+		}
+	}
+	logger.Info(fmt.Sprintf("✔ Acknowledged: %s by %s via %s", notif.ID, notif.Recipient, via))
+	return true
+}
+
+// AckedElsewhere reports whether the notification's recipient has already
+// acknowledged its event on some channel. A failing lookup does not hold
+// back an alert.
+func (d *Dispatcher) AckedElsewhere(ctx context.Context, notif models.Notification) bool {
+	if d.acks == nil || notif.EventID == "" {
+		return false
+	}
+	ok, err := d.acks.IsAcknowledged(ctx, notif.EventID, notif.RecipientKey())
+	if err != nil {
+		logger.Error(fmt.Errorf("check ack of %s: %w", notif.ID, err))
+		return false
+	}
+	return ok
+}
+
+// Skip drops a notification that no longer needs sending, e.g. an
+// escalation step for a recipient who acknowledged the alert.
+func (d *Dispatcher) Skip(ctx context.Context, notif models.Notification, reason string) {
+	d.setStatus(ctx, notif, "skipped", reason)
+	_ = d.store.RemoveFromRetryQueue(ctx, notif.ID)
+	logger.Info(fmt.Sprintf("↷ Skipped: %s to %s via %s - %s", notif.ID, notif.Recipient, notif.Channel, reason))
+}
+
+// MarkExpired stops all further delivery of a notification past its window.
+func (d *Dispatcher) MarkExpired(ctx context.Context, notif models.Notification) {
+	d.setStatus(ctx, notif, "expired", "alert expired before delivery")
+	_ = d.store.RemoveFromRetryQueue(ctx, notif.ID)
+	logger.Info(fmt.Sprintf("⌛ Expired: %s to %s via %s", notif.ID, notif.Recipient, notif.Channel))
+}
+
+const statusMetric = "notifications_status_total"
+
+func init() {
+	metrics.Describe(statusMetric, "Notification status transitions by channel and status.")
+}
+
+// setStatus records a status transition and counts it. Only notifications
+// still in one of the storage.Unsettled states move; it reports false when
+// the notification was settled meanwhile, e.g. cancelled while it was being
+// sent. A failing write is logged and treated as done.
+func (d *Dispatcher) setStatus(ctx context.Context, notif models.Notification, status, errMsg string) bool {
+	ok, err := d.store.TransitionNotificationStatus(ctx, notif.ID, storage.Unsettled, status, errMsg)
+	if err != nil {
+		logger.Error(fmt.Errorf("set status of %s to %s: %w", notif.ID, status, err))
+		return true
+	}
+	if !ok {
+		logger.Info(fmt.Sprintf("Notification %s was settled meanwhile, not marking it %s", notif.ID, status))
+		return false
+	}
+	metrics.Inc(statusMetric, "channel", notif.Channel, "status", status)
+	return true
+}
@@ -0,0 +1,407 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+)
+
+// slowHandler records how many sends overlap.
+type slowHandler struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (h *slowHandler) Send(ctx context.Context, n models.Notification) models.DispatchResult {
+	h.mu.Lock()
+	h.inFlight++
+	h.peak = max(h.peak, h.inFlight)
+	h.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	h.mu.Lock()
+	h.inFlight--
+	h.mu.Unlock()
+	return models.DispatchResult{NotificationID: n.ID, Success: true, Timestamp: time.Now()}
+}
+
+func TestDispatchEventBoundsConcurrency(t *testing.T) {
+	d := NewDispatcher(memorystore.New())
+	handler := &slowHandler{}
+	d.Register("sms", handler)
+	d.SetConcurrency(4)
+
+	event := models.Event{ID: "evt-1", Type: "flood", Message: "m", Channels: []string{"sms"}}
+	for i := range 40 {
+		event.Recipients = append(event.Recipients, fmt.Sprintf("+9100000000%02d", i))
+	}
+	results := d.DispatchEvent(context.Background(), event)
+
+	if len(results) != 40 {
+		t.Errorf("got %d results, want 40", len(results))
+	}
+	if handler.peak > 4 {
+		t.Errorf("%d sends overlapped, want at most 4", handler.peak)
+	}
+}
+
+// addressRecorder records the channel and address of every send.
+type addressRecorder struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (h *addressRecorder) Send(ctx context.Context, n models.Notification) models.DispatchResult {
+	h.mu.Lock()
+	h.sent = append(h.sent, n.Channel+" "+n.Recipient+" "+n.RecipientID)
+	h.mu.Unlock()
+	return models.DispatchResult{NotificationID: n.ID, Success: true, Timestamp: time.Now()}
+}
+
+func TestDispatchEventResolvesDirectoryRecipients(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	if err := mem.SaveRecipient(ctx, models.Recipient{
+		ID:         "asha",
+		Phones:     []string{"+911111111111", "+912222222222"},
+		PushTokens: []models.PushToken{{Token: "live"}, {Token: "gone", Dead: true}},
+	}); err != nil {
+		t.Fatalf("SaveRecipient: %v", err)
+	}
+	d := NewDispatcher(mem)
+	d.SetDirectory(mem)
+	h := &addressRecorder{}
+	for _, ch := range []string{"sms", "push", "telegram"} {
+		d.Register(ch, h)
+	}
+
+	// asha has no telegram handle and is only sent on her channels; an
+	// unknown ID is a raw address on every channel
+	event := models.Event{ID: "evt-1", Type: "flood", Message: "m", Channels: []string{"sms", "push", "telegram"},
+		Recipients:        []string{"asha", "+913333333333"},
+		RecipientChannels: map[string][]string{"+913333333333": {"sms"}}}
+	d.DispatchEvent(ctx, event)
+
+	slices.Sort(h.sent)
+	want := []string{
+		"push live asha",
+		"sms +911111111111 asha",
+		"sms +912222222222 asha",
+		"sms +913333333333 ",
+	}
+	if !slices.Equal(h.sent, want) {
+		t.Errorf("sent %q, want %q", h.sent, want)
+	}
+}
+
+// cancellingHandler cancels the event during the first send, as a cancel
+// arriving mid-dispatch would, and counts the sends that went out.
+type cancellingHandler struct {
+	mem   *memorystore.MemoryStore
+	sends int
+}
+
+func (h *cancellingHandler) Send(ctx context.Context, n models.Notification) models.DispatchResult {
+	h.sends++
+	if h.sends == 1 {
+		_ = h.mem.UpdateEventStatus(ctx, n.EventID, "cancelled")
+		_, _ = h.mem.TransitionNotificationStatus(ctx, n.ID, []string{"pending"}, "cancelled", "cancelled by test")
+	}
+	return models.DispatchResult{NotificationID: n.ID, Success: true, Timestamp: time.Now()}
+}
+
+func TestDispatchEventStopsWhenCancelled(t *testing.T) {
+	interval := eventCheckInterval
+	eventCheckInterval = 0
+	t.Cleanup(func() { eventCheckInterval = interval })
+
+	ctx := context.Background()
+	mem := memorystore.New()
+	event := models.Event{ID: "evt-1", Type: "flood", Message: "m", Channels: []string{"sms"},
+		Recipients: []string{"+911", "+912", "+913"}}
+	if err := mem.SaveEvent(ctx, models.EventRecord{Event: event, Status: "processing"}); err != nil {
+		t.Fatalf("SaveEvent: %v", err)
+	}
+	d := NewDispatcher(mem)
+	d.SetEvents(mem)
+	d.SetConcurrency(1)
+	handler := &cancellingHandler{mem: mem}
+	d.Register("sms", handler)
+
+	d.DispatchEvent(ctx, event)
+
+	if handler.sends != 1 {
+		t.Errorf("%d sends after the cancel, want only the one in flight", handler.sends-1)
+	}
+	notifs, _, _ := mem.ListNotificationsByEvent(ctx, event.ID, 0, 10)
+	if len(notifs) != 3 {
+		t.Fatalf("got %d notifications, want 3", len(notifs))
+	}
+	for _, n := range notifs {
+		// the send in flight finished after the cancel and must not undo it
+		if n.Status != "cancelled" {
+			t.Errorf("%s to %s is %s, want cancelled", n.ID, n.Recipient, n.Status)
+		}
+	}
+}
+
+// updatingHandler updates the event during the first send, as an update
+// arriving mid-dispatch would.
+type updatingHandler struct {
+	mem   *memorystore.MemoryStore
+	event models.Event
+	sends int
+}
+
+func (h *updatingHandler) Send(ctx context.Context, n models.Notification) models.DispatchResult {
+	h.sends++
+	if h.sends == 1 {
+		ev := h.event
+		ev.Message = "Evacuate now"
+		_ = h.mem.UpdateEventContent(ctx, ev)
+	}
+	return models.DispatchResult{NotificationID: n.ID, Success: true, Timestamp: time.Now()}
+}
+
+func TestDispatchEventPicksUpUpdates(t *testing.T) {
+	interval := eventCheckInterval
+	eventCheckInterval = 0
+	t.Cleanup(func() { eventCheckInterval = interval })
+
+	ctx := context.Background()
+	mem := memorystore.New()
+	stored := models.Event{ID: "evt-1", Type: "flood", Message: "River rising", Channels: []string{"sms"}}
+	if err := mem.SaveEvent(ctx, models.EventRecord{Event: stored, Status: "processing"}); err != nil {
+		t.Fatalf("SaveEvent: %v", err)
+	}
+	d := NewDispatcher(mem)
+	d.SetEvents(mem)
+	d.SetConcurrency(1)
+	d.Register("sms", &updatingHandler{mem: mem, event: stored})
+
+	// recipients come from the stages, not the stored copy
+	event := stored
+	event.Recipients = []string{"+911", "+912", "+913"}
+	d.DispatchEvent(ctx, event)
+
+	notifs, _, _ := mem.ListNotificationsByEvent(ctx, event.ID, 0, 10)
+	if len(notifs) != 3 {
+		t.Fatalf("got %d notifications, want 3", len(notifs))
+	}
+	for i, n := range notifs {
+		want := "Evacuate now"
+		if i == 0 {
+			want = "River rising"
+		}
+		if n.Message != want {
+			t.Errorf("notification %d to %s says %q, want %q", i, n.Recipient, n.Message, want)
+		}
+	}
+}
+
+func TestAcknowledgeOnlyHandedOverNotifications(t *testing.T) {
+	tests := []struct {
+		status string
+		acked  bool
+	}{
+		{"queued", true},
+		{"success", true},
+		{"failed", true},
+		{"pending", false},
+		{"scheduled", false},
+		{"cancelled", false},
+		{"expired", false},
+		{"failed_permanent", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			ctx := context.Background()
+			mem := memorystore.New()
+			d := NewDispatcher(mem)
+			d.SetAcknowledgements(mem, nil, nil)
+			notif := models.Notification{ID: "notif-1", EventID: "evt-1", Recipient: "+911", Channel: "sms", Status: tt.status}
+			if err := mem.SaveNotification(ctx, notif); err != nil {
+				t.Fatalf("SaveNotification: %v", err)
+			}
+
+			if got := d.Acknowledge(ctx, notif, "sms reply"); got != tt.acked {
+				t.Errorf("Acknowledge = %v, want %v", got, tt.acked)
+			}
+			want := tt.status
+			if tt.acked {
+				want = "acknowledged"
+			}
+			if got, _ := mem.GetNotification(ctx, notif.ID); got.Status != want {
+				t.Errorf("status = %s, want %s", got.Status, want)
+			}
+			if acked, _ := mem.IsAcknowledged(ctx, "evt-1", "+911"); acked != tt.acked {
+				t.Errorf("ack recorded = %v, want %v", acked, tt.acked)
+			}
+		})
+	}
+}
+
+func TestRecordResultWaitsForRetryAfter(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	d := NewDispatcher(mem)
+	notif := models.Notification{ID: "notif-1", EventID: "evt-1", Recipient: "device-1", Channel: "push", Status: "pending"}
+	if err := mem.SaveNotification(ctx, notif); err != nil {
+		t.Fatalf("SaveNotification: %v", err)
+	}
+
+	// an unavailable provider asking for two minutes, well short of the
+	// five-minute backoff
+	d.RecordResult(ctx, notif, models.DispatchResult{NotificationID: notif.ID, Error: "FCM 503 UNAVAILABLE",
+		ErrorClass: models.ErrorTransient, RetryAfter: 2 * time.Minute})
+
+	if due, _ := mem.GetDueRetries(ctx, time.Now().Add(time.Minute), 10); len(due) != 0 {
+		t.Errorf("retry due within a minute: %v", due)
+	}
+	if due, _ := mem.GetDueRetries(ctx, time.Now().Add(3*time.Minute), 10); len(due) != 1 {
+		t.Errorf("retry not due within three minutes: %v", due)
+	}
+}
+
+func TestRecordResultSchedulesByErrorClass(t *testing.T) {
+	tests := []struct {
+		name   string
+		result models.DispatchResult
+		status string
+		due    time.Duration // retry due after this long, 0 for no retry
+	}{
+		{"InvalidNumber", models.DispatchResult{Error: "twilio error 21211: invalid 'To' phone number",
+			ErrorClass: models.ErrorPermanent, ErrorCode: "21211"}, "failed_permanent", 0},
+		{"Unsubscribed", models.DispatchResult{Error: "twilio error 21610: recipient unsubscribed by replying STOP",
+			ErrorClass: models.ErrorPermanent, ErrorCode: "21610"}, "failed_permanent", 0},
+		{"Timeout", models.DispatchResult{Error: "timeout", ErrorClass: models.ErrorTransient}, "failed", 5 * time.Minute},
+		{"RateLimited", models.DispatchResult{Error: "twilio rate limited", ErrorClass: models.ErrorRateLimited,
+			RetryAfter: 30 * time.Second}, "failed", 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mem := memorystore.New()
+			d := NewDispatcher(mem)
+			notif := models.Notification{ID: "notif-1", EventID: "evt-1", Recipient: "+911", Channel: "sms", Status: "pending", MaxRetries: 5}
+			if err := mem.SaveNotification(ctx, notif); err != nil {
+				t.Fatalf("SaveNotification: %v", err)
+			}
+			result := tt.result
+			result.NotificationID = notif.ID
+			start := time.Now()
+			d.RecordResult(ctx, notif, result)
+
+			got, _ := mem.GetNotification(ctx, notif.ID)
+			if got.Status != tt.status || got.Attempts != 1 {
+				t.Errorf("status/attempts = %s/%d, want %s/1", got.Status, got.Attempts, tt.status)
+			}
+			if due, _ := mem.GetDueRetries(ctx, start.Add(tt.due-time.Second), 10); len(due) != 0 {
+				t.Errorf("retry due before %s: %v", tt.due, due)
+			}
+			wantDue := 1
+			if tt.due == 0 {
+				wantDue = 0
+			}
+			if due, _ := mem.GetDueRetries(ctx, time.Now().Add(tt.due+time.Second), 10); len(due) != wantDue {
+				t.Errorf("%d retries due after %s, want %d", len(due), tt.due, wantDue)
+			}
+		})
+	}
+}
+
+func TestSendSkipsSuppressedAddresses(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	d := NewDispatcher(mem)
+	d.SetSuppressions(mem)
+	h := &addressRecorder{}
+	d.Register("sms", h)
+	d.Register("email", h)
+	if err := mem.Suppress(ctx, models.Suppression{Channel: "sms", Address: "+911", Reason: "STOP", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Suppress: %v", err)
+	}
+
+	tests := []struct {
+		channel, address string
+		sent             bool
+	}{
+		{"sms", "+911", false},
+		{"sms", "+912", true},
+		// an SMS opt-out says nothing about other channels
+		{"email", "+911", true},
+	}
+	for _, tt := range tests {
+		h.sent = nil
+		res := d.Send(ctx, models.Notification{ID: "notif-1", Recipient: tt.address, Channel: tt.channel})
+		if res.Success != tt.sent || (len(h.sent) == 1) != tt.sent {
+			t.Errorf("%s to %s: success=%v, %d sends, want sent=%v", tt.channel, tt.address, res.Success, len(h.sent), tt.sent)
+		}
+		if !tt.sent && res.ErrorClass != models.ErrorPermanent {
+			t.Errorf("%s to %s: class %s, want a permanent failure so it is not retried", tt.channel, tt.address, res.ErrorClass)
+		}
+	}
+}
+
+func TestDispatchEventExpired(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	d := NewDispatcher(mem)
+	h := &addressRecorder{}
+	d.Register("sms", h)
+	d.Register("email", h)
+
+	// sat in the queue past its window
+	expired := time.Now().Add(-time.Minute)
+	event := models.Event{ID: "evt-1", Type: "flood", Message: "m", Channels: []string{"sms", "email"},
+		Recipients: []string{"+911", "a@example.org"}, ExpiresAt: &expired,
+		RecipientChannels: map[string][]string{"+911": {"sms"}, "a@example.org": {"email"}}}
+	d.DispatchEvent(ctx, event)
+
+	if len(h.sent) != 0 {
+		t.Errorf("sent %q after the alert expired", h.sent)
+	}
+	notifs, total, err := mem.ListNotificationsByEvent(ctx, "evt-1", 0, 10)
+	if err != nil || total != 2 {
+		t.Fatalf("ListNotificationsByEvent = %d, %v, want a record per recipient", total, err)
+	}
+	for _, n := range notifs {
+		if n.Status != "expired" {
+			t.Errorf("%s to %s is %s, want expired", n.Channel, n.Recipient, n.Status)
+		}
+	}
+	if due, _ := mem.GetDueRetries(ctx, time.Now().Add(time.Hour), 10); len(due) != 0 {
+		t.Errorf("retries queued: %v", due)
+	}
+}
+
+func TestRecordResultExpiresInsteadOfLateRetry(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	d := NewDispatcher(mem)
+	expires := time.Now().Add(time.Minute)
+	notif := models.Notification{ID: "notif-1", EventID: "evt-1", Recipient: "+911", Channel: "sms",
+		Status: "pending", MaxRetries: 5, ExpiresAt: &expires}
+	if err := mem.SaveNotification(ctx, notif); err != nil {
+		t.Fatalf("SaveNotification: %v", err)
+	}
+
+	// the five-minute backoff lands after the alert expires
+	d.RecordResult(ctx, notif, models.DispatchResult{NotificationID: notif.ID, Error: "timeout",
+		ErrorClass: models.ErrorTransient})
+
+	if got, _ := mem.GetNotification(ctx, notif.ID); got.Status != "expired" {
+		t.Errorf("status = %s, want expired", got.Status)
+	}
+	if due, _ := mem.GetDueRetries(ctx, time.Now().Add(time.Hour), 10); len(due) != 0 {
+		t.Errorf("retry scheduled past expiry: %v", due)
+	}
+}
@@ -0,0 +1,207 @@
+// Package geo has the geometry used to target alerts at an area: distances,
+// point-in-polygon tests and GeoJSON parsing. Coordinates are WGS84 degrees.
+package geo
+
+import (
+	"fmt"
+	"math"
+
+	"notification-service/pkg/models"
+)
+
+const earthRadiusKm = 6371.0088
+
+// DistanceKm is the great-circle (haversine) distance between two points.
+func DistanceKm(a, b models.GeoPoint) float64 {
+	lat1, lat2 := rad(a.Lat), rad(b.Lat)
+	dLat := lat2 - lat1
+	dLon := rad(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func rad(deg float64) float64 { return deg * math.Pi / 180 }
+
+// Shape is a target region. BoundingCircle returns a circle that contains
+// the whole shape, so a radius query against a location index can narrow
+// candidates before the exact Contains test.
+type Shape interface {
+	Contains(p models.GeoPoint) bool
+	BoundingCircle() models.Circle
+}
+
+// Circle is a Shape for a centre and radius.
+type Circle models.Circle
+
+func (c Circle) Contains(p models.GeoPoint) bool {
+	return DistanceKm(c.Center, p) <= c.RadiusKm
+}
+
+func (c Circle) BoundingCircle() models.Circle {
+	return models.Circle(c)
+}
+
+// Polygon is a Shape for an outer ring with optional holes. Rings are
+// treated as planar in lat/lon, which is accurate enough for district- and
+// coastline-sized areas away from the poles. A ring with an edge spanning
+// more than 180° of longitude is taken to cross the antimeridian rather than
+// circle the globe. Points on the boundary are inside, so neighbouring areas
+// that touch both reach the recipients on their shared border.
+type Polygon struct {
+	Outer []models.GeoPoint
+	Holes [][]models.GeoPoint
+}
+
+func (pg Polygon) Contains(p models.GeoPoint) bool {
+	wrap := crossesAntimeridian(pg.Outer)
+	p = unwrap(p, wrap)
+	if !ringContains(unwrapRing(pg.Outer, wrap), p) {
+		return false
+	}
+	for _, h := range pg.Holes {
+		if h := unwrapRing(h, wrap); ringContains(h, p) && !onRing(h, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// BoundingCircle centres on the bounding box and reaches the furthest vertex,
+// with a small margin for the planar/spherical mismatch.
+func (pg Polygon) BoundingCircle() models.Circle {
+	outer := unwrapRing(pg.Outer, crossesAntimeridian(pg.Outer))
+	minLat, maxLat := math.Inf(1), math.Inf(-1)
+	minLon, maxLon := math.Inf(1), math.Inf(-1)
+	for _, v := range outer {
+		minLat, maxLat = math.Min(minLat, v.Lat), math.Max(maxLat, v.Lat)
+		minLon, maxLon = math.Min(minLon, v.Lon), math.Max(maxLon, v.Lon)
+	}
+	center := models.GeoPoint{Lat: (minLat + maxLat) / 2, Lon: (minLon + maxLon) / 2}
+	if center.Lon > 180 {
+		center.Lon -= 360
+	}
+	r := 0.0
+	for _, v := range pg.Outer {
+		r = math.Max(r, DistanceKm(center, v))
+	}
+	return models.Circle{Center: center, RadiusKm: r*1.01 + 0.1}
+}
+
+// crossesAntimeridian reports whether a ring has an edge spanning more than
+// half the globe, which only makes sense the short way round, across 180°.
+func crossesAntimeridian(ring []models.GeoPoint) bool {
+	for i := 1; i < len(ring); i++ {
+		if math.Abs(ring[i].Lon-ring[i-1].Lon) > 180 {
+			return true
+		}
+	}
+	return false
+}
+
+// unwrap moves a western longitude past 180° when wrap is set, so a ring
+// crossing the antimeridian can be tested in continuous coordinates.
+func unwrap(p models.GeoPoint, wrap bool) models.GeoPoint {
+	if wrap && p.Lon < 0 {
+		p.Lon += 360
+	}
+	return p
+}
+
+func unwrapRing(ring []models.GeoPoint, wrap bool) []models.GeoPoint {
+	if !wrap {
+		return ring
+	}
+	out := make([]models.GeoPoint, len(ring))
+	for i, v := range ring {
+		out[i] = unwrap(v, true)
+	}
+	return out
+}
+
+// ringContains is the even-odd ray casting test, counting points on the
+// ring itself as inside.
+func ringContains(ring []models.GeoPoint, p models.GeoPoint) bool {
+	if onRing(ring, p) {
+		return true
+	}
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lon < (b.Lon-a.Lon)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// boundaryEpsilon is how close, in degrees (about 0.1 mm), a point must be
+// to an edge to count as on it.
+const boundaryEpsilon = 1e-9
+
+// onRing reports whether p lies on one of the ring's edges.
+func onRing(ring []models.GeoPoint, p models.GeoPoint) bool {
+	for i := 1; i < len(ring); i++ {
+		a, b := ring[i-1], ring[i]
+		if p.Lat < math.Min(a.Lat, b.Lat)-boundaryEpsilon || p.Lat > math.Max(a.Lat, b.Lat)+boundaryEpsilon ||
+			p.Lon < math.Min(a.Lon, b.Lon)-boundaryEpsilon || p.Lon > math.Max(a.Lon, b.Lon)+boundaryEpsilon {
+			continue
+		}
+		cross := (b.Lon-a.Lon)*(p.Lat-a.Lat) - (b.Lat-a.Lat)*(p.Lon-a.Lon)
+		if math.Abs(cross) <= boundaryEpsilon*math.Max(1, math.Hypot(b.Lon-a.Lon, b.Lat-a.Lat)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShapesFromArea converts an event area (polygons, circles and/or GeoJSON)
+// into shapes. Geocodes cannot be resolved geometrically and are ignored.
+func ShapesFromArea(area models.Area) ([]Shape, error) {
+	var shapes []Shape
+	for i, ring := range area.Polygons {
+		if err := validRing(ring); err != nil {
+			return nil, fmt.Errorf("polygon %d: %w", i, err)
+		}
+		shapes = append(shapes, Polygon{Outer: ring})
+	}
+	for i, c := range area.Circles {
+		if err := validPoint(c.Center); err != nil {
+			return nil, fmt.Errorf("circle %d: %w", i, err)
+		}
+		if c.RadiusKm <= 0 {
+			return nil, fmt.Errorf("circle %d: radius_km must be positive", i)
+		}
+		shapes = append(shapes, Circle(c))
+	}
+	if len(area.GeoJSON) > 0 {
+		gj, err := ParseGeoJSON(area.GeoJSON)
+		if err != nil {
+			return nil, fmt.Errorf("geojson: %w", err)
+		}
+		shapes = append(shapes, gj...)
+	}
+	return shapes, nil
+}
+
+func validRing(ring []models.GeoPoint) error {
+	if len(ring) < 4 {
+		return fmt.Errorf("needs at least 4 points, got %d", len(ring))
+	}
+	if ring[0] != ring[len(ring)-1] {
+		return fmt.Errorf("first and last points must be equal")
+	}
+	for _, p := range ring {
+		if err := validPoint(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validPoint(p models.GeoPoint) error {
+	if p.Lat < -90 || p.Lat > 90 || p.Lon < -180 || p.Lon > 180 {
+		return fmt.Errorf("point (%g, %g) is out of range", p.Lat, p.Lon)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package geo
+
+import (
+	"testing"
+
+	"notification-service/pkg/models"
+)
+
+func pt(lat, lon float64) models.GeoPoint { return models.GeoPoint{Lat: lat, Lon: lon} }
+
+// box is a closed rectangular ring.
+func box(south, west, north, east float64) []models.GeoPoint {
+	return []models.GeoPoint{pt(south, west), pt(south, east), pt(north, east), pt(north, west), pt(south, west)}
+}
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b models.GeoPoint
+		want float64
+	}{
+		{"Same", pt(19.8, 85.8), pt(19.8, 85.8), 0},
+		{"OneDegreeOfLatitude", pt(0, 0), pt(1, 0), 111.2},
+		{"MumbaiChennai", pt(19.076, 72.8777), pt(13.0827, 80.2707), 1030},
+		{"AcrossAntimeridian", pt(0, 179.5), pt(0, -179.5), 111.2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DistanceKm(tt.a, tt.b); got < tt.want*0.99 || got > tt.want*1.01+0.01 {
+				t.Errorf("DistanceKm = %.1f, want about %.1f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircleContains(t *testing.T) {
+	puri := Circle{Center: pt(19.8135, 85.8312), RadiusKm: 10}
+	tests := []struct {
+		name string
+		p    models.GeoPoint
+		want bool
+	}{
+		{"Centre", pt(19.8135, 85.8312), true},
+		{"Inside", pt(19.85, 85.85), true},
+		{"Outside", pt(19.95, 85.83), false},
+		{"Konark", pt(19.8876, 86.0945), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := puri.Contains(tt.p); got != tt.want {
+				t.Errorf("Contains(%v) = %v, want %v (%.2f km away)", tt.p, got, tt.want, DistanceKm(puri.Center, tt.p))
+			}
+		})
+	}
+
+	// the radius itself is inside
+	edge := pt(19.9, 85.8312)
+	onRadius := Circle{Center: puri.Center, RadiusKm: DistanceKm(puri.Center, edge)}
+	if !onRadius.Contains(edge) {
+		t.Error("point at exactly the radius is outside")
+	}
+	if bc := puri.BoundingCircle(); bc != models.Circle(puri) {
+		t.Errorf("BoundingCircle = %+v, want the circle itself", bc)
+	}
+}
+
+func TestPolygonContains(t *testing.T) {
+	// a district with a lake cut out of it
+	district := Polygon{
+		Outer: box(19, 85, 20, 86),
+		Holes: [][]models.GeoPoint{box(19.4, 85.4, 19.6, 85.6)},
+	}
+	// an L-shaped coast, concave at the north-east
+	coast := Polygon{Outer: []models.GeoPoint{
+		pt(0, 0), pt(0, 2), pt(1, 2), pt(1, 1), pt(2, 1), pt(2, 0), pt(0, 0),
+	}}
+
+	tests := []struct {
+		name  string
+		shape Polygon
+		p     models.GeoPoint
+		want  bool
+	}{
+		{"Inside", district, pt(19.2, 85.2), true},
+		{"North", district, pt(20.5, 85.5), false},
+		{"East", district, pt(19.5, 86.5), false},
+		{"InHole", district, pt(19.5, 85.5), false},
+		{"BesideHole", district, pt(19.5, 85.7), true},
+		{"HoleEdgeIsLand", district, pt(19.4, 85.5), true},
+		{"ConcaveInside", coast, pt(0.5, 1.5), true},
+		{"ConcaveNotch", coast, pt(1.5, 1.5), false},
+		{"ConcaveArm", coast, pt(1.5, 0.5), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.shape.Contains(tt.p); got != tt.want {
+				t.Errorf("Contains(%v) = %v, want %v", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolygonAcrossAntimeridian(t *testing.T) {
+	// around Fiji's Taveuni, from 179.5°E to 179.5°W
+	fiji := Polygon{Outer: []models.GeoPoint{
+		pt(-17, 179.5), pt(-17, -179.5), pt(-16, -179.5), pt(-16, 179.5), pt(-17, 179.5),
+	}}
+	tests := []struct {
+		name string
+		p    models.GeoPoint
+		want bool
+	}{
+		{"EastOfLine", pt(-16.5, 179.8), true},
+		{"WestOfLine", pt(-16.5, -179.8), true},
+		{"OnLine", pt(-16.5, 180), true},
+		{"OnLineNegative", pt(-16.5, -180), true},
+		{"FarEast", pt(-16.5, 178), false},
+		{"FarWest", pt(-16.5, -178), false},
+		{"Greenwich", pt(-16.5, 0), false},
+		{"South", pt(-17.5, 179.8), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fiji.Contains(tt.p); got != tt.want {
+				t.Errorf("Contains(%v) = %v, want %v", tt.p, got, tt.want)
+			}
+		})
+	}
+
+	bc := fiji.BoundingCircle()
+	if bc.Center.Lat != -16.5 || (bc.Center.Lon != 180 && bc.Center.Lon != -180) {
+		t.Errorf("bounding circle centred on %v, want (-16.5, 180)", bc.Center)
+	}
+	if bc.RadiusKm > 150 {
+		t.Errorf("bounding circle radius %.0f km, want it to hug the island rather than the globe", bc.RadiusKm)
+	}
+	for _, v := range fiji.Outer {
+		if DistanceKm(bc.Center, v) > bc.RadiusKm {
+			t.Errorf("vertex %v outside the bounding circle", v)
+		}
+	}
+}
+
+func TestShapesTouchingAtBoundary(t *testing.T) {
+	// two districts sharing the 85.5°E meridian and a third meeting them at
+	// a single corner
+	west := Polygon{Outer: box(19, 85, 20, 85.5)}
+	east := Polygon{Outer: box(19, 85.5, 20, 86)}
+	north := Polygon{Outer: box(20, 86, 21, 87)}
+
+	tests := []struct {
+		name  string
+		p     models.GeoPoint
+		shape Polygon
+	}{
+		{"SharedEdgeWest", pt(19.5, 85.5), west},
+		{"SharedEdgeEast", pt(19.5, 85.5), east},
+		{"SharedCornerEast", pt(20, 86), east},
+		{"SharedCornerNorth", pt(20, 86), north},
+		{"Vertex", pt(19, 85), west},
+		{"SouthEdge", pt(19, 85.25), west},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.shape.Contains(tt.p) {
+				t.Errorf("Contains(%v) = false, want points on the boundary inside", tt.p)
+			}
+		})
+	}
+
+	// just off the shared edge belongs to one side only
+	if p := pt(19.5, 85.5001); west.Contains(p) || !east.Contains(p) {
+		t.Errorf("%v: west %v, east %v, want only east", p, west.Contains(p), east.Contains(p))
+	}
+
+	// a circle that reaches the shared edge takes in the point on it
+	c := Circle{Center: pt(19.5, 85.4), RadiusKm: DistanceKm(pt(19.5, 85.4), pt(19.5, 85.5))}
+	if !c.Contains(pt(19.5, 85.5)) || !east.Contains(pt(19.5, 85.5)) {
+		t.Error("circle touching the east district does not share the tangent point")
+	}
+}
+
+func TestShapesFromArea(t *testing.T) {
+	area := models.Area{
+		Polygons: [][]models.GeoPoint{box(19, 85, 20, 86)},
+		Circles:  []models.Circle{{Center: pt(19.8, 85.8), RadiusKm: 5}},
+		GeoJSON:  []byte(`{"type":"Polygon","coordinates":[[[179.5,-17],[-179.5,-17],[-179.5,-16],[179.5,-16],[179.5,-17]]]}`),
+	}
+	shapes, err := ShapesFromArea(area)
+	if err != nil {
+		t.Fatalf("ShapesFromArea: %v", err)
+	}
+	if len(shapes) != 3 {
+		t.Fatalf("got %d shapes, want 3", len(shapes))
+	}
+	if !shapes[2].Contains(pt(-16.5, -179.8)) {
+		t.Error("GeoJSON polygon across the antimeridian misses a point west of it")
+	}
+
+	bad := []struct {
+		name string
+		area models.Area
+	}{
+		{"OpenRing", models.Area{Polygons: [][]models.GeoPoint{{pt(0, 0), pt(0, 1), pt(1, 1), pt(1, 0)}}}},
+		{"ShortRing", models.Area{Polygons: [][]models.GeoPoint{{pt(0, 0), pt(0, 1), pt(0, 0)}}}},
+		{"OutOfRange", models.Area{Polygons: [][]models.GeoPoint{box(0, 0, 91, 1)}}},
+		{"ZeroRadius", models.Area{Circles: []models.Circle{{Center: pt(0, 0)}}}},
+		{"BadGeoJSON", models.Area{GeoJSON: []byte(`{"type":"LineString","coordinates":[[0,0],[1,1]]}`)}},
+	}
+	for _, tt := range bad {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ShapesFromArea(tt.area); err == nil {
+				t.Error("ShapesFromArea accepted an invalid area")
+			}
+		})
+	}
+}
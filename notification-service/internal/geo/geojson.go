@@ -0,0 +1,131 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"notification-service/pkg/models"
+)
+
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSON        `json:"geometry"`
+	Geometries  []geoJSON       `json:"geometries"`
+	Features    []geoJSON       `json:"features"`
+	Properties  struct {
+		RadiusKm float64 `json:"radius_km"`
+	} `json:"properties"`
+}
+
+// ParseGeoJSON reads a GeoJSON Polygon, MultiPolygon, GeometryCollection,
+// Feature or FeatureCollection. A Point becomes a circle when its Feature has
+// a positive "radius_km" property. GeoJSON positions are [lon, lat].
+func ParseGeoJSON(raw []byte) ([]Shape, error) {
+	var g geoJSON
+	if err := json.Unmarshal(raw, &g); err != nil {
+		return nil, fmt.Errorf("malformed GeoJSON: %w", err)
+	}
+	shapes, err := g.shapes(0)
+	if err != nil {
+		return nil, err
+	}
+	if len(shapes) == 0 {
+		return nil, fmt.Errorf("GeoJSON contains no areas")
+	}
+	return shapes, nil
+}
+
+func (g geoJSON) shapes(radiusKm float64) ([]Shape, error) {
+	switch g.Type {
+	case "FeatureCollection":
+		var out []Shape
+		for _, f := range g.Features {
+			s, err := f.shapes(0)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, s...)
+		}
+		return out, nil
+	case "Feature":
+		if g.Geometry == nil {
+			return nil, nil
+		}
+		return g.Geometry.shapes(g.Properties.RadiusKm)
+	case "GeometryCollection":
+		var out []Shape
+		for _, sub := range g.Geometries {
+			s, err := sub.shapes(radiusKm)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, s...)
+		}
+		return out, nil
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &rings); err != nil {
+			return nil, fmt.Errorf("polygon coordinates: %w", err)
+		}
+		p, err := polygonFromRings(rings)
+		if err != nil {
+			return nil, err
+		}
+		return []Shape{p}, nil
+	case "MultiPolygon":
+		var polys [][][][]float64
+		if err := json.Unmarshal(g.Coordinates, &polys); err != nil {
+			return nil, fmt.Errorf("multipolygon coordinates: %w", err)
+		}
+		var out []Shape
+		for _, rings := range polys {
+			p, err := polygonFromRings(rings)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, p)
+		}
+		return out, nil
+	case "Point":
+		if radiusKm <= 0 {
+			return nil, fmt.Errorf("point needs a positive radius_km property")
+		}
+		var pos []float64
+		if err := json.Unmarshal(g.Coordinates, &pos); err != nil || len(pos) < 2 {
+			return nil, fmt.Errorf("point coordinates must be [lon, lat]")
+		}
+		c := models.Circle{Center: models.GeoPoint{Lat: pos[1], Lon: pos[0]}, RadiusKm: radiusKm}
+		if err := validPoint(c.Center); err != nil {
+			return nil, err
+		}
+		return []Shape{Circle(c)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON type %q", g.Type)
+	}
+}
+
+func polygonFromRings(rings [][][]float64) (Polygon, error) {
+	if len(rings) == 0 {
+		return Polygon{}, fmt.Errorf("polygon has no rings")
+	}
+	var p Polygon
+	for i, r := range rings {
+		ring := make([]models.GeoPoint, 0, len(r))
+		for _, pos := range r {
+			if len(pos) < 2 {
+				return Polygon{}, fmt.Errorf("position must be [lon, lat]")
+			}
+			ring = append(ring, models.GeoPoint{Lat: pos[1], Lon: pos[0]})
+		}
+		if err := validRing(ring); err != nil {
+			return Polygon{}, err
+		}
+		if i == 0 {
+			p.Outer = ring
+		} else {
+			p.Holes = append(p.Holes, ring)
+		}
+	}
+	return p, nil
+}
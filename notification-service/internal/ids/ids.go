@@ -0,0 +1,17 @@
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// New returns an identifier of the form <prefix>-<unixnano>-<random>.
+// The random suffix keeps IDs unique when many are generated in the same
+// nanosecond by concurrent goroutines.
+func New(prefix string) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", prefix, time.Now().UnixNano(), hex.EncodeToString(b))
+}
@@ -0,0 +1,83 @@
+// Package metrics keeps in-process counters and serves them in the
+// Prometheus text exposition format on /metrics.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	mu       sync.Mutex
+	counters = map[string]map[string]float64{} // name -> rendered labels -> value
+	help     = map[string]string{}
+)
+
+// Describe sets the HELP text shown for a counter.
+func Describe(name, text string) {
+	mu.Lock()
+	defer mu.Unlock()
+	help[name] = text
+}
+
+// Inc adds one to the counter name with the given label key/value pairs,
+// e.g. Inc("notifications_status_total", "channel", "sms", "status", "expired").
+func Inc(name string, labels ...string) {
+	Add(name, 1, labels...)
+}
+
+// Add adds v to the counter name with the given label key/value pairs.
+func Add(name string, v float64, labels ...string) {
+	key := renderLabels(labels)
+	mu.Lock()
+	defer mu.Unlock()
+	if counters[name] == nil {
+		counters[name] = map[string]float64{}
+	}
+	counters[name][key] += v
+}
+
+func renderLabels(labels []string) string {
+	if len(labels) < 2 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[i+1])
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], v))
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Handler serves all counters in Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		names := make([]string, 0, len(counters))
+		for name := range counters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if h := help[name]; h != "" {
+				fmt.Fprintf(w, "# HELP %s %s\n", name, h)
+			}
+			fmt.Fprintf(w, "# TYPE %s counter\n", name)
+			series := make([]string, 0, len(counters[name]))
+			for labels := range counters[name] {
+				series = append(series, labels)
+			}
+			sort.Strings(series)
+			for _, labels := range series {
+				fmt.Fprintf(w, "%s%s %g\n", name, labels, counters[name][labels])
+			}
+		}
+	})
+}
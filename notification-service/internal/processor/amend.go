@@ -0,0 +1,245 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"notification-service/internal/logger"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+// undelivered are the notification states an update or cancel may still
+// change: created but not sent yet, waiting in the retry queue, or an
+// escalation step not yet due.
+var undelivered = []string{"pending", "failed", "scheduled"}
+
+// handedOver are the notification states in which the provider already has
+// the alert, so its recipient may have seen it and should get the all-clear.
+// Unlike storage.HandedOver it leaves out "failed": such a notification waits
+// in the retry queue and is cancelled like any other undelivered one.
+var handedOver = storage.Delivered
+
+// applyAmendment applies an update or cancel to every referenced event right
+// away, on the request path, so a withdrawn warning cannot be sent by a retry
+// that becomes due while the amendment would otherwise sit in the queue. The
+// amendment is stored first, kept for the audit trail, so the same ID can
+// only be applied once; one that failed may be submitted again.
+func applyAmendment(ctx context.Context, amendment models.Event) error {
+	if err := createAmendment(ctx, amendment); err != nil {
+		return fmt.Errorf("save %s %s: %w", amendment.MsgType, amendment.ID, err)
+	}
+	for _, ref := range amendment.References {
+		var err error
+		if amendment.MsgType == "cancel" {
+			err = cancelEvent(ctx, ref, amendment)
+		} else {
+			err = updateEvent(ctx, ref, amendment)
+		}
+		if err != nil {
+			// free the ID so the sender can retry it
+			_ = events.UpdateEventStatus(ctx, amendment.ID, "rejected")
+			return fmt.Errorf("%s %s: %w", amendment.MsgType, ref, err)
+		}
+	}
+
+	if err := events.UpdateEventStatus(ctx, amendment.ID, "applied"); err != nil {
+		return fmt.Errorf("save %s %s: %w", amendment.MsgType, amendment.ID, err)
+	}
+	logger.Info(fmt.Sprintf("Applied %s %s to %v", amendment.MsgType, amendment.ID, amendment.References))
+	return nil
+}
+
+// createAmendment claims the ID of an update or cancel. A taken ID is
+// refused with storage.ErrExists unless the earlier attempt was rejected.
+func createAmendment(ctx context.Context, amendment models.Event) error {
+	record := models.EventRecord{
+		Event:      amendment,
+		Status:     "applying",
+		AcceptedAt: time.Now(),
+	}
+	err := events.CreateEvent(ctx, record)
+	if !errors.Is(err, storage.ErrExists) {
+		return err
+	}
+	if existing, getErr := events.GetEvent(ctx, amendment.ID); getErr != nil || existing.Status != "rejected" {
+		return err
+	}
+	return events.SaveEvent(ctx, record)
+}
+
+// cancelEvent marks the event cancelled, pulls its undelivered notifications
+// out of the retry queue and sends an all-clear where the alert was already
+// handed over: always to webhook endpoints, and to people when the cancel
+// asks for it.
+func cancelEvent(ctx context.Context, eventID string, cancel models.Event) error {
+	record, err := events.GetEvent(ctx, eventID)
+	if err != nil {
+		return err
+	}
+	if err := events.UpdateEventStatus(ctx, eventID, "cancelled"); err != nil {
+		return err
+	}
+
+	// the channels each recipient was handed the alert on
+	notified := map[string][]string{}
+	note := func(n models.Notification) {
+		if key := n.RecipientKey(); !slices.Contains(notified[key], n.Channel) {
+			notified[key] = append(notified[key], n.Channel)
+		}
+	}
+	err = forEachNotification(ctx, eventID, func(n models.Notification) error {
+		if slices.Contains(handedOver, n.Status) {
+			note(n)
+			return nil
+		}
+		if !slices.Contains(undelivered, n.Status) {
+			return nil
+		}
+		if err := disp.Store().RemoveFromRetryQueue(ctx, n.ID); err != nil {
+			return err
+		}
+		ok, err := disp.Store().TransitionNotificationStatus(ctx, n.ID, undelivered, "cancelled", "cancelled by "+cancel.ID)
+		if err != nil || ok {
+			return err
+		}
+		// handed over since it was listed
+		if current, err := disp.Store().GetNotification(ctx, n.ID); err == nil && slices.Contains(handedOver, current.Status) {
+			note(*current)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// partner systems may still be showing or relaying the alert
+	if !cancel.SendAllClear {
+		for r, channels := range notified {
+			if slices.Contains(channels, "webhook") {
+				notified[r] = []string{"webhook"}
+			} else {
+				delete(notified, r)
+			}
+		}
+	}
+	if len(notified) == 0 {
+		return nil
+	}
+	// taken from the notifications, so recipients a stage resolved from the
+	// target area and webhook endpoints are included too
+	allClear := allClearEvent(eventID, record.Event, cancel, notified)
+	if err := SubmitEvent(ctx, &allClear); err != nil && !errors.Is(err, storage.ErrExists) {
+		return err
+	}
+	return nil
+}
+
+// updateEvent supersedes the content of the event and re-renders its
+// undelivered notifications; recipients who already got the alert are left
+// alone.
+func updateEvent(ctx context.Context, eventID string, update models.Event) error {
+	record, err := events.GetEvent(ctx, eventID)
+	if err != nil {
+		return err
+	}
+	if record.Status == "cancelled" {
+		return fmt.Errorf("event %s is cancelled", eventID)
+	}
+
+	ev := record.Event
+	if update.Title != "" {
+		ev.Title = update.Title
+	}
+	// new text replaces the translations too, so stale ones are never sent
+	if update.Message != "" || len(update.Messages) > 0 {
+		if update.Message != "" {
+			ev.Message = update.Message
+		}
+		ev.Messages = update.Messages
+		if update.FallbackLanguage != "" {
+			ev.FallbackLanguage = update.FallbackLanguage
+		}
+	}
+	if update.Severity != "" {
+		ev.Severity = update.Severity
+	}
+	if update.Headline != "" {
+		ev.Headline = update.Headline
+	}
+	if update.Description != "" {
+		ev.Description = update.Description
+	}
+	if update.Instruction != "" {
+		ev.Instruction = update.Instruction
+	}
+	if update.ExpiresAt != nil {
+		ev.ExpiresAt = update.ExpiresAt
+	}
+	// keeps the stored status, so a cancel landing meanwhile stands
+	if err := events.UpdateEventContent(ctx, ev); err != nil {
+		return err
+	}
+
+	return forEachNotification(ctx, eventID, func(n models.Notification) error {
+		if !slices.Contains(undelivered, n.Status) {
+			return nil
+		}
+		return disp.Store().UpdateNotificationContent(ctx, disp.Rerender(ctx, ev, n))
+	})
+}
+
+// allClearEvent builds the follow-up sent when a cancel asks for one. Its ID
+// names the cancelled event, as one cancel may withdraw several. It goes to
+// each recipient only on the channels in notified, the ones the alert
+// reached them on.
+func allClearEvent(eventID string, original, cancel models.Event, notified map[string][]string) models.Event {
+	msg := cancel.Message
+	if msg == "" {
+		msg = fmt.Sprintf("All clear: the earlier %q alert has been withdrawn. No further action is needed.", original.Title)
+	}
+	allClear := models.Event{
+		ID:                cancel.ID + "-allclear-" + eventID,
+		Type:              original.Type,
+		Title:             "All clear: " + original.Title,
+		Message:           msg,
+		Severity:          "minor",
+		RecipientChannels: map[string][]string{},
+		References:        []string{eventID},
+		AllClear:          true,
+	}
+	for _, r := range slices.Sorted(maps.Keys(notified)) {
+		allClear.Recipients = append(allClear.Recipients, r)
+		allClear.RecipientChannels[r] = slices.Sorted(slices.Values(notified[r]))
+		for _, ch := range notified[r] {
+			if !slices.Contains(allClear.Channels, ch) {
+				allClear.Channels = append(allClear.Channels, ch)
+			}
+		}
+	}
+	slices.Sort(allClear.Channels)
+	return allClear
+}
+
+// forEachNotification walks every notification of an event page by page.
+func forEachNotification(ctx context.Context, eventID string, fn func(models.Notification) error) error {
+	const pageSize = 500
+	for offset := 0; ; offset += pageSize {
+		page, total, err := disp.Store().ListNotificationsByEvent(ctx, eventID, offset, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, n := range page {
+			if err := fn(n); err != nil {
+				return err
+			}
+		}
+		if len(page) == 0 || offset+pageSize >= total {
+			return nil
+		}
+	}
+}
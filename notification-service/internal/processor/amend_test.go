@@ -0,0 +1,297 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"notification-service/internal/resolver"
+	"notification-service/internal/storage"
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+)
+
+// fakeHandler answers every send with a copy of result.
+type fakeHandler struct{ result models.DispatchResult }
+
+func (f fakeHandler) Send(ctx context.Context, n models.Notification) models.DispatchResult {
+	res := f.result
+	res.NotificationID, res.Timestamp = n.ID, time.Now()
+	return res
+}
+
+// dispatchNow submits the event and dispatches it as an event consumer would.
+func dispatchNow(t *testing.T, mem *memorystore.MemoryStore, event *models.Event) []models.Notification {
+	t.Helper()
+	ctx := context.Background()
+	if err := SubmitEvent(ctx, event); err != nil {
+		t.Fatalf("SubmitEvent: %v", err)
+	}
+	queued, err := mem.ReadEvents(ctx, "test", 1, time.Second)
+	if err != nil || len(queued) != 1 {
+		t.Fatalf("ReadEvents: %v, %v", queued, err)
+	}
+	handleQueuedEvent(ctx, mem, "test", queued[0], 0)
+	notifs, _, err := mem.ListNotificationsByEvent(ctx, event.ID, 0, 100)
+	if err != nil {
+		t.Fatalf("ListNotificationsByEvent: %v", err)
+	}
+	return notifs
+}
+
+func TestUpdateRerendersUndeliveredNotifications(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	Init(mem, mem, mem)
+	failing := fakeHandler{models.DispatchResult{Error: "down", ErrorClass: models.ErrorTransient}}
+	disp.Register("sms", failing)
+	disp.Register("push", failing)
+
+	ev := models.Event{Type: "flood", Title: "Flood watch", Message: "River rising",
+		Channels: []string{"sms", "push"}, Recipients: []string{"+911234567890"}}
+	dispatchNow(t, mem, &ev)
+
+	update := models.Event{MsgType: "update", References: []string{ev.ID},
+		Title: "Flood warning", Message: "வெள்ளம்: உயரமான இடத்துக்குச் செல்லுங்கள்"}
+	if err := SubmitEvent(ctx, &update); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	notifs, _, _ := mem.ListNotificationsByEvent(ctx, ev.ID, 0, 100)
+	for _, n := range notifs {
+		if n.Message != update.Message {
+			t.Errorf("%s message = %q, want the updated text", n.Channel, n.Message)
+		}
+		switch n.Channel {
+		case "push":
+			if n.Subject != "Flood warning" {
+				t.Errorf("push subject = %q, want the updated title", n.Subject)
+			}
+		case "sms":
+			if n.SMSEncoding != "UCS-2" || n.SMSSegments != 1 {
+				t.Errorf("sms encoding/segments = %s/%d, want UCS-2/1", n.SMSEncoding, n.SMSSegments)
+			}
+		}
+	}
+}
+
+func TestCancelSendsOneAllClearPerReference(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	Init(mem, mem, mem)
+	// handed to the provider, outcome still open
+	disp.Register("sms", fakeHandler{models.DispatchResult{Success: true, Pending: true}})
+
+	first := models.Event{Type: "flood", Title: "North", Message: "m", Channels: []string{"sms"}, Recipients: []string{"a"}}
+	second := models.Event{Type: "flood", Title: "South", Message: "m", Channels: []string{"sms"}, Recipients: []string{"b"}}
+	for _, ev := range []*models.Event{&first, &second} {
+		if notifs := dispatchNow(t, mem, ev); notifs[0].Status != "queued" {
+			t.Fatalf("status = %s, want queued", notifs[0].Status)
+		}
+	}
+
+	cancel := models.Event{MsgType: "cancel", References: []string{first.ID, second.ID}, SendAllClear: true}
+	if err := SubmitEvent(ctx, &cancel); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	queued, err := mem.ReadEvents(ctx, "test", 10, time.Second)
+	if err != nil {
+		t.Fatalf("ReadEvents: %v", err)
+	}
+	var ids []string
+	for _, qe := range queued {
+		ids = append(ids, qe.Event.ID)
+	}
+	slices.Sort(ids)
+	want := []string{cancel.ID + "-allclear-" + first.ID, cancel.ID + "-allclear-" + second.ID}
+	slices.Sort(want)
+	if !slices.Equal(ids, want) {
+		t.Errorf("all-clears = %v, want %v", ids, want)
+	}
+}
+
+// recordingHandler hands every send over and records where it went.
+type recordingHandler struct {
+	mu   *sync.Mutex
+	sent *[]string
+}
+
+func (h recordingHandler) Send(ctx context.Context, n models.Notification) models.DispatchResult {
+	h.mu.Lock()
+	*h.sent = append(*h.sent, n.Channel+" "+n.Recipient)
+	h.mu.Unlock()
+	return models.DispatchResult{NotificationID: n.ID, Success: true, Timestamp: time.Now()}
+}
+
+func TestAllClearGoesOnlyWhereTheAlertWent(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	Init(mem, mem, mem)
+	saved := stages
+	stages = nil
+	t.Cleanup(func() { stages = saved })
+	Use(resolver.NewWebhookFanout(mem))
+	if err := mem.SaveWebhook(ctx, models.WebhookEndpoint{ID: "wh-1", URL: "https://partner.example/hook"}); err != nil {
+		t.Fatalf("SaveWebhook: %v", err)
+	}
+
+	var mu sync.Mutex
+	var sent []string
+	for _, ch := range []string{"sms", "email", "webhook"} {
+		disp.Register(ch, recordingHandler{&mu, &sent})
+	}
+
+	ev := models.Event{Type: "flood", Title: "Flood warning", Message: "River rising", Severity: "severe",
+		Channels: []string{"sms", "email", "webhook"}, Recipients: []string{"+911", "b@example.com"},
+		RecipientChannels: map[string][]string{"+911": {"sms"}, "b@example.com": {"email"}}}
+	dispatchNow(t, mem, &ev)
+
+	cancel := models.Event{MsgType: "cancel", References: []string{ev.ID}, SendAllClear: true}
+	if err := SubmitEvent(ctx, &cancel); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	sent = nil
+	queued, err := mem.ReadEvents(ctx, "test", 1, time.Second)
+	if err != nil || len(queued) != 1 {
+		t.Fatalf("ReadEvents: %v, %v", queued, err)
+	}
+	handleQueuedEvent(ctx, mem, "test", queued[0], 0)
+
+	slices.Sort(sent)
+	want := []string{"email b@example.com", "sms +911", "webhook wh-1"}
+	if !slices.Equal(sent, want) {
+		t.Errorf("all-clear sent to %v, want %v", sent, want)
+	}
+}
+
+func TestCancelAlwaysTellsWebhooks(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	Init(mem, mem, mem)
+	saved := stages
+	stages = nil
+	t.Cleanup(func() { stages = saved })
+	Use(resolver.NewWebhookFanout(mem))
+	if err := mem.SaveWebhook(ctx, models.WebhookEndpoint{ID: "wh-1", URL: "https://partner.example/hook"}); err != nil {
+		t.Fatalf("SaveWebhook: %v", err)
+	}
+
+	var mu sync.Mutex
+	var sent []string
+	for _, ch := range []string{"sms", "webhook"} {
+		disp.Register(ch, recordingHandler{&mu, &sent})
+	}
+
+	ev := models.Event{Type: "flood", Title: "Flood warning", Message: "River rising",
+		Channels: []string{"sms", "webhook"}, Recipients: []string{"+911"}}
+	dispatchNow(t, mem, &ev)
+
+	// no all-clear asked for, so the person is left alone
+	cancel := models.Event{MsgType: "cancel", References: []string{ev.ID}}
+	if err := SubmitEvent(ctx, &cancel); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	sent = nil
+	queued, err := mem.ReadEvents(ctx, "test", 1, time.Second)
+	if err != nil || len(queued) != 1 {
+		t.Fatalf("ReadEvents: %v, %v", queued, err)
+	}
+	handleQueuedEvent(ctx, mem, "test", queued[0], 0)
+
+	if want := []string{"webhook wh-1"}; !slices.Equal(sent, want) {
+		t.Errorf("cancel sent to %v, want %v", sent, want)
+	}
+	if record, err := mem.GetEvent(ctx, queued[0].Event.ID); err != nil || !record.Event.AllClear {
+		t.Errorf("follow-up = %+v, %v, want an all-clear", record, err)
+	}
+}
+
+// cancellingStage cancels the event while it is being processed, as a
+// cancel arriving on the request path would.
+type cancellingStage struct{}
+
+func (cancellingStage) Apply(ctx context.Context, event *models.Event) error {
+	return cancelEvent(ctx, event.ID, models.Event{ID: "cancel-1"})
+}
+
+func TestCancelDuringProcessingIsNotOverwritten(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	Init(mem, mem, mem)
+	saved := stages
+	stages = nil
+	t.Cleanup(func() { stages = saved })
+	Use(cancellingStage{})
+
+	var mu sync.Mutex
+	var sent []string
+	disp.Register("sms", recordingHandler{&mu, &sent})
+
+	ev := models.Event{Type: "flood", Title: "Flood warning", Message: "River rising",
+		Channels: []string{"sms"}, Recipients: []string{"+911"}}
+	dispatchNow(t, mem, &ev)
+
+	if len(sent) != 0 {
+		t.Errorf("cancelled alert sent to %v", sent)
+	}
+	if record, err := mem.GetEvent(ctx, ev.ID); err != nil || record.Status != "cancelled" {
+		t.Errorf("record = %+v, %v, want cancelled", record, err)
+	}
+
+}
+
+func TestAmendmentIsAppliedOnce(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	Init(mem, mem, mem)
+	disp.Register("sms", fakeHandler{models.DispatchResult{Success: true}})
+
+	// refers to an event not accepted yet, so the first attempt fails
+	update := models.Event{ID: "upd-1", MsgType: "update", References: []string{"evt-1"}, Title: "Flood warning"}
+	first := update
+	if err := SubmitEvent(ctx, &first); err == nil {
+		t.Fatal("update of an unknown event accepted")
+	}
+	if record, err := mem.GetEvent(ctx, "upd-1"); err != nil || record.Status != "rejected" {
+		t.Fatalf("record = %+v, %v, want rejected", record, err)
+	}
+
+	ev := models.Event{ID: "evt-1", Type: "flood", Title: "Flood watch", Message: "River rising",
+		Channels: []string{"sms"}, Recipients: []string{"+911234567890"}}
+	dispatchNow(t, mem, &ev)
+
+	// the sender retries, several times over
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			retry := update
+			errs[i] = SubmitEvent(ctx, &retry)
+		}()
+	}
+	wg.Wait()
+	applied := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			applied++
+		case !errors.Is(err, storage.ErrExists):
+			t.Errorf("SubmitEvent(retry) = %v, want nil or ErrExists", err)
+		}
+	}
+	if applied != 1 {
+		t.Errorf("update applied %d times, want once", applied)
+	}
+	if record, err := mem.GetEvent(ctx, "upd-1"); err != nil || record.Status != "applied" {
+		t.Errorf("record = %+v, %v, want applied", record, err)
+	}
+	if record, _ := mem.GetEvent(ctx, "evt-1"); record.Event.Title != "Flood warning" {
+		t.Errorf("event title = %q, want the update applied", record.Event.Title)
+	}
+}
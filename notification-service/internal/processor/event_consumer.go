@@ -0,0 +1,133 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"notification-service/internal/logger"
+	"notification-service/internal/storage"
+)
+
+// StartEventConsumers starts workers goroutines draining the intake queue, plus
+// one reclaimer that takes over entries left unacknowledged for claimIdle by a
+// consumer that crashed or was restarted.
+func StartEventConsumers(ctx context.Context, q storage.EventQueue, workers int, claimIdle time.Duration) {
+	if workers <= 0 {
+		workers = 1
+	}
+	base := consumerBaseName()
+
+	for i := 0; i < workers; i++ {
+		consumer := fmt.Sprintf("%s-%d", base, i)
+		go func() {
+			for {
+				if ctx.Err() != nil {
+					logger.Info("Event consumer " + consumer + " exiting")
+					return
+				}
+				events, err := q.ReadEvents(ctx, consumer, 1, 5*time.Second)
+				if err != nil {
+					if ctx.Err() == nil {
+						logger.Error(err)
+						time.Sleep(time.Second)
+					}
+					continue
+				}
+				for _, qe := range events {
+					handleQueuedEvent(ctx, q, consumer, qe, claimIdle/3)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		consumer := base + "-reclaim"
+		ticker := time.NewTicker(claimIdle / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Info("Event reclaimer exiting")
+				return
+			case <-ticker.C:
+				events, err := q.ClaimStaleEvents(ctx, consumer, claimIdle, 10)
+				if err != nil {
+					logger.Error(err)
+					continue
+				}
+				for _, qe := range events {
+					logger.Info(fmt.Sprintf("Reclaimed stale event %s (msg %s)", qe.Event.ID, qe.MessageID))
+					handleQueuedEvent(ctx, q, consumer, qe, claimIdle/3)
+				}
+			}
+		}
+	}()
+}
+
+// handleQueuedEvent dispatches one queued event and acknowledges it. Events
+// that fail validation are acknowledged too, since retrying cannot fix them;
+// other failures leave the entry pending so the reclaimer tries again later.
+// If the process dies before the ack the entry is redelivered (at-least-once).
+// While the event is processed the entry is touched every touchEvery, so a
+// dispatch that outlasts the claim idle time is not taken over and sent twice.
+func handleQueuedEvent(ctx context.Context, q storage.EventQueue, consumer string, qe storage.QueuedEvent, touchEvery time.Duration) {
+	stop := keepClaimed(ctx, q, consumer, qe.MessageID, touchEvery)
+	err := ProcessEvent(ctx, qe.Event)
+	stop()
+	if err != nil {
+		logger.Error(fmt.Errorf("process event %s: %w", qe.Event.ID, err))
+		if !errors.Is(err, errInvalidEvent) {
+			return
+		}
+	}
+	if ctx.Err() != nil {
+		// shutting down mid-dispatch: leave the entry pending for reclaim
+		return
+	}
+	if err := q.AckEvent(ctx, qe.MessageID); err != nil {
+		logger.Error(err)
+	}
+}
+
+// keepClaimed touches a queue entry every interval until the returned stop
+// function is called. A non-positive interval disables it.
+func keepClaimed(ctx context.Context, q storage.EventQueue, consumer, messageID string, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := q.TouchEvent(ctx, consumer, messageID); err != nil {
+					logger.Error(err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func consumerBaseName() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "consumer"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -0,0 +1,59 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+)
+
+// blockingHandler holds every send until release is closed.
+type blockingHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h blockingHandler) Send(ctx context.Context, n models.Notification) models.DispatchResult {
+	close(h.started)
+	<-h.release
+	return models.DispatchResult{NotificationID: n.ID, Success: true, Timestamp: time.Now()}
+}
+
+func TestLongDispatchIsNotReclaimed(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	Init(mem, mem, mem)
+	h := blockingHandler{started: make(chan struct{}), release: make(chan struct{})}
+	disp.Register("sms", h)
+
+	ev := models.Event{Type: "flood", Title: "Flood warning", Message: "River rising",
+		Channels: []string{"sms"}, Recipients: []string{"+911234567890"}}
+	if err := SubmitEvent(ctx, &ev); err != nil {
+		t.Fatalf("SubmitEvent: %v", err)
+	}
+	queued, err := mem.ReadEvents(ctx, "worker", 1, time.Second)
+	if err != nil || len(queued) != 1 {
+		t.Fatalf("ReadEvents: %v, %v", queued, err)
+	}
+
+	const claimIdle = 50 * time.Millisecond
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handleQueuedEvent(ctx, mem, "worker", queued[0], claimIdle/3)
+	}()
+	<-h.started
+	time.Sleep(3 * claimIdle)
+
+	stolen, err := mem.ClaimStaleEvents(ctx, "reclaim", claimIdle, 10)
+	close(h.release)
+	<-done
+	if err != nil || len(stolen) != 0 {
+		t.Errorf("reclaimer took %v (%v) from a consumer still dispatching", stolen, err)
+	}
+	if left, _ := mem.ClaimStaleEvents(ctx, "reclaim", 0, 10); len(left) != 0 {
+		t.Errorf("entry still pending after dispatch: %v", left)
+	}
+}
@@ -2,43 +2,220 @@ package processor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"notification-service/internal/dispatcher"
+	"notification-service/internal/geo"
+	"notification-service/internal/ids"
 	"notification-service/internal/logger"
 	"notification-service/internal/storage"
 	"notification-service/pkg/models"
 )
 
+// ErrExpired is returned by SubmitEvent for an alert whose expiry has passed.
+var ErrExpired = errors.New("event already expired")
+
+// errInvalidEvent marks queued events that can never be processed, as
+// opposed to transient failures worth another attempt.
+var errInvalidEvent = errors.New("invalid event")
+
 var (
-	disp *dispatcher.Dispatcher
+	disp   *dispatcher.Dispatcher
+	queue  storage.EventQueue
+	events storage.EventStore
+	stages []Stage
 )
 
+// Stage transforms an accepted event between the queue and the dispatcher,
+// e.g. resolving recipients from a target area. Stages run in the order they
+// were registered with Use.
+type Stage interface {
+	Apply(ctx context.Context, event *models.Event) error
+}
+
+// Use appends a stage to the processing pipeline; call it before starting
+// the event consumers.
+func Use(stage Stage) {
+	stages = append(stages, stage)
+}
+
 func Disp() *dispatcher.Dispatcher {
 	return disp
 }
 
-func Init(store storage.NotificationStore) {
+func Init(store storage.NotificationStore, q storage.EventQueue, ev storage.EventStore) {
 	disp = dispatcher.NewDispatcher(store)
+	disp.SetEvents(ev)
+	queue = q
+	events = ev
 }
 
-func ProcessEvent(event models.Event) error {
-	logger.Info(fmt.Sprintf("Processing Event: %s (%s)", event.Title, event.Type))
+// ValidateEvent checks the fields required before an event can be accepted.
+// Updates and cancels only need the IDs of the events they amend.
+func ValidateEvent(event models.Event) error {
+	switch event.MsgType {
+	case "", "alert":
+	case "update", "cancel":
+		if len(event.References) == 0 {
+			return fmt.Errorf("%s requires references to earlier event ids", event.MsgType)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown msg_type %q (want alert, update or cancel)", event.MsgType)
+	}
 
-	if event.ID == "" || event.Type == "" {
-		return fmt.Errorf("missing required fields: id or type")
+	if event.Type == "" {
+		return fmt.Errorf("missing required field: type")
 	}
-	if len(event.Recipients) == 0 {
-		return fmt.Errorf("no recipients specified")
+	// webhook endpoints are added by the fan-out stage
+	if len(event.Recipients) == 0 && len(event.Areas) == 0 && !slices.Contains(event.Channels, "webhook") {
+		return fmt.Errorf("no recipients, target areas or webhook channel specified")
+	}
+	for _, area := range event.Areas {
+		if _, err := geo.ShapesFromArea(area); err != nil {
+			return fmt.Errorf("area %q: %w", area.Description, err)
+		}
 	}
 	if len(event.Channels) == 0 {
 		return fmt.Errorf("no channels specified")
 	}
+	size := 0
+	for _, a := range event.Attachments {
+		if a.Filename == "" {
+			return fmt.Errorf("attachment without filename")
+		}
+		size += len(a.Data)
+	}
+	if size > maxAttachmentBytes {
+		return fmt.Errorf("attachments total %d bytes, limit is %d", size, maxAttachmentBytes)
+	}
+	return nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// maxAttachmentBytes keeps emails under the 10 MB most mail servers accept
+// once base64 encoding is added.
+const maxAttachmentBytes = 7 << 20
 
-	_ = disp.DispatchEvent(ctx, event)
+// SubmitEvent validates the event, assigns an ID if the caller did not provide
+// one and persists it to the intake queue. Dispatch happens asynchronously in
+// the event consumers; the (possibly generated) ID is written back to event.
+// Updates and cancels are applied immediately instead (see applyAmendment).
+func SubmitEvent(ctx context.Context, event *models.Event) error {
+	if err := ValidateEvent(*event); err != nil {
+		return err
+	}
+	if event.ID == "" {
+		event.ID = ids.New("evt")
+	}
+	if event.MsgType == "update" || event.MsgType == "cancel" {
+		return applyAmendment(ctx, *event)
+	}
+	if event.ExpiresAt != nil && !time.Now().Before(*event.ExpiresAt) {
+		return fmt.Errorf("%w at %s", ErrExpired, event.ExpiresAt.Format(time.RFC3339))
+	}
+	record := models.EventRecord{
+		Event:      *event,
+		Status:     "queued",
+		AcceptedAt: time.Now(),
+	}
+	accepted, err := createEvent(ctx, record)
+	if err != nil {
+		return fmt.Errorf("save event %s: %w", event.ID, err)
+	}
+	if err := queue.EnqueueEvent(ctx, accepted); err != nil {
+		// free the ID so the sender can retry it
+		_ = events.UpdateEventStatus(ctx, event.ID, "rejected")
+		return fmt.Errorf("enqueue event %s: %w", event.ID, err)
+	}
+	logger.Info(fmt.Sprintf("Accepted Event: %s (%s) id=%s", event.Title, event.Type, event.ID))
+	return nil
+}
+
+// createEvent stores a newly accepted event and returns the event to
+// enqueue. An ID that is already taken is refused with storage.ErrExists, so
+// a replayed or colliding submission can never replace an alert that was
+// already dispatched. An event whose enqueue failed may be submitted again.
+// So may one still "queued": a crash between storing and enqueuing it would
+// otherwise lose it, so its stored copy is enqueued again. Should the first
+// copy have reached the queue after all, ProcessEvent skips whichever copy
+// comes second once the event is dispatched.
+func createEvent(ctx context.Context, record models.EventRecord) (models.Event, error) {
+	err := events.CreateEvent(ctx, record)
+	if !errors.Is(err, storage.ErrExists) {
+		return record.Event, err
+	}
+	existing, getErr := events.GetEvent(ctx, record.Event.ID)
+	switch {
+	case getErr != nil:
+		return models.Event{}, err
+	case existing.Status == "queued":
+		return existing.Event, nil
+	case existing.Status != "rejected":
+		return models.Event{}, err
+	}
+	return record.Event, events.SaveEvent(ctx, record)
+}
+
+// ProcessEvent dispatches an accepted event to all its recipients and channels.
+// It runs in the event consumers, never on the HTTP request path. The stored
+// record wins over the queued copy, so updates and cancels applied while the
+// event waited in the queue are honoured. A redelivered event is not sent
+// twice: one already dispatched is skipped and an interrupted one resumed.
+func ProcessEvent(ctx context.Context, event models.Event) error {
+	logger.Info(fmt.Sprintf("Processing Event: %s (%s)", event.Title, event.Type))
+
+	if event.ID == "" {
+		return fmt.Errorf("%w: missing required field: id", errInvalidEvent)
+	}
+	resume := false
+	if record, err := events.GetEvent(ctx, event.ID); err == nil {
+		switch record.Status {
+		case "cancelled":
+			logger.Info("Skipping cancelled event " + event.ID)
+			return nil
+		case "dispatched":
+			// redelivered after a dispatch that finished but was not acked
+			logger.Info("Skipping already dispatched event " + event.ID)
+			return nil
+		case "processing":
+			resume = true
+		}
+		event = record.Event
+	}
+	if err := ValidateEvent(event); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidEvent, err)
+	}
+
+	// a cancel may have landed since the record was read; don't overwrite it
+	ok, err := events.TransitionEventStatus(ctx, event.ID, []string{"queued", "processing"}, "processing")
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+	case err != nil:
+		return fmt.Errorf("start event %s: %w", event.ID, err)
+	case !ok:
+		logger.Info("Skipping event " + event.ID + ": cancelled or dispatched meanwhile")
+		return nil
+	}
+	for _, stage := range stages {
+		if err := stage.Apply(ctx, &event); err != nil {
+			return fmt.Errorf("stage %T: %w", stage, err)
+		}
+	}
+	if len(event.Recipients) == 0 {
+		logger.Info("No recipients resolved for event " + event.ID)
+	}
+	if resume {
+		logger.Info("Resuming interrupted dispatch of event " + event.ID)
+		if _, err := disp.ResumeEvent(ctx, event); err != nil {
+			return err
+		}
+	} else {
+		_ = disp.DispatchEvent(ctx, event)
+	}
+	// a cancel may have landed mid-dispatch; don't overwrite it
+	_, _ = events.TransitionEventStatus(ctx, event.ID, []string{"processing"}, "dispatched")
 	return nil
 }
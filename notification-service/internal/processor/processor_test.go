@@ -0,0 +1,171 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"notification-service/internal/storage"
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+)
+
+// downQueue refuses every enqueue while down is set.
+type downQueue struct {
+	*memorystore.MemoryStore
+	down bool
+}
+
+func (q *downQueue) EnqueueEvent(ctx context.Context, event models.Event) error {
+	if q.down {
+		return errors.New("queue unavailable")
+	}
+	return q.MemoryStore.EnqueueEvent(ctx, event)
+}
+
+func TestSubmitEventRejectsTakenID(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	Init(mem, mem, mem)
+	disp.Register("sms", fakeHandler{models.DispatchResult{Success: true}})
+
+	ev := models.Event{ID: "evt-1", Type: "flood", Title: "Flood warning", Message: "River rising",
+		Channels: []string{"sms"}, Recipients: []string{"+911234567890"}}
+	dispatchNow(t, mem, &ev)
+
+	replay := models.Event{ID: "evt-1", Type: "fire", Title: "Forest fire", Message: "Evacuate",
+		Channels: []string{"sms"}, Recipients: []string{"+919876543210"}}
+	if err := SubmitEvent(ctx, &replay); !errors.Is(err, storage.ErrExists) {
+		t.Fatalf("SubmitEvent(taken ID) = %v, want ErrExists", err)
+	}
+	record, err := mem.GetEvent(ctx, "evt-1")
+	if err != nil || record.Event.Title != "Flood warning" || record.Status != "dispatched" {
+		t.Errorf("record = %+v, %v, want the dispatched flood warning kept", record, err)
+	}
+
+	cancel := models.Event{ID: "cancel-1", MsgType: "cancel", References: []string{"evt-1"}}
+	if err := SubmitEvent(ctx, &cancel); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	again := cancel
+	if err := SubmitEvent(ctx, &again); !errors.Is(err, storage.ErrExists) {
+		t.Errorf("SubmitEvent(cancel again) = %v, want ErrExists", err)
+	}
+}
+
+func TestSubmitEventAcceptsRetryAfterEnqueueFailure(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	q := &downQueue{MemoryStore: mem, down: true}
+	Init(mem, q, mem)
+
+	ev := models.Event{ID: "evt-1", Type: "flood", Title: "Flood warning", Message: "River rising",
+		Channels: []string{"sms"}, Recipients: []string{"+911234567890"}}
+	if err := SubmitEvent(ctx, &ev); err == nil || errors.Is(err, storage.ErrExists) {
+		t.Fatalf("SubmitEvent with the queue down = %v, want an enqueue error", err)
+	}
+	q.down = false
+	if err := SubmitEvent(ctx, &ev); err != nil {
+		t.Fatalf("SubmitEvent retry = %v, want it accepted", err)
+	}
+	if record, err := mem.GetEvent(ctx, "evt-1"); err != nil || record.Status != "queued" {
+		t.Errorf("record = %+v, %v, want queued", record, err)
+	}
+}
+
+func TestSubmitEventRecoversStoredButNotEnqueued(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	Init(mem, mem, mem)
+	sms := &countingHandler{}
+	disp.Register("sms", sms)
+
+	// the process died after storing the event and before enqueuing it
+	ev := models.Event{ID: "evt-1", Type: "flood", Title: "Flood warning", Message: "River rising",
+		Channels: []string{"sms"}, Recipients: []string{"+911111111111"}}
+	if err := mem.CreateEvent(ctx, models.EventRecord{Event: ev, Status: "queued", AcceptedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+
+	// the sender never got a 202 and submits again
+	resubmit := ev
+	if err := SubmitEvent(ctx, &resubmit); err != nil {
+		t.Fatalf("SubmitEvent(still queued) = %v, want it enqueued", err)
+	}
+	queued, err := mem.ReadEvents(ctx, "test", 10, time.Second)
+	if err != nil || len(queued) != 1 || queued[0].Event.ID != "evt-1" {
+		t.Fatalf("ReadEvents = %v, %v, want evt-1 enqueued", queued, err)
+	}
+	handleQueuedEvent(ctx, mem, "test", queued[0], 0)
+	if len(sms.sent) != 1 {
+		t.Errorf("sent %v, want one alert", sms.sent)
+	}
+
+	// once dispatched the ID is taken for good
+	if err := SubmitEvent(ctx, &resubmit); !errors.Is(err, storage.ErrExists) {
+		t.Errorf("SubmitEvent(dispatched) = %v, want ErrExists", err)
+	}
+}
+
+// countingHandler records the notification IDs it is asked to send.
+type countingHandler struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (h *countingHandler) Send(ctx context.Context, n models.Notification) models.DispatchResult {
+	h.mu.Lock()
+	h.sent = append(h.sent, n.ID)
+	h.mu.Unlock()
+	return models.DispatchResult{NotificationID: n.ID, Success: true, Timestamp: time.Now()}
+}
+
+func TestRedeliveredEventIsNotSentTwice(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	Init(mem, mem, mem)
+	sms := &countingHandler{}
+	disp.Register("sms", sms)
+
+	ev := models.Event{ID: "evt-1", Type: "flood", Title: "Flood warning", Message: "River rising",
+		Channels: []string{"sms"}, Recipients: []string{"+911111111111", "+912222222222", "+913333333333"}}
+	if err := SubmitEvent(ctx, &ev); err != nil {
+		t.Fatalf("SubmitEvent: %v", err)
+	}
+	queued, err := mem.ReadEvents(ctx, "test", 1, time.Second)
+	if err != nil || len(queued) != 1 {
+		t.Fatalf("ReadEvents: %v, %v", queued, err)
+	}
+
+	// a consumer died mid-dispatch: one recipient was sent, one was saved but
+	// maybe not sent, the third not reached
+	_ = mem.UpdateEventStatus(ctx, "evt-1", "processing")
+	now := time.Now()
+	_ = mem.SaveNotification(ctx, models.Notification{ID: "notif-sent", EventID: "evt-1", Recipient: "+911111111111",
+		Channel: "sms", Status: "success", Timestamp: now})
+	_ = mem.SaveNotification(ctx, models.Notification{ID: "notif-pending", EventID: "evt-1", Recipient: "+912222222222",
+		Channel: "sms", Status: "pending", Timestamp: now})
+
+	handleQueuedEvent(ctx, mem, "test", queued[0], 0)
+
+	slices.Sort(sms.sent)
+	if len(sms.sent) != 2 || sms.sent[1] != "notif-pending" || sms.sent[0] == "notif-sent" {
+		t.Errorf("sent %v, want the pending notification again and one new one", sms.sent)
+	}
+	notifs, total, _ := mem.ListNotificationsByEvent(ctx, "evt-1", 0, 100)
+	if total != 3 {
+		t.Errorf("event has %d notifications, want one per recipient: %+v", total, notifs)
+	}
+
+	// redelivered once more after the dispatch finished
+	sms.sent = nil
+	if err := ProcessEvent(ctx, ev); err != nil {
+		t.Fatalf("ProcessEvent: %v", err)
+	}
+	if len(sms.sent) != 0 {
+		t.Errorf("dispatched event sent again: %v", sms.sent)
+	}
+}
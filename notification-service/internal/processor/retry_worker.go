@@ -2,14 +2,22 @@ package processor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"notification-service/internal/dispatcher"
 	"notification-service/internal/logger"
 	"notification-service/internal/storage"
-	"notification-service/pkg/models"
 	"time"
 )
 
+// retryLease is how long a claimed retry stays owned by this worker. A send
+// is cut off well before the lease ends so that a reaped lease can only
+// belong to a worker that actually died.
+const (
+	retryLease       = 5 * time.Minute
+	retrySendTimeout = 2 * time.Minute
+)
+
 func StartRetryWorker(ctx context.Context, store storage.NotificationStore, dispatcher *dispatcher.Dispatcher, pollInterval time.Duration) {
 	go func() {
 		ticker := time.NewTicker(pollInterval)
@@ -22,82 +30,85 @@ func StartRetryWorker(ctx context.Context, store storage.NotificationStore, disp
 				return
 			case <-ticker.C:
 				now := time.Now()
-				ids, err := store.GetDueRetries(ctx, now, 100)
+				if n, err := store.ReapExpiredLeases(ctx, now); err != nil {
+					logger.Error(err)
+				} else if n > 0 {
+					logger.Info(fmt.Sprintf("Returned %d expired retry leases to the queue", n))
+				}
+
+				ids, err := store.ClaimDueRetries(ctx, now, retryLease, 100)
 				if err != nil {
 					logger.Error(err)
 					continue
 				}
 				for _, id := range ids {
-					go func(notifID string) {
-						notif, err := store.GetNotification(ctx, notifID)
-						if err != nil {
-							logger.Error(err)
-							_ = store.RemoveFromRetryQueue(ctx, notifID) // remove corrupted entry
-							return
-						}
-
-						// build a temporary Event for single dispatch
-						results := dispatcher.DispatchEvent(ctx, models.Event{
-							ID:         notif.EventID,
-							Recipients: []string{notif.Recipient},
-							Channels:   []string{notif.Channel},
-							Message:    notif.Message,
-						})
-
-						if len(results) == 0 {
-							logger.Info("No dispatch result for retry notification " + notifID)
-							return
-						}
-						res := results[0]
+					go retryNotification(ctx, store, dispatcher, id)
+				}
+			}
+		}
+	}()
+}
 
-						if res.Success {
-							_ = store.UpdateNotificationStatus(ctx, notifID, "success", "")
-							_ = store.RemoveFromRetryQueue(ctx, notifID)
-							logger.Info("Retry success for notification " + notifID)
-							return
-						}
+// retryNotification resends one claimed retry and releases its lease.
+func retryNotification(ctx context.Context, store storage.NotificationStore, dispatcher *dispatcher.Dispatcher, notifID string) {
+	notif, err := store.GetNotification(ctx, notifID)
+	if errors.Is(err, storage.ErrNotFound) {
+		logger.Error(err)
+		_ = store.RemoveFromRetryQueue(ctx, notifID) // nothing left to retry
+		return
+	}
+	if err != nil {
+		// keep the entry; the lease expires and ReapExpiredLeases
+		// hands it back once the store answers again
+		logger.Error(err)
+		return
+	}
 
-						// failure: increment attempts and decide next action
-						newAttempts, err := store.IncrementAttempts(ctx, notifID, res.Error)
-						if err != nil {
-							logger.Error(fmt.Errorf("increment attempts for retry %s: %w", notifID, err))
-							// Do not infinite-loop: schedule a conservative retry
-							nextRetry := time.Now().Add(10 * time.Minute)
-							_ = store.ScheduleRetry(ctx, notifID, nextRetry, res.Error)
-							return
-						}
+	cancelled, expiresAt := eventState(ctx, notif.EventID)
+	if cancelled {
+		_ = store.UpdateNotificationStatus(ctx, notifID, "cancelled", "event cancelled")
+		_ = store.RemoveFromRetryQueue(ctx, notifID)
+		logger.Info("Dropped retry for cancelled notification " + notifID)
+		return
+	}
+	if expiresAt != nil {
+		notif.ExpiresAt = expiresAt
+	}
+	if notif.Expired(time.Now()) {
+		dispatcher.MarkExpired(ctx, *notif)
+		return
+	}
+	// escalation steps and retries stop once a human saw the alert
+	if dispatcher.AckedElsewhere(ctx, *notif) {
+		dispatcher.Skip(ctx, *notif, "recipient acknowledged the alert")
+		return
+	}
 
-						// determine max retries (fallback to 5)
-						maxRetries := notif.MaxRetries
-						if maxRetries == 0 {
-							maxRetries = 5
-						}
+	sendCtx, cancel := context.WithTimeout(ctx, retrySendTimeout)
+	res := dispatcher.Send(sendCtx, *notif)
+	cancel()
 
-						if newAttempts >= maxRetries {
-							_ = store.UpdateNotificationStatus(ctx, notifID, "failed_permanent", res.Error)
-							_ = store.RemoveFromRetryQueue(ctx, notifID)
-							logger.Info("Marked permanent failure for notification " + notifID + " after attempts")
-							return
-						}
+	// releases the lease: either removes the ID or reschedules it
+	dispatcher.RecordResult(ctx, *notif, res)
+}
 
-						// exponential backoff: base * 2^(attempts-1), capped
-						baseSeconds := int64(300)  // 5 minutes
-						maxBackoff := int64(86400) // 24 hours
-						delay := baseSeconds << uint(newAttempts-1)
-						if delay > maxBackoff {
-							delay = maxBackoff
-						}
-						nextRetry := time.Now().Add(time.Duration(delay) * time.Second)
+// EventCancelled reports whether the event has been cancelled, e.g. so a
+// late provider callback does not move its notifications on.
+func EventCancelled(ctx context.Context, eventID string) bool {
+	cancelled, _ := eventState(ctx, eventID)
+	return cancelled
+}
 
-						_ = store.UpdateNotificationStatus(ctx, notifID, "failed", res.Error)
-						if err := store.ScheduleRetry(ctx, notifID, nextRetry, res.Error); err != nil {
-							logger.Error(fmt.Errorf("schedule retry failed for %s: %w", notifID, err))
-						} else {
-							logger.Info(fmt.Sprintf("Retry scheduled for %s at %s (attempt %d/%d)", notifID, nextRetry.Format(time.RFC3339), newAttempts, maxRetries))
-						}
-					}(id)
-				}
-			}
-		}
-	}()
+// eventState reports whether the notification's event has been cancelled and
+// its current expiry, which an update may have moved. It errs on the side of
+// sending if the event record cannot be read.
+func eventState(ctx context.Context, eventID string) (cancelled bool, expiresAt *time.Time) {
+	if events == nil || eventID == "" {
+		return false, nil
+	}
+	record, err := events.GetEvent(ctx, eventID)
+	if err != nil {
+		return false, nil
+	}
+	return record.Status == "cancelled", record.Event.ExpiresAt
 }
@@ -0,0 +1,165 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"notification-service/internal/ack"
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+)
+
+// unreachableStore fails every notification read as a store outage would.
+type unreachableStore struct{ *memorystore.MemoryStore }
+
+func (unreachableStore) GetNotification(ctx context.Context, id string) (*models.Notification, error) {
+	return nil, errors.New("dial tcp 10.0.0.5:6379: i/o timeout")
+}
+
+func TestRetryKeepsLeaseWhenStoreIsUnreachable(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	Init(mem, mem, mem)
+	now := time.Now()
+	if err := mem.SaveNotification(ctx, models.Notification{ID: "notif-1", Channel: "sms", Recipient: "a", Status: "failed"}); err != nil {
+		t.Fatalf("SaveNotification: %v", err)
+	}
+	for _, id := range []string{"notif-1", "notif-gone"} {
+		if err := mem.ScheduleRetry(ctx, id, now, "down"); err != nil {
+			t.Fatalf("ScheduleRetry: %v", err)
+		}
+	}
+	if ids, err := mem.ClaimDueRetries(ctx, now, retryLease, 10); err != nil || len(ids) != 2 {
+		t.Fatalf("ClaimDueRetries = %v, %v", ids, err)
+	}
+
+	retryNotification(ctx, unreachableStore{mem}, disp, "notif-1")
+	retryNotification(ctx, mem, disp, "notif-gone")
+
+	// only the retry whose notification was deleted leaves the queue
+	if n, err := mem.ReapExpiredLeases(ctx, now.Add(retryLease)); err != nil || n != 1 {
+		t.Fatalf("ReapExpiredLeases = %d, %v, want the unreadable retry back", n, err)
+	}
+	ids, _ := mem.ClaimDueRetries(ctx, now.Add(retryLease), retryLease, 10)
+	if len(ids) != 1 || ids[0] != "notif-1" {
+		t.Errorf("due retries = %v, want [notif-1]", ids)
+	}
+}
+
+func TestEscalationStopsOnceAcknowledged(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	Init(mem, mem, mem)
+	disp.SetAcknowledgements(mem, nil, ack.Policies{"extreme": {{Channel: "push"}, {Channel: "sms", After: 5 * time.Minute}}})
+	var mu sync.Mutex
+	var sent []string
+	for _, ch := range []string{"push", "sms"} {
+		disp.Register(ch, recordingHandler{&mu, &sent})
+	}
+
+	ev := models.Event{Type: "cyclone", Title: "Cyclone warning", Message: "Move inland", Severity: "extreme",
+		Channels: []string{"push", "sms"}, Recipients: []string{"+911", "+912"}}
+	notifs := dispatchNow(t, mem, &ev)
+
+	pushTo, smsTo := map[string]models.Notification{}, map[string]models.Notification{}
+	for _, n := range notifs {
+		if n.Channel == "push" {
+			pushTo[n.Recipient] = n
+		} else {
+			smsTo[n.Recipient] = n
+		}
+	}
+	if len(pushTo) != 2 || len(smsTo) != 2 {
+		t.Fatalf("got %d push and %d sms notifications, want 2 of each", len(pushTo), len(smsTo))
+	}
+	for _, n := range smsTo {
+		if n.Status != "scheduled" {
+			t.Errorf("sms to %s is %s before its deadline, want scheduled", n.Recipient, n.Status)
+		}
+	}
+	slices.Sort(sent)
+	if !slices.Equal(sent, []string{"push +911", "push +912"}) {
+		t.Fatalf("sent right away: %v, want push only", sent)
+	}
+
+	// the SMS fallback cannot be acknowledged before it goes out
+	if disp.Acknowledge(ctx, smsTo["+911"], "link") {
+		t.Error("acknowledged an SMS that was only scheduled")
+	}
+	if !disp.Acknowledge(ctx, pushTo["+911"], "push action") {
+		t.Fatal("push to +911 was not acknowledged")
+	}
+
+	if due, _ := mem.ClaimDueRetries(ctx, time.Now().Add(4*time.Minute), retryLease, 10); len(due) != 0 {
+		t.Fatalf("escalation due before its deadline: %v", due)
+	}
+	due, err := mem.ClaimDueRetries(ctx, time.Now().Add(6*time.Minute), retryLease, 10)
+	if err != nil || len(due) != 2 {
+		t.Fatalf("ClaimDueRetries after the deadline = %v, %v, want both SMS", due, err)
+	}
+	mu.Lock()
+	sent = nil
+	mu.Unlock()
+	for _, id := range due {
+		retryNotification(ctx, mem, disp, id)
+	}
+
+	// only the recipient who stayed silent gets the fallback
+	if !slices.Equal(sent, []string{"sms +912"}) {
+		t.Errorf("escalated: %v, want only sms +912", sent)
+	}
+	for rec, want := range map[string]string{"+911": "skipped", "+912": "success"} {
+		if got, _ := mem.GetNotification(ctx, smsTo[rec].ID); got.Status != want {
+			t.Errorf("sms to %s is %s, want %s", rec, got.Status, want)
+		}
+	}
+	if left, _ := mem.ClaimDueRetries(ctx, time.Now().Add(time.Hour), retryLease, 10); len(left) != 0 {
+		t.Errorf("still queued: %v", left)
+	}
+}
+
+func TestRetryAfterExpiryIsNotSent(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	Init(mem, mem, mem)
+	sms := &countingHandler{}
+	disp.Register("sms", sms)
+
+	// the retry was scheduled inside the window, which has passed since
+	expired := time.Now().Add(-time.Minute)
+	ev := models.Event{ID: "evt-1", Type: "flood", Title: "Flood warning", Message: "River rising",
+		Channels: []string{"sms"}, Recipients: []string{"+911"}, ExpiresAt: &expired}
+	if err := mem.CreateEvent(ctx, models.EventRecord{Event: ev, Status: "dispatched", AcceptedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if err := mem.SaveNotification(ctx, models.Notification{ID: "notif-1", EventID: "evt-1", Channel: "sms",
+		Recipient: "+911", Status: "failed", Attempts: 1, MaxRetries: 5}); err != nil {
+		t.Fatalf("SaveNotification: %v", err)
+	}
+	now := time.Now()
+	if err := mem.ScheduleRetry(ctx, "notif-1", now, "timeout"); err != nil {
+		t.Fatalf("ScheduleRetry: %v", err)
+	}
+	if ids, err := mem.ClaimDueRetries(ctx, now, retryLease, 10); err != nil || len(ids) != 1 {
+		t.Fatalf("ClaimDueRetries = %v, %v", ids, err)
+	}
+
+	retryNotification(ctx, mem, disp, "notif-1")
+
+	if len(sms.sent) != 0 {
+		t.Errorf("sent %v after the alert expired", sms.sent)
+	}
+	if got, _ := mem.GetNotification(ctx, "notif-1"); got.Status != "expired" {
+		t.Errorf("status = %s, want expired", got.Status)
+	}
+	if n, _ := mem.ReapExpiredLeases(ctx, now.Add(retryLease)); n != 0 {
+		t.Errorf("%d retries still leased, want the expired one removed", n)
+	}
+	if due, _ := mem.GetDueRetries(ctx, now.Add(time.Hour), 10); len(due) != 0 {
+		t.Errorf("retries still queued: %v", due)
+	}
+}
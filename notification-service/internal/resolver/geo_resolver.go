@@ -0,0 +1,61 @@
+// Package resolver holds processor stages that work out who an event should
+// reach before it is handed to the dispatcher.
+package resolver
+
+import (
+	"context"
+	"fmt"
+
+	"notification-service/internal/geo"
+	"notification-service/internal/logger"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+// GeoResolver adds every recipient registered inside the event's target
+// areas to event.Recipients. Candidates come from a radius query on the
+// location index and are then tested exactly against each shape.
+type GeoResolver struct {
+	locations storage.LocationStore
+}
+
+func NewGeoResolver(locations storage.LocationStore) *GeoResolver {
+	return &GeoResolver{locations: locations}
+}
+
+func (r *GeoResolver) Apply(ctx context.Context, event *models.Event) error {
+	if len(event.Areas) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(event.Recipients))
+	for _, rec := range event.Recipients {
+		seen[rec] = true
+	}
+
+	added := 0
+	for _, area := range event.Areas {
+		shapes, err := geo.ShapesFromArea(area)
+		if err != nil {
+			return fmt.Errorf("area %q: %w", area.Description, err)
+		}
+		for _, shape := range shapes {
+			bound := shape.BoundingCircle()
+			candidates, err := r.locations.RecipientsWithinRadius(ctx, bound.Center, bound.RadiusKm)
+			if err != nil {
+				return fmt.Errorf("resolve area %q: %w", area.Description, err)
+			}
+			for _, c := range candidates {
+				if seen[c.RecipientID] || !shape.Contains(c.Location) {
+					continue
+				}
+				seen[c.RecipientID] = true
+				event.Recipients = append(event.Recipients, c.RecipientID)
+				added++
+			}
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Geo resolver: %d recipients in target area of event %s", added, event.ID))
+	return nil
+}
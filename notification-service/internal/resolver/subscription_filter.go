@@ -0,0 +1,91 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"notification-service/internal/logger"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+// SubscriptionFilter drops recipients who have not subscribed to an event's
+// hazard type and severity, and narrows the channels of those who subscribed
+// on only some of them. Register it after any stage that adds recipients.
+//
+// Life-safety rule: an event whose severity is one of the configured
+// life-safety severities (by default "extreme" and "severe") reaches every
+// recipient on every requested channel, whatever their subscriptions say.
+// Recipients without any subscription, including raw addresses, are never
+// filtered. The all-clear of a cancelled alert is not filtered either: it
+// goes to recipients who already got that alert, whatever its own severity.
+// Other alerts that merely reference earlier ones are filtered as usual.
+type SubscriptionFilter struct {
+	subs       storage.SubscriptionStore
+	lifeSafety []string
+}
+
+func NewSubscriptionFilter(subs storage.SubscriptionStore, lifeSafety []string) *SubscriptionFilter {
+	return &SubscriptionFilter{subs: subs, lifeSafety: lifeSafety}
+}
+
+func (f *SubscriptionFilter) Apply(ctx context.Context, event *models.Event) error {
+	if slices.Contains(f.lifeSafety, strings.ToLower(event.Severity)) {
+		logger.Info(fmt.Sprintf("Subscription filter: event %s is life-safety (%s), overriding opt-outs", event.ID, event.Severity))
+		return nil
+	}
+	if event.AllClear {
+		return nil
+	}
+
+	kept := event.Recipients[:0:0]
+	for _, rec := range event.Recipients {
+		subs, err := f.subs.ListSubscriptions(ctx, rec)
+		if err != nil {
+			return fmt.Errorf("subscriptions of %s: %w", rec, err)
+		}
+		if len(subs) == 0 {
+			kept = append(kept, rec)
+			continue
+		}
+
+		channels, all := subscribedChannels(subs, event)
+		if !all && len(channels) == 0 {
+			continue
+		}
+		kept = append(kept, rec)
+		if !all {
+			if event.RecipientChannels == nil {
+				event.RecipientChannels = map[string][]string{}
+			}
+			event.RecipientChannels[rec] = channels
+		}
+	}
+
+	if dropped := len(event.Recipients) - len(kept); dropped > 0 {
+		logger.Info(fmt.Sprintf("Subscription filter: %d recipients not subscribed to event %s", dropped, event.ID))
+	}
+	event.Recipients = kept
+	return nil
+}
+
+// subscribedChannels returns the event channels the matching subscriptions
+// allow, or all=true when one of them allows every channel.
+func subscribedChannels(subs []models.Subscription, event *models.Event) (channels []string, all bool) {
+	for _, sub := range subs {
+		if !sub.Matches(event.Type, event.Severity) {
+			continue
+		}
+		if len(sub.Channels) == 0 {
+			return nil, true
+		}
+		for _, ch := range sub.Channels {
+			if slices.Contains(event.Channels, ch) && !slices.Contains(channels, ch) {
+				channels = append(channels, ch)
+			}
+		}
+	}
+	return channels, false
+}
@@ -0,0 +1,47 @@
+package resolver
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+)
+
+func TestSubscriptionFilter(t *testing.T) {
+	ctx := context.Background()
+	subs := memorystore.New()
+	if err := subs.SaveSubscription(ctx, models.Subscription{
+		ID: "s1", RecipientID: "asha", HazardType: "flood", MinSeverity: "severe",
+	}); err != nil {
+		t.Fatalf("SaveSubscription: %v", err)
+	}
+	filter := NewSubscriptionFilter(subs, []string{"extreme"})
+
+	tests := []struct {
+		name  string
+		event models.Event
+		want  []string
+	}{
+		{"below min severity", models.Event{Type: "flood", Severity: "minor"}, []string{"ravi"}},
+		{"at min severity", models.Event{Type: "flood", Severity: "severe"}, []string{"asha", "ravi"}},
+		{"other hazard", models.Event{Type: "cyclone", Severity: "severe"}, []string{"ravi"}},
+		{"life-safety", models.Event{Type: "cyclone", Severity: "extreme"}, []string{"asha", "ravi"}},
+		{"all-clear", models.Event{Type: "flood", Severity: "minor", References: []string{"evt-1"}, AllClear: true}, []string{"asha", "ravi"}},
+		{"alert with references", models.Event{MsgType: "alert", Type: "flood", Severity: "minor", References: []string{"evt-1"}}, []string{"ravi"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := tt.event
+			event.Channels = []string{"sms"}
+			event.Recipients = []string{"asha", "ravi"}
+			if err := filter.Apply(ctx, &event); err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !slices.Equal(event.Recipients, tt.want) {
+				t.Errorf("recipients = %v, want %v", event.Recipients, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"notification-service/internal/logger"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+// WebhookFanout turns the "webhook" channel of an event into one recipient
+// per matching partner endpoint. Endpoints receive only the webhook channel
+// and other recipients never do, so a phone number is not posted to and an
+// endpoint is not texted. The all-clear of a cancelled alert already names
+// the endpoints that got that alert and is left alone. Register it before
+// the subscription filter.
+type WebhookFanout struct {
+	webhooks storage.WebhookStore
+}
+
+func NewWebhookFanout(webhooks storage.WebhookStore) *WebhookFanout {
+	return &WebhookFanout{webhooks: webhooks}
+}
+
+func (f *WebhookFanout) Apply(ctx context.Context, event *models.Event) error {
+	if !slices.Contains(event.Channels, "webhook") || event.AllClear {
+		return nil
+	}
+	endpoints, err := f.webhooks.ListWebhooks(ctx)
+	if err != nil {
+		return fmt.Errorf("list webhooks: %w", err)
+	}
+
+	others := slices.DeleteFunc(slices.Clone(event.Channels), func(ch string) bool { return ch == "webhook" })
+	if event.RecipientChannels == nil {
+		event.RecipientChannels = map[string][]string{}
+	}
+	for _, rec := range event.Recipients {
+		allowed, ok := event.RecipientChannels[rec]
+		if !ok {
+			allowed = others
+		}
+		event.RecipientChannels[rec] = slices.DeleteFunc(slices.Clone(allowed), func(ch string) bool { return ch == "webhook" })
+	}
+
+	added := 0
+	for _, w := range endpoints {
+		if !w.Matches(event.Type, event.Severity) || slices.Contains(event.Recipients, w.ID) {
+			continue
+		}
+		event.Recipients = append(event.Recipients, w.ID)
+		event.RecipientChannels[w.ID] = []string{"webhook"}
+		added++
+	}
+
+	logger.Info(fmt.Sprintf("Webhook fan-out: %d endpoints for event %s", added, event.ID))
+	return nil
+}
@@ -0,0 +1,73 @@
+package resolver
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+)
+
+func TestWebhookFanout(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	for _, w := range []models.WebhookEndpoint{
+		{ID: "wh-flood", URL: "https://a.example/hook", HazardTypes: []string{"flood"}},
+		{ID: "wh-cyclone", URL: "https://b.example/hook", HazardTypes: []string{"cyclone"}},
+	} {
+		if err := mem.SaveWebhook(ctx, w); err != nil {
+			t.Fatalf("SaveWebhook: %v", err)
+		}
+	}
+	fanout := NewWebhookFanout(mem)
+
+	tests := []struct {
+		name       string
+		event      models.Event
+		recipients []string
+		channels   map[string][]string
+	}{
+		{
+			name:       "alert",
+			event:      models.Event{Type: "flood"},
+			recipients: []string{"+911", "wh-flood"},
+			channels:   map[string][]string{"+911": {"sms"}, "wh-flood": {"webhook"}},
+		},
+		{
+			name:       "alert with references",
+			event:      models.Event{MsgType: "alert", Type: "flood", References: []string{"evt-1"}},
+			recipients: []string{"+911", "wh-flood"},
+			channels:   map[string][]string{"+911": {"sms"}, "wh-flood": {"webhook"}},
+		},
+		{
+			// names the endpoints the cancelled alert went to
+			name: "all-clear",
+			event: models.Event{Type: "flood", References: []string{"evt-1"}, AllClear: true,
+				RecipientChannels: map[string][]string{"+911": {"sms"}, "wh-cyclone": {"webhook"}}},
+			recipients: []string{"+911", "wh-cyclone"},
+			channels:   map[string][]string{"+911": {"sms"}, "wh-cyclone": {"webhook"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := tt.event
+			event.Channels = []string{"sms", "webhook"}
+			event.Recipients = []string{"+911"}
+			if event.AllClear {
+				event.Recipients = []string{"+911", "wh-cyclone"}
+			}
+			if err := fanout.Apply(ctx, &event); err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !slices.Equal(event.Recipients, tt.recipients) {
+				t.Errorf("recipients = %v, want %v", event.Recipients, tt.recipients)
+			}
+			for rec, want := range tt.channels {
+				if got := event.RecipientChannels[rec]; !slices.Equal(got, want) {
+					t.Errorf("channels of %s = %v, want %v", rec, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package smsenc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Length policies for messages longer than the segment limit.
+const (
+	PolicyNone     = "none"     // send as is, only count segments
+	PolicyTruncate = "truncate" // cut to the limit and end with an ellipsis
+	PolicyShorten  = "shorten"  // swap look-alike characters for GSM-7 ones and tidy whitespace, then truncate
+)
+
+// Policy bounds the segments one SMS may use.
+type Policy struct {
+	Mode        string
+	MaxSegments int // 0 means no limit
+}
+
+// ParsePolicy checks a mode name from configuration.
+func ParsePolicy(mode string, maxSegments int) (Policy, error) {
+	switch mode {
+	case PolicyNone, PolicyTruncate, PolicyShorten:
+		return Policy{Mode: mode, MaxSegments: maxSegments}, nil
+	default:
+		return Policy{}, fmt.Errorf("unknown SMS length policy %q (want none, truncate or shorten)", mode)
+	}
+}
+
+// lookalikes maps common typographic characters, which would force the
+// whole message into UCS-2, to GSM-7 equivalents.
+var lookalikes = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "′", "'",
+	"“", "\"", "”", "\"", "„", "\"", "″", "\"",
+	"–", "-", "—", "-", "−", "-", "‐", "-", "‑", "-",
+	"…", "...", " ", " ", " ", " ", "​", "",
+	"•", "*", "·", ".", "\t", " ",
+)
+
+// Apply fits s to the policy and returns the text to send with its encoding
+// and segment count.
+func (p Policy) Apply(s string) (string, Info) {
+	return p.ApplySuffix(s, "")
+}
+
+// ApplySuffix is Apply for a message that must end in suffix, e.g. a link:
+// only s is shortened or truncated, the suffix is always kept whole.
+func (p Policy) ApplySuffix(s, suffix string) (string, Info) {
+	if p.Mode == PolicyShorten {
+		s = shorten(s)
+	}
+	info := Analyze(s + suffix)
+	if p.Mode == PolicyNone || p.MaxSegments <= 0 || info.Segments <= p.MaxSegments {
+		return s + suffix, info
+	}
+	s = truncate(s, suffix, info.Encoding, p.MaxSegments)
+	return s, Analyze(s)
+}
+
+// shorten replaces look-alike characters when that makes the message
+// GSM-7, and collapses runs of spaces and blank lines.
+func shorten(s string) string {
+	if replaced := lookalikes.Replace(s); IsGSM7(replaced) {
+		s = replaced
+	}
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// truncate keeps as much of s as fits in maxSegments segments together with
+// the suffix, ending it in an ellipsis. The ellipsis is chosen so it does not
+// change the encoding.
+func truncate(s, suffix, encoding string, maxSegments int) string {
+	ellipsis := "…"
+	if encoding == GSM7 {
+		ellipsis = "..."
+	}
+	runes := []rune(s)
+	cut := func(n int) string {
+		return strings.TrimRight(string(runes[:n]), " \n") + ellipsis + suffix
+	}
+	// segment count only grows with length, so search for the longest prefix
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if Analyze(cut(mid)).Segments <= maxSegments {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return cut(lo)
+}
@@ -0,0 +1,132 @@
+// Package smsenc works out how an SMS body will be encoded and split before
+// it is sent: GSM-7 when every character is in the GSM 03.38 alphabet,
+// UCS-2 otherwise (which covers every Indic script), and the number of
+// concatenated segments the carrier bills for.
+package smsenc
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	GSM7 = "GSM-7"
+	UCS2 = "UCS-2"
+)
+
+// Segment sizes in septets (GSM-7) or UTF-16 code units (UCS-2). A
+// multi-part message loses room in every part to the concatenation header.
+const (
+	gsmSingle  = 160
+	gsmMulti   = 153
+	ucs2Single = 70
+	ucs2Multi  = 67
+)
+
+// gsmBasic is the GSM 03.38 default alphabet; each character is one septet.
+const gsmBasic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsmExtension characters need an escape septet, so they count twice.
+const gsmExtension = "^{}\\[~]|€\f"
+
+var gsmWidth = func() map[rune]int {
+	m := map[rune]int{}
+	for _, r := range gsmBasic {
+		m[r] = 1
+	}
+	for _, r := range gsmExtension {
+		m[r] = 2
+	}
+	return m
+}()
+
+// Info describes how a message will go over the air.
+type Info struct {
+	Encoding   string `json:"encoding"`
+	Characters int    `json:"characters"`
+	Units      int    `json:"units"` // septets for GSM-7, UTF-16 code units for UCS-2
+	Segments   int    `json:"segments"`
+}
+
+// IsGSM7 reports whether s can be sent in the GSM-7 alphabet.
+func IsGSM7(s string) bool {
+	for _, r := range s {
+		if gsmWidth[r] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Analyze detects the encoding of s and counts its segments.
+func Analyze(s string) Info {
+	if s == "" {
+		return Info{Encoding: GSM7}
+	}
+	if IsGSM7(s) {
+		return Info{
+			Encoding:   GSM7,
+			Characters: len([]rune(s)),
+			Units:      gsmUnits(s),
+			Segments:   len(split(s, GSM7)),
+		}
+	}
+	return Info{
+		Encoding:   UCS2,
+		Characters: len([]rune(s)),
+		Units:      len(utf16.Encode([]rune(s))),
+		Segments:   len(split(s, UCS2)),
+	}
+}
+
+func gsmUnits(s string) int {
+	n := 0
+	for _, r := range s {
+		n += gsmWidth[r]
+	}
+	return n
+}
+
+// runeUnits is the size of r in the given encoding.
+func runeUnits(r rune, encoding string) int {
+	if encoding == GSM7 {
+		return gsmWidth[r]
+	}
+	if r >= 0x10000 {
+		return 2 // surrogate pair
+	}
+	return 1
+}
+
+// split cuts s into the segments a handset reassembles. An escaped GSM
+// character or a surrogate pair is never split across two segments.
+func split(s string, encoding string) []string {
+	single, multi := gsmSingle, gsmMulti
+	if encoding == UCS2 {
+		single, multi = ucs2Single, ucs2Multi
+	}
+
+	total := 0
+	for _, r := range s {
+		total += runeUnits(r, encoding)
+	}
+	if total <= single {
+		return []string{s}
+	}
+
+	var parts []string
+	var cur strings.Builder
+	used := 0
+	for _, r := range s {
+		w := runeUnits(r, encoding)
+		if used+w > multi {
+			parts = append(parts, cur.String())
+			cur.Reset()
+			used = 0
+		}
+		cur.WriteRune(r)
+		used += w
+	}
+	return append(parts, cur.String())
+}
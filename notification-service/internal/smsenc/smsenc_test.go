@@ -0,0 +1,183 @@
+package smsenc
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		encoding string
+		units    int
+		segments int
+	}{
+		{"Empty", "", GSM7, 0, 0},
+		{"GSMAccents", "Ñandù: évacuez à l'école", GSM7, 24, 1},
+		{"RupeeSign", "Relief of ₹500 per family", UCS2, 25, 1},
+		{"Hindi", "बाढ़ की चेतावनी", UCS2, 15, 1},
+		{"Tamil", "வெள்ளம்", UCS2, 7, 1},
+
+		{"GSMSingleFull", strings.Repeat("a", 160), GSM7, 160, 1},
+		{"GSMSingleOver", strings.Repeat("a", 161), GSM7, 161, 2},
+		{"GSMTwoFull", strings.Repeat("a", 306), GSM7, 306, 2},
+		{"GSMThree", strings.Repeat("a", 307), GSM7, 307, 3},
+
+		{"ExtensionCountsTwice", strings.Repeat("a", 158) + "€", GSM7, 160, 1},
+		{"ExtensionTipsOver", strings.Repeat("a", 159) + "€", GSM7, 161, 2},
+		{"AllExtension", "^{}\\[~]|€", GSM7, 18, 1},
+
+		{"UCS2SingleFull", strings.Repeat("अ", 70), UCS2, 70, 1},
+		{"UCS2SingleOver", strings.Repeat("अ", 71), UCS2, 71, 2},
+		{"UCS2TwoFull", strings.Repeat("अ", 134), UCS2, 134, 2},
+		{"UCS2Three", strings.Repeat("अ", 135), UCS2, 135, 3},
+		{"EmojiIsTwoUnits", strings.Repeat("अ", 68) + "🌊", UCS2, 70, 1},
+		{"EmojiTipsOver", strings.Repeat("अ", 69) + "🌊", UCS2, 71, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Analyze(tt.text)
+			if got.Encoding != tt.encoding || got.Units != tt.units || got.Segments != tt.segments {
+				t.Errorf("Analyze = %+v, want %s, %d units, %d segments", got, tt.encoding, tt.units, tt.segments)
+			}
+			if got.Characters != utf8.RuneCountInString(tt.text) {
+				t.Errorf("characters = %d, want %d", got.Characters, utf8.RuneCountInString(tt.text))
+			}
+		})
+	}
+}
+
+func TestSplitKeepsMultiUnitCharactersWhole(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		encoding  string
+		firstSize int // units in the first segment
+	}{
+		// 152 septets leave one free in a 153-septet part; the escaped € needs two
+		{"GSMEscape", strings.Repeat("a", 152) + "€" + strings.Repeat("a", 10), GSM7, 152},
+		// 66 units leave one free in a 67-unit part; the surrogate pair needs two
+		{"SurrogatePair", strings.Repeat("अ", 66) + "🌊" + strings.Repeat("अ", 10), UCS2, 66},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := split(tt.text, tt.encoding)
+			if len(parts) != 2 || strings.Join(parts, "") != tt.text {
+				t.Fatalf("split into %d parts %q", len(parts), parts)
+			}
+			for _, p := range parts {
+				if !utf8.ValidString(p) {
+					t.Errorf("part %q is not valid text", p)
+				}
+			}
+			size := gsmUnits(parts[0])
+			if tt.encoding == UCS2 {
+				size = len(utf16.Encode([]rune(parts[0])))
+			}
+			if size != tt.firstSize {
+				t.Errorf("first part has %d units, want %d", size, tt.firstSize)
+			}
+		})
+	}
+}
+
+func TestPolicyEnforcesLength(t *testing.T) {
+	long := strings.Repeat("Move to higher ground now. ", 10) // 270 GSM-7 characters
+	hindi := strings.Repeat("ऊँचे स्थान पर जाएँ। ", 10)
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		text     string
+		suffix   string
+		segments int
+		encoding string
+		check    func(t *testing.T, out string)
+	}{
+		{
+			name: "NoneOnlyCounts", policy: Policy{Mode: PolicyNone, MaxSegments: 1}, text: long,
+			segments: 2, encoding: GSM7,
+			check: func(t *testing.T, out string) {
+				if out != long {
+					t.Errorf("text changed to %q", out)
+				}
+			},
+		},
+		{
+			name: "NoLimit", policy: Policy{Mode: PolicyTruncate}, text: long,
+			segments: 2, encoding: GSM7,
+		},
+		{
+			name: "TruncateGSM", policy: Policy{Mode: PolicyTruncate, MaxSegments: 1}, text: long,
+			segments: 1, encoding: GSM7,
+			check: func(t *testing.T, out string) {
+				// the space before the cut is trimmed
+				if !strings.HasSuffix(out, "ground...") || len(out) != 159 {
+					t.Errorf("truncated to %d characters %q, want 159 ending in ...", len(out), out)
+				}
+			},
+		},
+		{
+			name: "TruncateUCS2", policy: Policy{Mode: PolicyTruncate, MaxSegments: 1}, text: hindi,
+			segments: 1, encoding: UCS2,
+			check: func(t *testing.T, out string) {
+				if !strings.HasSuffix(out, "…") {
+					t.Errorf("truncated to %q, want it to end in …", out)
+				}
+			},
+		},
+		{
+			name: "SuffixKeptWhole", policy: Policy{Mode: PolicyTruncate, MaxSegments: 1}, text: long,
+			suffix: "\nConfirm: https://vs.example/a/abc", segments: 1, encoding: GSM7,
+			check: func(t *testing.T, out string) {
+				if !strings.HasSuffix(out, "...\nConfirm: https://vs.example/a/abc") {
+					t.Errorf("got %q, want the text cut before the link", out)
+				}
+			},
+		},
+		{
+			name: "ShortenSwapsLookalikes", policy: Policy{Mode: PolicyShorten, MaxSegments: 1},
+			text: "“Cyclone” warning – move   inland…\n\n\nShelters open", segments: 1, encoding: GSM7,
+			check: func(t *testing.T, out string) {
+				if out != "\"Cyclone\" warning - move inland...\nShelters open" {
+					t.Errorf("shortened to %q", out)
+				}
+			},
+		},
+		{
+			name: "ShortenKeepsLookalikesInUCS2", policy: Policy{Mode: PolicyShorten, MaxSegments: 1},
+			text: "“चक्रवात” – सावधान", segments: 1, encoding: UCS2,
+			check: func(t *testing.T, out string) {
+				if !strings.Contains(out, "“") {
+					t.Errorf("shortened to %q, want the quotes kept as the text stays UCS-2", out)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, info := tt.policy.ApplySuffix(tt.text, tt.suffix)
+			if info.Segments != tt.segments || info.Encoding != tt.encoding {
+				t.Errorf("info = %+v, want %s in %d segments", info, tt.encoding, tt.segments)
+			}
+			if got := Analyze(out); got != info {
+				t.Errorf("reported %+v, but the text analyses as %+v", info, got)
+			}
+			if tt.check != nil {
+				tt.check(t, out)
+			}
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	if p, err := ParsePolicy(PolicyShorten, 3); err != nil || p.Mode != PolicyShorten || p.MaxSegments != 3 {
+		t.Errorf("ParsePolicy(shorten) = %+v, %v", p, err)
+	}
+	if _, err := ParsePolicy("drop", 1); err == nil {
+		t.Error("ParsePolicy(drop) accepted an unknown mode")
+	}
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"notification-service/pkg/models"
+)
+
+// SuppressionStore keeps the addresses recipients have opted out on.
+type SuppressionStore interface {
+	Suppress(ctx context.Context, s models.Suppression) error
+	// Unsuppress lifts a suppression; lifting an absent one is not an error.
+	Unsuppress(ctx context.Context, channel, address string) error
+	IsSuppressed(ctx context.Context, channel, address string) (bool, error)
+	// ListSuppressions returns a channel's suppressions, oldest first.
+	ListSuppressions(ctx context.Context, channel string) ([]models.Suppression, error)
+}
+
+// ReportStore keeps citizen reports received as replies to alerts.
+type ReportStore interface {
+	SaveReport(ctx context.Context, r models.CitizenReport) error
+	// ListReportsByEvent returns an event's reports, oldest first; the
+	// empty event ID lists reports not linked to any alert.
+	ListReportsByEvent(ctx context.Context, eventID string) ([]models.CitizenReport, error)
+}
+
+// AckStore keeps the acknowledgements of events by recipient.
+type AckStore interface {
+	// RecordAck keeps the first acknowledgement of an event by a recipient
+	// and reports whether this one was it.
+	RecordAck(ctx context.Context, ack models.Ack) (bool, error)
+	IsAcknowledged(ctx context.Context, eventID, recipient string) (bool, error)
+	// ListAcks returns an event's acknowledgements, oldest first.
+	ListAcks(ctx context.Context, eventID string) ([]models.Ack, error)
+}
@@ -0,0 +1,15 @@
+package storage
+
+import (
+	"context"
+	"notification-service/pkg/models"
+)
+
+// LocationStore is a geospatial index of where recipients are registered.
+type LocationStore interface {
+	SetLocation(ctx context.Context, recipientID string, loc models.GeoPoint) error
+	GetLocation(ctx context.Context, recipientID string) (*models.GeoPoint, error)
+	RemoveLocation(ctx context.Context, recipientID string) error
+	// RecipientsWithinRadius returns every recipient within radiusKm of center.
+	RecipientsWithinRadius(ctx context.Context, center models.GeoPoint, radiusKm float64) ([]models.LocatedRecipient, error)
+}
@@ -0,0 +1,97 @@
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+func (s *MemoryStore) EnqueueEvent(ctx context.Context, event models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextMsg++
+	s.queue = append(s.queue, storage.QueuedEvent{
+		MessageID: fmt.Sprintf("%d-0", s.nextMsg),
+		Event:     event,
+	})
+	close(s.wake)
+	s.wake = make(chan struct{})
+	return nil
+}
+
+func (s *MemoryStore) ReadEvents(ctx context.Context, consumer string, count int, block time.Duration) ([]storage.QueuedEvent, error) {
+	if count <= 0 {
+		count = 1
+	}
+	timer := time.NewTimer(block)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		if len(s.queue) > 0 {
+			n := min(count, len(s.queue))
+			out := append([]storage.QueuedEvent(nil), s.queue[:n]...)
+			s.queue = s.queue[n:]
+			for _, qe := range out {
+				s.pending[qe.MessageID] = &pendingEvent{entry: qe, consumer: consumer, deliverAt: time.Now()}
+			}
+			s.mu.Unlock()
+			return out, nil
+		}
+		wake := s.wake
+		s.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *MemoryStore) ClaimStaleEvents(ctx context.Context, consumer string, minIdle time.Duration, count int) ([]storage.QueuedEvent, error) {
+	if count <= 0 {
+		count = 10
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []storage.QueuedEvent
+	now := time.Now()
+	for _, p := range s.pending {
+		if len(out) >= count {
+			break
+		}
+		if now.Sub(p.deliverAt) >= minIdle {
+			p.consumer = consumer
+			p.deliverAt = now
+			out = append(out, p.entry)
+		}
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) TouchEvent(ctx context.Context, consumer, messageID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.pending[messageID]; ok {
+		p.consumer = consumer
+		p.deliverAt = time.Now()
+	}
+	return nil
+}
+
+func (s *MemoryStore) AckEvent(ctx context.Context, messageID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pending, messageID)
+	return nil
+}
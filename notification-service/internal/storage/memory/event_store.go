@@ -0,0 +1,92 @@
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+func (s *MemoryStore) SaveEvent(ctx context.Context, record models.EventRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := record
+	if r.UpdatedAt.IsZero() {
+		r.UpdatedAt = r.AcceptedAt
+	}
+	s.events[record.Event.ID] = &r
+	return nil
+}
+
+func (s *MemoryStore) CreateEvent(ctx context.Context, record models.EventRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.events[record.Event.ID]; ok {
+		return fmt.Errorf("event %s: %w", record.Event.ID, storage.ErrExists)
+	}
+	r := record
+	if r.UpdatedAt.IsZero() {
+		r.UpdatedAt = r.AcceptedAt
+	}
+	s.events[record.Event.ID] = &r
+	return nil
+}
+
+func (s *MemoryStore) GetEvent(ctx context.Context, id string) (*models.EventRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.events[id]
+	if !ok {
+		return nil, fmt.Errorf("event %s: %w", id, storage.ErrNotFound)
+	}
+	cp := *r
+	return &cp, nil
+}
+
+func (s *MemoryStore) UpdateEventStatus(ctx context.Context, id string, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.events[id]
+	if !ok {
+		return fmt.Errorf("update event status %s: %w", id, storage.ErrNotFound)
+	}
+	r.Status = status
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *MemoryStore) TransitionEventStatus(ctx context.Context, id string, from []string, status string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.events[id]
+	if !ok {
+		return false, fmt.Errorf("transition event status %s: %w", id, storage.ErrNotFound)
+	}
+	if !slices.Contains(from, r.Status) {
+		return false, nil
+	}
+	r.Status = status
+	r.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (s *MemoryStore) UpdateEventContent(ctx context.Context, event models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.events[event.ID]
+	if !ok {
+		return fmt.Errorf("update event %s: %w", event.ID, storage.ErrNotFound)
+	}
+	r.Event = event
+	r.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,106 @@
+package memorystore
+
+import (
+	"context"
+	"sort"
+
+	"notification-service/pkg/models"
+)
+
+func (s *MemoryStore) Suppress(ctx context.Context, sup models.Suppression) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.suppressions[sup.Channel] == nil {
+		s.suppressions[sup.Channel] = map[string]models.Suppression{}
+	}
+	s.suppressions[sup.Channel][sup.Address] = sup
+	return nil
+}
+
+func (s *MemoryStore) Unsuppress(ctx context.Context, channel, address string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.suppressions[channel], address)
+	return nil
+}
+
+func (s *MemoryStore) IsSuppressed(ctx context.Context, channel, address string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.suppressions[channel][address]
+	return ok, nil
+}
+
+func (s *MemoryStore) ListSuppressions(ctx context.Context, channel string) ([]models.Suppression, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]models.Suppression, 0, len(s.suppressions[channel]))
+	for _, sup := range s.suppressions[channel] {
+		out = append(out, sup)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].Address < out[j].Address
+	})
+	return out, nil
+}
+
+func (s *MemoryStore) SaveReport(ctx context.Context, r models.CitizenReport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reports[r.EventID] = append(s.reports[r.EventID], r)
+	return nil
+}
+
+func (s *MemoryStore) ListReportsByEvent(ctx context.Context, eventID string) ([]models.CitizenReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]models.CitizenReport{}, s.reports[eventID]...), nil
+}
+
+func (s *MemoryStore) RecordAck(ctx context.Context, ack models.Ack) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.acks[ack.EventID][ack.Recipient]; ok {
+		return false, nil
+	}
+	if s.acks[ack.EventID] == nil {
+		s.acks[ack.EventID] = map[string]models.Ack{}
+	}
+	s.acks[ack.EventID][ack.Recipient] = ack
+	return true, nil
+}
+
+func (s *MemoryStore) IsAcknowledged(ctx context.Context, eventID, recipient string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.acks[eventID][recipient]
+	return ok, nil
+}
+
+func (s *MemoryStore) ListAcks(ctx context.Context, eventID string) ([]models.Ack, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]models.Ack, 0, len(s.acks[eventID]))
+	for _, a := range s.acks[eventID] {
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].AckedAt.Equal(out[j].AckedAt) {
+			return out[i].AckedAt.Before(out[j].AckedAt)
+		}
+		return out[i].Recipient < out[j].Recipient
+	})
+	return out, nil
+}
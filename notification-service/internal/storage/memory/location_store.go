@@ -0,0 +1,53 @@
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"notification-service/internal/geo"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+func (s *MemoryStore) SetLocation(ctx context.Context, recipientID string, loc models.GeoPoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.locations[recipientID] = loc
+	return nil
+}
+
+func (s *MemoryStore) GetLocation(ctx context.Context, recipientID string) (*models.GeoPoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loc, ok := s.locations[recipientID]
+	if !ok {
+		return nil, fmt.Errorf("location of %s: %w", recipientID, storage.ErrNotFound)
+	}
+	return &loc, nil
+}
+
+func (s *MemoryStore) RemoveLocation(ctx context.Context, recipientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.locations, recipientID)
+	return nil
+}
+
+// RecipientsWithinRadius scans every location; fine for tests and local runs.
+func (s *MemoryStore) RecipientsWithinRadius(ctx context.Context, center models.GeoPoint, radiusKm float64) ([]models.LocatedRecipient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []models.LocatedRecipient
+	for id, loc := range s.locations {
+		if geo.DistanceKm(center, loc) <= radiusKm {
+			out = append(out, models.LocatedRecipient{RecipientID: id, Location: loc})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].RecipientID < out[j].RecipientID })
+	return out, nil
+}
@@ -0,0 +1,326 @@
+package memorystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+// MemoryStore keeps everything in process memory. It implements the same
+// storage interfaces as redisstore.RedisStore, for tests and local runs
+// without infrastructure; nothing survives a restart.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	notifs   map[string]*models.Notification
+	byEvent  map[string][]string // event ID -> notification IDs in creation order
+	retries  *retryIndex
+	inflight map[string]time.Time // claimed ID -> lease deadline
+
+	events     map[string]*models.EventRecord
+	locations  map[string]models.GeoPoint
+	recipients map[string]*models.Recipient
+	subs       map[string]*models.Subscription
+	templates  map[string][]models.Template // name -> versions, oldest first
+	webhooks   map[string]*models.WebhookEndpoint
+
+	suppressions map[string]map[string]models.Suppression // channel -> address
+	reports      map[string][]models.CitizenReport        // event ID -> reports
+	acks         map[string]map[string]models.Ack         // event ID -> recipient
+
+	queue   []storage.QueuedEvent    // not yet read
+	pending map[string]*pendingEvent // read, not yet acknowledged
+	wake    chan struct{}            // closed and replaced on enqueue
+	nextMsg int64
+}
+
+type pendingEvent struct {
+	entry     storage.QueuedEvent
+	consumer  string
+	deliverAt time.Time
+}
+
+// New returns an empty MemoryStore.
+func New() *MemoryStore {
+	return &MemoryStore{
+		notifs:     map[string]*models.Notification{},
+		byEvent:    map[string][]string{},
+		retries:    newRetryIndex(),
+		inflight:   map[string]time.Time{},
+		events:     map[string]*models.EventRecord{},
+		locations:  map[string]models.GeoPoint{},
+		recipients: map[string]*models.Recipient{},
+		subs:       map[string]*models.Subscription{},
+		templates:  map[string][]models.Template{},
+		webhooks:   map[string]*models.WebhookEndpoint{},
+
+		suppressions: map[string]map[string]models.Suppression{},
+		reports:      map[string][]models.CitizenReport{},
+		acks:         map[string]map[string]models.Ack{},
+		pending:      map[string]*pendingEvent{},
+		wake:         make(chan struct{}),
+	}
+}
+
+func (s *MemoryStore) SaveNotification(ctx context.Context, notif models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, exists := s.notifs[notif.ID]
+	if !exists && notif.EventID != "" {
+		s.byEvent[notif.EventID] = append(s.byEvent[notif.EventID], notif.ID)
+	}
+	n := notif
+	n.UpdatedAt = notif.Timestamp
+	// like the Redis and SQL stores: the provider's answer and the attempt
+	// count are kept, and attempts and max_retries only overwritten when set
+	if exists {
+		n.LastError = old.LastError // only IncrementAttempts records it
+		n.APIStatusCode, n.APIResponse = old.APIStatusCode, old.APIResponse
+		n.ProviderMessageID = old.ProviderMessageID
+		if n.Attempts == 0 {
+			n.Attempts = old.Attempts
+		}
+		if n.MaxRetries == 0 {
+			n.MaxRetries = old.MaxRetries
+		}
+	}
+	s.notifs[notif.ID] = &n
+	return nil
+}
+
+func (s *MemoryStore) GetNotification(ctx context.Context, id string) (*models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.notifs[id]
+	if !ok {
+		return nil, fmt.Errorf("notification %s: %w", id, storage.ErrNotFound)
+	}
+	cp := *n
+	return &cp, nil
+}
+
+func (s *MemoryStore) LatestNotificationTo(ctx context.Context, channel, recipient string) (*models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var latest *models.Notification
+	for _, n := range s.notifs {
+		if n.Channel != channel || n.Recipient != recipient || !slices.Contains(storage.HandedOver, n.Status) {
+			continue
+		}
+		if latest == nil || n.Timestamp.After(latest.Timestamp) ||
+			(n.Timestamp.Equal(latest.Timestamp) && n.ID > latest.ID) {
+			latest = n
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("notification to %s via %s: %w", recipient, channel, storage.ErrNotFound)
+	}
+	cp := *latest
+	return &cp, nil
+}
+
+func (s *MemoryStore) ListNotificationsByEvent(ctx context.Context, eventID string, offset, limit int) ([]models.Notification, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	ids := s.byEvent[eventID]
+	// keep creation order stable even if records were saved out of order
+	sort.SliceStable(ids, func(i, j int) bool {
+		return s.notifs[ids[i]].Timestamp.Before(s.notifs[ids[j]].Timestamp)
+	})
+
+	out := []models.Notification{}
+	for i := offset; i < len(ids) && i < offset+limit; i++ {
+		out = append(out, *s.notifs[ids[i]])
+	}
+	return out, len(ids), nil
+}
+
+func (s *MemoryStore) UpdateNotificationStatus(ctx context.Context, id string, status string, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.notifs[id]
+	if !ok {
+		return fmt.Errorf("update status %s: %w", id, storage.ErrNotFound)
+	}
+	n.Status = status
+	n.Error = errMsg
+	n.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *MemoryStore) TransitionNotificationStatus(ctx context.Context, id string, from []string, status string, errMsg string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.notifs[id]
+	if !ok {
+		return false, fmt.Errorf("transition status %s: %w", id, storage.ErrNotFound)
+	}
+	if !slices.Contains(from, n.Status) {
+		return false, nil
+	}
+	n.Status = status
+	n.Error = errMsg
+	n.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (s *MemoryStore) UpdateNotificationContent(ctx context.Context, notif models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.notifs[notif.ID]
+	if !ok {
+		return fmt.Errorf("update content %s: %w", notif.ID, storage.ErrNotFound)
+	}
+	n.Message, n.Subject, n.HTMLBody, n.Language = notif.Message, notif.Subject, notif.HTMLBody, notif.Language
+	n.SMSEncoding, n.SMSSegments = notif.SMSEncoding, notif.SMSSegments
+	n.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *MemoryStore) IncrementAttempts(ctx context.Context, id string, lastError string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.notifs[id]
+	if !ok {
+		return 0, fmt.Errorf("increment attempts %s: %w", id, storage.ErrNotFound)
+	}
+	n.Attempts++
+	n.LastError = lastError
+	return n.Attempts, nil
+}
+
+func (s *MemoryStore) ScheduleRetry(ctx context.Context, notifID string, nextRetry time.Time, lastErr string) error {
+	if notifID == "" {
+		return errors.New("sched retry: empty notifID")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n, ok := s.notifs[notifID]; ok {
+		n.Error = lastErr
+		n.UpdatedAt = time.Now()
+	}
+	delete(s.inflight, notifID)
+	s.retries.set(notifID, nextRetry)
+	return nil
+}
+
+func (s *MemoryStore) GetDueRetries(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.retries.due(before, limit), nil
+}
+
+func (s *MemoryStore) ClaimDueRetries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]string, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []string
+	for len(ids) < limit {
+		it, ok := s.retries.peek()
+		if !ok || it.due.After(now) {
+			break
+		}
+		s.retries.remove(it.id)
+		s.inflight[it.id] = now.Add(lease)
+		ids = append(ids, it.id)
+	}
+	return ids, nil
+}
+
+func (s *MemoryStore) ReapExpiredLeases(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for id, deadline := range s.inflight {
+		if !deadline.After(now) {
+			delete(s.inflight, id)
+			s.retries.set(id, now)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryStore) RemoveFromRetryQueue(ctx context.Context, notifID string) error {
+	if notifID == "" {
+		return errors.New("remove retry: empty notifID")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.retries.remove(notifID)
+	delete(s.inflight, notifID)
+	return nil
+}
+
+func (s *MemoryStore) UpdateAPIResponse(ctx context.Context, id string, statusCode int, body string) error {
+	if id == "" {
+		return fmt.Errorf("update api response: empty id")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.notifs[id]
+	if !ok {
+		return fmt.Errorf("update api response %s: %w", id, storage.ErrNotFound)
+	}
+	// same 1000-char cap as the Redis store
+	if len(body) > 1000 {
+		body = body[:1000]
+	}
+	n.APIStatusCode = statusCode
+	n.APIResponse = body
+	n.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *MemoryStore) SetProviderMessageID(ctx context.Context, id string, providerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.notifs[id]
+	if !ok {
+		return fmt.Errorf("set provider message id %s: %w", id, storage.ErrNotFound)
+	}
+	n.ProviderMessageID = providerID
+	n.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (s *MemoryStore) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,20 @@
+package memorystore
+
+import (
+	"testing"
+
+	"notification-service/internal/storage"
+	"notification-service/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.RunNotificationStoreTests(t, func(t *testing.T) storage.NotificationStore {
+		return New()
+	})
+}
+
+func TestEventConformance(t *testing.T) {
+	storagetest.RunEventStoreTests(t, func(t *testing.T) storage.EventStore {
+		return New()
+	})
+}
@@ -0,0 +1,132 @@
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+func (s *MemoryStore) SaveRecipient(ctx context.Context, r models.Recipient) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := r
+	cp.Location = nil // lives in the location index
+	s.recipients[r.ID] = &cp
+	if r.Location != nil {
+		s.locations[r.ID] = *r.Location
+	} else {
+		delete(s.locations, r.ID)
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetRecipient(ctx context.Context, id string) (*models.Recipient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.recipients[id]
+	if !ok {
+		return nil, fmt.Errorf("recipient %s: %w", id, storage.ErrNotFound)
+	}
+	return s.withLocation(r), nil
+}
+
+func (s *MemoryStore) DeleteRecipient(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.recipients[id]; !ok {
+		return fmt.Errorf("recipient %s: %w", id, storage.ErrNotFound)
+	}
+	delete(s.recipients, id)
+	delete(s.locations, id)
+	return nil
+}
+
+func (s *MemoryStore) ListRecipients(ctx context.Context, offset, limit int) ([]models.Recipient, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	all := make([]*models.Recipient, 0, len(s.recipients))
+	for _, r := range s.recipients {
+		all = append(all, r)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if !all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].CreatedAt.Before(all[j].CreatedAt)
+		}
+		return all[i].ID < all[j].ID
+	})
+
+	out := []models.Recipient{}
+	for i := offset; i < len(all) && i < offset+limit; i++ {
+		out = append(out, *s.withLocation(all[i]))
+	}
+	return out, len(all), nil
+}
+
+// withLocation copies a profile and fills in its indexed location; callers
+// hold s.mu.
+func (s *MemoryStore) withLocation(r *models.Recipient) *models.Recipient {
+	cp := *r
+	if loc, ok := s.locations[r.ID]; ok {
+		cp.Location = &loc
+	}
+	return &cp
+}
+
+func (s *MemoryStore) MarkPushTokenDead(ctx context.Context, recipientID, address string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.recipients[recipientID]
+	if !ok {
+		return fmt.Errorf("recipient %s: %w", recipientID, storage.ErrNotFound)
+	}
+	r.PushTokens = slices.Clone(r.PushTokens)
+	for i, t := range r.PushTokens {
+		if t.Address() == address {
+			r.PushTokens[i].Dead = true
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) SaveWebPushSubscription(ctx context.Context, recipientID string, sub models.WebPushSubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.recipients[recipientID]
+	if !ok {
+		return fmt.Errorf("recipient %s: %w", recipientID, storage.ErrNotFound)
+	}
+	r.WebPush = slices.Clone(r.WebPush) // copies handed out share the old array
+	r.PutWebPush(sub)
+	return nil
+}
+
+func (s *MemoryStore) DeleteWebPushSubscription(ctx context.Context, recipientID, endpoint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.recipients[recipientID]
+	if !ok {
+		return fmt.Errorf("recipient %s: %w", recipientID, storage.ErrNotFound)
+	}
+	r.WebPush = slices.Clone(r.WebPush)
+	if !r.RemoveWebPush(endpoint) {
+		return fmt.Errorf("web push subscription: %w", storage.ErrNotFound)
+	}
+	return nil
+}
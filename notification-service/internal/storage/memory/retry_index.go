@@ -0,0 +1,110 @@
+package memorystore
+
+import (
+	"container/heap"
+	"time"
+)
+
+// retryIndex is a min-heap of notification IDs ordered by due time (ties by
+// ID, matching Redis ZSET ordering) with O(log n) update and removal.
+type retryIndex struct {
+	items []*retryItem
+	pos   map[string]*retryItem
+}
+
+type retryItem struct {
+	id    string
+	due   time.Time
+	index int
+}
+
+func newRetryIndex() *retryIndex {
+	return &retryIndex{pos: map[string]*retryItem{}}
+}
+
+// set inserts id or moves it to a new due time.
+func (r *retryIndex) set(id string, due time.Time) {
+	if it, ok := r.pos[id]; ok {
+		it.due = due
+		heap.Fix(r, it.index)
+		return
+	}
+	it := &retryItem{id: id, due: due}
+	r.pos[id] = it
+	heap.Push(r, it)
+}
+
+func (r *retryIndex) remove(id string) bool {
+	it, ok := r.pos[id]
+	if !ok {
+		return false
+	}
+	heap.Remove(r, it.index)
+	delete(r.pos, id)
+	return true
+}
+
+// peek returns the earliest entry without removing it.
+func (r *retryIndex) peek() (*retryItem, bool) {
+	if len(r.items) == 0 {
+		return nil, false
+	}
+	return r.items[0], true
+}
+
+// due returns up to limit IDs due at or before before, earliest first,
+// without modifying the index.
+func (r *retryIndex) due(before time.Time, limit int) []string {
+	var out []string
+	// walk the heap from the root, only descending into due subtrees
+	cand := &retryIndex{pos: map[string]*retryItem{}}
+	if len(r.items) > 0 {
+		heap.Push(cand, &retryItem{id: r.items[0].id, due: r.items[0].due, index: 0})
+	}
+	for cand.Len() > 0 && len(out) < limit {
+		it := heap.Pop(cand).(*retryItem)
+		if it.due.After(before) {
+			break
+		}
+		out = append(out, it.id)
+		src := r.pos[it.id].index
+		for _, child := range []int{2*src + 1, 2*src + 2} {
+			if child < len(r.items) {
+				c := r.items[child]
+				heap.Push(cand, &retryItem{id: c.id, due: c.due})
+			}
+		}
+	}
+	return out
+}
+
+func (r *retryIndex) Len() int { return len(r.items) }
+
+func (r *retryIndex) Less(i, j int) bool {
+	a, b := r.items[i], r.items[j]
+	if a.due.Equal(b.due) {
+		return a.id < b.id
+	}
+	return a.due.Before(b.due)
+}
+
+func (r *retryIndex) Swap(i, j int) {
+	r.items[i], r.items[j] = r.items[j], r.items[i]
+	r.items[i].index = i
+	r.items[j].index = j
+}
+
+func (r *retryIndex) Push(x any) {
+	it := x.(*retryItem)
+	it.index = len(r.items)
+	r.items = append(r.items, it)
+}
+
+func (r *retryIndex) Pop() any {
+	old := r.items
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	r.items = old[:n-1]
+	return it
+}
@@ -0,0 +1,61 @@
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+func (s *MemoryStore) SaveSubscription(ctx context.Context, sub models.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := sub
+	s.subs[sub.ID] = &cp
+	return nil
+}
+
+func (s *MemoryStore) GetSubscription(ctx context.Context, id string) (*models.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[id]
+	if !ok {
+		return nil, fmt.Errorf("subscription %s: %w", id, storage.ErrNotFound)
+	}
+	cp := *sub
+	return &cp, nil
+}
+
+func (s *MemoryStore) DeleteSubscription(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[id]; !ok {
+		return fmt.Errorf("subscription %s: %w", id, storage.ErrNotFound)
+	}
+	delete(s.subs, id)
+	return nil
+}
+
+func (s *MemoryStore) ListSubscriptions(ctx context.Context, recipientID string) ([]models.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := []models.Subscription{}
+	for _, sub := range s.subs {
+		if sub.RecipientID == recipientID {
+			out = append(out, *sub)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+func (s *MemoryStore) CreateTemplateVersion(ctx context.Context, t models.Template) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t.Version = len(s.templates[t.Name]) + 1
+	s.templates[t.Name] = append(s.templates[t.Name], t)
+	return t.Version, nil
+}
+
+func (s *MemoryStore) GetTemplate(ctx context.Context, name string, version int) (*models.Template, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions := s.templates[name]
+	if version == 0 {
+		version = len(versions)
+	}
+	if version < 1 || version > len(versions) {
+		return nil, fmt.Errorf("template %s v%d: %w", name, version, storage.ErrNotFound)
+	}
+	t := versions[version-1]
+	return &t, nil
+}
+
+func (s *MemoryStore) ListTemplateVersions(ctx context.Context, name string) ([]models.Template, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions, ok := s.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s: %w", name, storage.ErrNotFound)
+	}
+	return append([]models.Template(nil), versions...), nil
+}
+
+func (s *MemoryStore) ListTemplates(ctx context.Context) ([]models.Template, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := []models.Template{}
+	for _, versions := range s.templates {
+		out = append(out, versions[len(versions)-1])
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
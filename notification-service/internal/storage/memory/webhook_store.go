@@ -0,0 +1,59 @@
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+func (s *MemoryStore) SaveWebhook(ctx context.Context, w models.WebhookEndpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := w
+	s.webhooks[w.ID] = &cp
+	return nil
+}
+
+func (s *MemoryStore) GetWebhook(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.webhooks[id]
+	if !ok {
+		return nil, fmt.Errorf("webhook %s: %w", id, storage.ErrNotFound)
+	}
+	cp := *w
+	return &cp, nil
+}
+
+func (s *MemoryStore) DeleteWebhook(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.webhooks[id]; !ok {
+		return fmt.Errorf("webhook %s: %w", id, storage.ErrNotFound)
+	}
+	delete(s.webhooks, id)
+	return nil
+}
+
+func (s *MemoryStore) ListWebhooks(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]models.WebhookEndpoint, 0, len(s.webhooks))
+	for _, w := range s.webhooks {
+		out = append(out, *w)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
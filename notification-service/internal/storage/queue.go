@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"notification-service/pkg/models"
+	"time"
+)
+
+// QueuedEvent is an event read from the intake queue together with the
+// queue's own message ID, which must be passed back to AckEvent.
+type QueuedEvent struct {
+	MessageID string
+	Event     models.Event
+}
+
+// EventQueue is the durable intake queue between the HTTP API and the
+// dispatch workers. Entries stay pending until acknowledged, so events
+// accepted before a crash or restart are delivered again afterwards.
+type EventQueue interface {
+	EnqueueEvent(ctx context.Context, event models.Event) error
+	// ReadEvents blocks up to block waiting for new events for consumer.
+	ReadEvents(ctx context.Context, consumer string, count int, block time.Duration) ([]QueuedEvent, error)
+	// ClaimStaleEvents takes over entries that another consumer read but did
+	// not acknowledge within minIdle (e.g. because its process died).
+	ClaimStaleEvents(ctx context.Context, consumer string, minIdle time.Duration, count int) ([]QueuedEvent, error)
+	// TouchEvent tells the queue that consumer is still working on an entry, so
+	// ClaimStaleEvents does not hand a long dispatch to another consumer.
+	TouchEvent(ctx context.Context, consumer, messageID string) error
+	AckEvent(ctx context.Context, messageID string) error
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"notification-service/pkg/models"
+)
+
+// RecipientStore is the recipient directory. Saving a profile also updates
+// the recipient's entry in the location index, and reading one returns the
+// indexed location, so the two never disagree.
+type RecipientStore interface {
+	SaveRecipient(ctx context.Context, r models.Recipient) error
+	GetRecipient(ctx context.Context, id string) (*models.Recipient, error)
+	DeleteRecipient(ctx context.Context, id string) error
+	// ListRecipients returns one page of profiles in creation order and the total count.
+	ListRecipients(ctx context.Context, offset, limit int) ([]models.Recipient, int, error)
+	// MarkPushTokenDead flags a recipient's device token so it is no longer
+	// sent to; address is the token as used in notifications.
+	MarkPushTokenDead(ctx context.Context, recipientID, address string) error
+	// SaveWebPushSubscription adds a browser subscription to the profile,
+	// replacing one with the same endpoint.
+	SaveWebPushSubscription(ctx context.Context, recipientID string, sub models.WebPushSubscription) error
+	// DeleteWebPushSubscription removes the subscription with the endpoint.
+	DeleteWebPushSubscription(ctx context.Context, recipientID, endpoint string) error
+}
@@ -0,0 +1,142 @@
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// The intake queue is a Redis stream read through a consumer group: entries
+// stay in the group's pending list until acknowledged, so nothing accepted by
+// the API is lost if a worker dies mid-dispatch.
+const (
+	eventStream = "event_stream"
+	eventGroup  = "event_processors"
+)
+
+// ensureEventGroup creates the consumer group (and the stream) if missing.
+func (s *RedisStore) ensureEventGroup(ctx context.Context) error {
+	err := s.rdb.XGroupCreateMkStream(ctx, eventStream, eventGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("xgroup create: %w", err)
+	}
+	return nil
+}
+
+// EnqueueEvent appends the event to the intake stream.
+func (s *RedisStore) EnqueueEvent(ctx context.Context, event models.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("enqueue event: marshal: %w", err)
+	}
+	if err := s.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: eventStream,
+		Values: map[string]interface{}{"event_id": event.ID, "event": payload},
+	}).Err(); err != nil {
+		return fmt.Errorf("enqueue event: xadd: %w", err)
+	}
+	return nil
+}
+
+// ReadEvents reads new entries for consumer, blocking up to block.
+// Returns an empty slice (and no error) when the wait times out.
+func (s *RedisStore) ReadEvents(ctx context.Context, consumer string, count int, block time.Duration) ([]storage.QueuedEvent, error) {
+	if count <= 0 {
+		count = 1
+	}
+	args := &redis.XReadGroupArgs{
+		Group:    eventGroup,
+		Consumer: consumer,
+		Streams:  []string{eventStream, ">"},
+		Count:    int64(count),
+		Block:    block,
+	}
+	streams, err := s.rdb.XReadGroup(ctx, args).Result()
+	if err != nil && strings.HasPrefix(err.Error(), "NOGROUP") {
+		// stream was deleted underneath us (e.g. FLUSHDB); recreate and retry once
+		if err := s.ensureEventGroup(ctx); err != nil {
+			return nil, err
+		}
+		streams, err = s.rdb.XReadGroup(ctx, args).Result()
+	}
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read events: xreadgroup: %w", err)
+	}
+
+	var out []storage.QueuedEvent
+	for _, st := range streams {
+		out = append(out, s.decodeEvents(ctx, st.Messages)...)
+	}
+	return out, nil
+}
+
+// ClaimStaleEvents moves entries idle for at least minIdle to consumer.
+func (s *RedisStore) ClaimStaleEvents(ctx context.Context, consumer string, minIdle time.Duration, count int) ([]storage.QueuedEvent, error) {
+	if count <= 0 {
+		count = 10
+	}
+	msgs, _, err := s.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   eventStream,
+		Group:    eventGroup,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    int64(count),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("claim stale events: xautoclaim: %w", err)
+	}
+	return s.decodeEvents(ctx, msgs), nil
+}
+
+// TouchEvent resets the idle time of a pending entry by claiming it again for
+// consumer, which XAUTOCLAIM then measures from now.
+func (s *RedisStore) TouchEvent(ctx context.Context, consumer, messageID string) error {
+	if err := s.rdb.XClaimJustID(ctx, &redis.XClaimArgs{
+		Stream:   eventStream,
+		Group:    eventGroup,
+		Consumer: consumer,
+		MinIdle:  0,
+		Messages: []string{messageID},
+	}).Err(); err != nil {
+		return fmt.Errorf("touch event: xclaim: %w", err)
+	}
+	return nil
+}
+
+// AckEvent acknowledges and deletes a processed entry so the stream stays small.
+func (s *RedisStore) AckEvent(ctx context.Context, messageID string) error {
+	pipe := s.rdb.TxPipeline()
+	pipe.XAck(ctx, eventStream, eventGroup, messageID)
+	pipe.XDel(ctx, eventStream, messageID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("ack event: %w", err)
+	}
+	return nil
+}
+
+// decodeEvents unmarshals stream entries. Entries that cannot be decoded will
+// never be processable, so they are acknowledged and dropped here.
+func (s *RedisStore) decodeEvents(ctx context.Context, msgs []redis.XMessage) []storage.QueuedEvent {
+	out := make([]storage.QueuedEvent, 0, len(msgs))
+	for _, msg := range msgs {
+		var event models.Event
+		raw, _ := msg.Values["event"].(string)
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			_ = s.AckEvent(ctx, msg.ID)
+			continue
+		}
+		out = append(out, storage.QueuedEvent{MessageID: msg.ID, Event: event})
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func (s *RedisStore) eventKey(id string) string {
+	return "event:" + id
+}
+
+// SaveEvent stores the event payload and its lifecycle status.
+func (s *RedisStore) SaveEvent(ctx context.Context, record models.EventRecord) error {
+	payload, err := json.Marshal(record.Event)
+	if err != nil {
+		return fmt.Errorf("save event: marshal: %w", err)
+	}
+	updated := record.UpdatedAt
+	if updated.IsZero() {
+		updated = record.AcceptedAt
+	}
+	if _, err := s.rdb.HSet(ctx, s.eventKey(record.Event.ID),
+		"payload", payload,
+		"status", record.Status,
+		"accepted_at", record.AcceptedAt.Unix(),
+		"updated_at", updated.Unix(),
+	).Result(); err != nil {
+		return fmt.Errorf("save event: hset: %w", err)
+	}
+	return nil
+}
+
+// createEventScript writes the event hash unless the key exists.
+// KEYS: event hash. ARGV: payload, status, accepted_at, updated_at.
+var createEventScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'payload', ARGV[1], 'status', ARGV[2], 'accepted_at', ARGV[3], 'updated_at', ARGV[4])
+return 1
+`)
+
+// CreateEvent stores a new event unless its ID is taken.
+func (s *RedisStore) CreateEvent(ctx context.Context, record models.EventRecord) error {
+	payload, err := json.Marshal(record.Event)
+	if err != nil {
+		return fmt.Errorf("create event: marshal: %w", err)
+	}
+	updated := record.UpdatedAt
+	if updated.IsZero() {
+		updated = record.AcceptedAt
+	}
+	created, err := createEventScript.Run(ctx, s.rdb, []string{s.eventKey(record.Event.ID)},
+		payload, record.Status, record.AcceptedAt.Unix(), updated.Unix()).Int()
+	if err != nil {
+		return fmt.Errorf("create event: %w", err)
+	}
+	if created == 0 {
+		return fmt.Errorf("event %s: %w", record.Event.ID, storage.ErrExists)
+	}
+	return nil
+}
+
+// GetEvent loads a stored event record.
+func (s *RedisStore) GetEvent(ctx context.Context, id string) (*models.EventRecord, error) {
+	result, err := s.rdb.HGetAll(ctx, s.eventKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get event: hgetall: %w", err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("event %s: %w", id, storage.ErrNotFound)
+	}
+
+	record := &models.EventRecord{Status: result["status"]}
+	if err := json.Unmarshal([]byte(result["payload"]), &record.Event); err != nil {
+		return nil, fmt.Errorf("get event: unmarshal: %w", err)
+	}
+	if t, err := strconv.ParseInt(result["accepted_at"], 10, 64); err == nil {
+		record.AcceptedAt = time.Unix(t, 0)
+	}
+	if t, err := strconv.ParseInt(result["updated_at"], 10, 64); err == nil {
+		record.UpdatedAt = time.Unix(t, 0)
+	}
+	return record, nil
+}
+
+// UpdateEventStatus changes the lifecycle status of a stored event.
+func (s *RedisStore) UpdateEventStatus(ctx context.Context, id string, status string) error {
+	if _, err := s.rdb.HSet(ctx, s.eventKey(id),
+		"status", status,
+		"updated_at", time.Now().Unix(),
+	).Result(); err != nil {
+		return fmt.Errorf("update event status: %w", err)
+	}
+	return nil
+}
+
+// eventTransitionScript sets status and updated_at if the current status is
+// one of the remaining arguments. KEYS: event hash. ARGV: status,
+// updated_at, allowed current statuses...
+// Returns 1 if set, 0 if not, -1 if the event does not exist.
+var eventTransitionScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'status')
+if not current then
+	return -1
+end
+for i = 3, #ARGV do
+	if ARGV[i] == current then
+		redis.call('HSET', KEYS[1], 'status', ARGV[1], 'updated_at', ARGV[2])
+		return 1
+	end
+end
+return 0
+`)
+
+func (s *RedisStore) TransitionEventStatus(ctx context.Context, id string, from []string, status string) (bool, error) {
+	args := []any{status, time.Now().Unix()}
+	for _, f := range from {
+		args = append(args, f)
+	}
+	n, err := eventTransitionScript.Run(ctx, s.rdb, []string{s.eventKey(id)}, args...).Int()
+	if err != nil {
+		return false, fmt.Errorf("transition event status: %w", err)
+	}
+	if n < 0 {
+		return false, fmt.Errorf("transition event status: event %s: %w", id, storage.ErrNotFound)
+	}
+	return n == 1, nil
+}
+
+// updateEventScript replaces the payload of an existing event hash.
+// KEYS: event hash. ARGV: payload, updated_at.
+var updateEventScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'payload', ARGV[1], 'updated_at', ARGV[2])
+return 1
+`)
+
+// UpdateEventContent replaces the event payload, leaving the status alone.
+func (s *RedisStore) UpdateEventContent(ctx context.Context, event models.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("update event: marshal: %w", err)
+	}
+	n, err := updateEventScript.Run(ctx, s.rdb, []string{s.eventKey(event.ID)}, payload, time.Now().Unix()).Int()
+	if err != nil {
+		return fmt.Errorf("update event: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("event %s: %w", event.ID, storage.ErrNotFound)
+	}
+	return nil
+}
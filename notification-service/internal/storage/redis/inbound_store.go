@@ -0,0 +1,171 @@
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"notification-service/pkg/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// suppressionsKey holds a channel's suppressions, address -> JSON.
+func (s *RedisStore) suppressionsKey(channel string) string {
+	return "suppressions:" + channel
+}
+
+func (s *RedisStore) reportKey(id string) string {
+	return "report:" + id
+}
+
+// eventReportsKey indexes an event's report IDs by receipt time.
+func (s *RedisStore) eventReportsKey(eventID string) string {
+	return "event_reports:" + eventID
+}
+
+func (s *RedisStore) Suppress(ctx context.Context, sup models.Suppression) error {
+	payload, err := json.Marshal(sup)
+	if err != nil {
+		return fmt.Errorf("suppress: marshal: %w", err)
+	}
+	if err := s.rdb.HSet(ctx, s.suppressionsKey(sup.Channel), sup.Address, payload).Err(); err != nil {
+		return fmt.Errorf("suppress: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Unsuppress(ctx context.Context, channel, address string) error {
+	if err := s.rdb.HDel(ctx, s.suppressionsKey(channel), address).Err(); err != nil {
+		return fmt.Errorf("unsuppress: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) IsSuppressed(ctx context.Context, channel, address string) (bool, error) {
+	ok, err := s.rdb.HExists(ctx, s.suppressionsKey(channel), address).Result()
+	if err != nil {
+		return false, fmt.Errorf("is suppressed: %w", err)
+	}
+	return ok, nil
+}
+
+func (s *RedisStore) ListSuppressions(ctx context.Context, channel string) ([]models.Suppression, error) {
+	all, err := s.rdb.HGetAll(ctx, s.suppressionsKey(channel)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list suppressions: %w", err)
+	}
+	out := make([]models.Suppression, 0, len(all))
+	for _, payload := range all {
+		var sup models.Suppression
+		if err := json.Unmarshal([]byte(payload), &sup); err != nil {
+			return nil, fmt.Errorf("list suppressions: unmarshal: %w", err)
+		}
+		out = append(out, sup)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].Address < out[j].Address
+	})
+	return out, nil
+}
+
+func (s *RedisStore) SaveReport(ctx context.Context, r models.CitizenReport) error {
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("save report: marshal: %w", err)
+	}
+	pipe := s.rdb.TxPipeline()
+	pipe.Set(ctx, s.reportKey(r.ID), payload, 0)
+	pipe.ZAdd(ctx, s.eventReportsKey(r.EventID), redis.Z{
+		Score:  float64(r.ReceivedAt.UnixMilli()),
+		Member: r.ID,
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("save report: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) ListReportsByEvent(ctx context.Context, eventID string) ([]models.CitizenReport, error) {
+	ids, err := s.rdb.ZRange(ctx, s.eventReportsKey(eventID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list reports: zrange: %w", err)
+	}
+	out := []models.CitizenReport{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+
+	pipe := s.rdb.Pipeline()
+	gets := make([]*redis.StringCmd, len(ids))
+	for i, id := range ids {
+		gets[i] = pipe.Get(ctx, s.reportKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("list reports: %w", err)
+	}
+	for _, get := range gets {
+		payload, err := get.Bytes()
+		if err != nil {
+			continue
+		}
+		var r models.CitizenReport
+		if err := json.Unmarshal(payload, &r); err != nil {
+			return nil, fmt.Errorf("list reports: unmarshal: %w", err)
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// eventAcksKey holds an event's acknowledgements, recipient -> JSON.
+func (s *RedisStore) eventAcksKey(eventID string) string {
+	return "event_acks:" + eventID
+}
+
+func (s *RedisStore) RecordAck(ctx context.Context, ack models.Ack) (bool, error) {
+	payload, err := json.Marshal(ack)
+	if err != nil {
+		return false, fmt.Errorf("record ack: marshal: %w", err)
+	}
+	first, err := s.rdb.HSetNX(ctx, s.eventAcksKey(ack.EventID), ack.Recipient, payload).Result()
+	if err != nil {
+		return false, fmt.Errorf("record ack: %w", err)
+	}
+	return first, nil
+}
+
+func (s *RedisStore) IsAcknowledged(ctx context.Context, eventID, recipient string) (bool, error) {
+	ok, err := s.rdb.HExists(ctx, s.eventAcksKey(eventID), recipient).Result()
+	if err != nil {
+		return false, fmt.Errorf("is acknowledged: %w", err)
+	}
+	return ok, nil
+}
+
+func (s *RedisStore) ListAcks(ctx context.Context, eventID string) ([]models.Ack, error) {
+	all, err := s.rdb.HGetAll(ctx, s.eventAcksKey(eventID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list acks: %w", err)
+	}
+	out := make([]models.Ack, 0, len(all))
+	for _, payload := range all {
+		var a models.Ack
+		if err := json.Unmarshal([]byte(payload), &a); err != nil {
+			return nil, fmt.Errorf("list acks: unmarshal: %w", err)
+		}
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].AckedAt.Equal(out[j].AckedAt) {
+			return out[i].AckedAt.Before(out[j].AckedAt)
+		}
+		return out[i].Recipient < out[j].Recipient
+	})
+	return out, nil
+}
@@ -0,0 +1,65 @@
+package redisstore
+
+import (
+	"context"
+	"fmt"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// locationsGeo is a Redis GEO set of recipient IDs.
+const locationsGeo = "recipient_locations"
+
+func (s *RedisStore) SetLocation(ctx context.Context, recipientID string, loc models.GeoPoint) error {
+	if err := s.rdb.GeoAdd(ctx, locationsGeo, &redis.GeoLocation{
+		Name:      recipientID,
+		Longitude: loc.Lon,
+		Latitude:  loc.Lat,
+	}).Err(); err != nil {
+		return fmt.Errorf("set location: geoadd: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) GetLocation(ctx context.Context, recipientID string) (*models.GeoPoint, error) {
+	pos, err := s.rdb.GeoPos(ctx, locationsGeo, recipientID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get location: geopos: %w", err)
+	}
+	if len(pos) == 0 || pos[0] == nil {
+		return nil, fmt.Errorf("location of %s: %w", recipientID, storage.ErrNotFound)
+	}
+	return &models.GeoPoint{Lat: pos[0].Latitude, Lon: pos[0].Longitude}, nil
+}
+
+func (s *RedisStore) RemoveLocation(ctx context.Context, recipientID string) error {
+	if err := s.rdb.ZRem(ctx, locationsGeo, recipientID).Err(); err != nil {
+		return fmt.Errorf("remove location: zrem: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) RecipientsWithinRadius(ctx context.Context, center models.GeoPoint, radiusKm float64) ([]models.LocatedRecipient, error) {
+	locs, err := s.rdb.GeoSearchLocation(ctx, locationsGeo, &redis.GeoSearchLocationQuery{
+		GeoSearchQuery: redis.GeoSearchQuery{
+			Longitude:  center.Lon,
+			Latitude:   center.Lat,
+			Radius:     radiusKm,
+			RadiusUnit: "km",
+		},
+		WithCoord: true,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("recipients within radius: geosearch: %w", err)
+	}
+	out := make([]models.LocatedRecipient, len(locs))
+	for i, l := range locs {
+		out[i] = models.LocatedRecipient{
+			RecipientID: l.Name,
+			Location:    models.GeoPoint{Lat: l.Latitude, Lon: l.Longitude},
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,211 @@
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// recipientsZSet indexes recipient IDs by creation time for listing.
+const recipientsZSet = "recipients"
+
+func (s *RedisStore) recipientKey(id string) string {
+	return "recipient:" + id
+}
+
+// SaveRecipient stores the profile and keeps the location index in step.
+func (s *RedisStore) SaveRecipient(ctx context.Context, r models.Recipient) error {
+	loc := r.Location
+	r.Location = nil // lives in the location index
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("save recipient: marshal: %w", err)
+	}
+
+	pipe := s.rdb.TxPipeline()
+	pipe.Set(ctx, s.recipientKey(r.ID), payload, 0)
+	pipe.ZAdd(ctx, recipientsZSet, redis.Z{
+		Score:  float64(r.CreatedAt.UnixMilli()),
+		Member: r.ID,
+	})
+	if loc != nil {
+		pipe.GeoAdd(ctx, locationsGeo, &redis.GeoLocation{
+			Name:      r.ID,
+			Longitude: loc.Lon,
+			Latitude:  loc.Lat,
+		})
+	} else {
+		pipe.ZRem(ctx, locationsGeo, r.ID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("save recipient: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) GetRecipient(ctx context.Context, id string) (*models.Recipient, error) {
+	pipe := s.rdb.Pipeline()
+	get := pipe.Get(ctx, s.recipientKey(id))
+	pos := pipe.GeoPos(ctx, locationsGeo, id)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("get recipient: %w", err)
+	}
+	payload, err := get.Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("recipient %s: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get recipient: %w", err)
+	}
+	return decodeRecipient(payload, pos.Val())
+}
+
+func (s *RedisStore) DeleteRecipient(ctx context.Context, id string) error {
+	pipe := s.rdb.TxPipeline()
+	del := pipe.Del(ctx, s.recipientKey(id))
+	pipe.ZRem(ctx, recipientsZSet, id)
+	pipe.ZRem(ctx, locationsGeo, id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("delete recipient: %w", err)
+	}
+	if del.Val() == 0 {
+		return fmt.Errorf("recipient %s: %w", id, storage.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *RedisStore) ListRecipients(ctx context.Context, offset, limit int) ([]models.Recipient, int, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	total, err := s.rdb.ZCard(ctx, recipientsZSet).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("list recipients: zcard: %w", err)
+	}
+	ids, err := s.rdb.ZRange(ctx, recipientsZSet, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("list recipients: zrange: %w", err)
+	}
+
+	out := []models.Recipient{}
+	if len(ids) == 0 {
+		return out, int(total), nil
+	}
+	pipe := s.rdb.Pipeline()
+	gets := make([]*redis.StringCmd, len(ids))
+	for i, id := range ids {
+		gets[i] = pipe.Get(ctx, s.recipientKey(id))
+	}
+	pos := pipe.GeoPos(ctx, locationsGeo, ids...)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, fmt.Errorf("list recipients: %w", err)
+	}
+	for i, get := range gets {
+		payload, err := get.Bytes()
+		if err != nil {
+			continue // deleted between ZRANGE and GET
+		}
+		r, err := decodeRecipient(payload, pos.Val()[i:i+1])
+		if err != nil {
+			return nil, 0, fmt.Errorf("list recipients: %w", err)
+		}
+		out = append(out, *r)
+	}
+	return out, int(total), nil
+}
+
+// decodeRecipient unmarshals a stored profile and attaches its GEOPOS result.
+func decodeRecipient(payload []byte, pos []*redis.GeoPos) (*models.Recipient, error) {
+	var r models.Recipient
+	if err := json.Unmarshal(payload, &r); err != nil {
+		return nil, fmt.Errorf("unmarshal recipient: %w", err)
+	}
+	if len(pos) > 0 && pos[0] != nil {
+		r.Location = &models.GeoPoint{Lat: pos[0].Latitude, Lon: pos[0].Longitude}
+	}
+	return &r, nil
+}
+
+// MarkPushTokenDead rewrites the profile with the token flagged.
+func (s *RedisStore) MarkPushTokenDead(ctx context.Context, recipientID, address string) error {
+	err := s.updateRecipient(ctx, recipientID, func(r *models.Recipient) (bool, error) {
+		changed := false
+		for i, t := range r.PushTokens {
+			if t.Address() == address && !t.Dead {
+				r.PushTokens[i].Dead = true
+				changed = true
+			}
+		}
+		return changed, nil
+	})
+	if err != nil {
+		return fmt.Errorf("mark push token dead: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) SaveWebPushSubscription(ctx context.Context, recipientID string, sub models.WebPushSubscription) error {
+	err := s.updateRecipient(ctx, recipientID, func(r *models.Recipient) (bool, error) {
+		r.PutWebPush(sub)
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("save web push subscription: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) DeleteWebPushSubscription(ctx context.Context, recipientID, endpoint string) error {
+	err := s.updateRecipient(ctx, recipientID, func(r *models.Recipient) (bool, error) {
+		if !r.RemoveWebPush(endpoint) {
+			return false, fmt.Errorf("web push subscription: %w", storage.ErrNotFound)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("delete web push subscription: %w", err)
+	}
+	return nil
+}
+
+// updateRecipient applies fn to the stored profile and writes it back if fn
+// reports a change. WATCH makes the read-modify-write safe against a
+// concurrent profile update.
+func (s *RedisStore) updateRecipient(ctx context.Context, id string, fn func(*models.Recipient) (bool, error)) error {
+	key := s.recipientKey(id)
+	return s.rdb.Watch(ctx, func(tx *redis.Tx) error {
+		payload, err := tx.Get(ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return fmt.Errorf("recipient %s: %w", id, storage.ErrNotFound)
+		}
+		if err != nil {
+			return err
+		}
+		var r models.Recipient
+		if err := json.Unmarshal(payload, &r); err != nil {
+			return err
+		}
+		changed, err := fn(&r)
+		if err != nil || !changed {
+			return err
+		}
+		updated, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, updated, 0)
+			return nil
+		})
+		return err
+	}, key)
+}
@@ -3,9 +3,12 @@ package redisstore
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"notification-service/internal/storage"
 	"notification-service/pkg/models"
+	"slices"
 	"strconv"
 	"time"
 
@@ -54,7 +57,11 @@ func NewRedisStore(ctx context.Context, cfg Config) (*RedisStore, error) {
 		return nil, fmt.Errorf("redis ping failed: %w", err)
 	}
 
-	return &RedisStore{rdb: rdb}, nil
+	s := &RedisStore{rdb: rdb}
+	if err := s.ensureEventGroup(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
 func (s *RedisStore) Close(ctx context.Context) error {
@@ -65,19 +72,36 @@ func (s *RedisStore) notifKey(id string) string {
 	return "notification:" + id
 }
 
+// eventNotifsKey indexes an event's notification IDs by creation time.
+func (s *RedisStore) eventNotifsKey(eventID string) string {
+	return "event_notifications:" + eventID
+}
+
+// recipientNotifsKey indexes the latest notifications sent to an address on
+// a channel by creation time.
+func (s *RedisStore) recipientNotifsKey(channel, recipient string) string {
+	return "recipient_notifications:" + channel + ":" + recipient
+}
+
+// recipientNotifsKept bounds the per-address index; only the latest
+// handed-over entry is ever read.
+const recipientNotifsKept = 20
+
 // SaveNotification stores/updates the notification hash.
 // Now also writes attempts and max_retries if present in the model.
 func (s *RedisStore) SaveNotification(ctx context.Context, notif models.Notification) error {
 	key := s.notifKey(notif.ID)
 	fields := map[string]interface{}{
-		"event_id":   notif.EventID,
-		"recipient":  notif.Recipient,
-		"channel":    notif.Channel,
-		"message":    notif.Message,
-		"status":     notif.Status,
-		"error":      notif.Error,
-		"created_at": notif.Timestamp.Unix(),
-		"updated_at": notif.Timestamp.Unix(),
+		"event_id":     notif.EventID,
+		"recipient":    notif.Recipient,
+		"recipient_id": notif.RecipientID,
+		"channel":      notif.Channel,
+		"severity":     notif.Severity,
+		"message":      notif.Message,
+		"status":       notif.Status,
+		"error":        notif.Error,
+		"created_at":   notif.Timestamp.Unix(),
+		"updated_at":   notif.Timestamp.Unix(),
 	}
 
 	// persist attempts and max_retries if present (0 means unset)
@@ -87,8 +111,47 @@ func (s *RedisStore) SaveNotification(ctx context.Context, notif models.Notifica
 	if notif.MaxRetries > 0 {
 		fields["max_retries"] = notif.MaxRetries
 	}
+	if notif.Subject != "" {
+		fields["subject"] = notif.Subject
+	}
+	if notif.HTMLBody != "" {
+		fields["html_body"] = notif.HTMLBody
+	}
+	if notif.Language != "" {
+		fields["language"] = notif.Language
+	}
+	if notif.SMSSegments > 0 {
+		fields["sms_encoding"] = notif.SMSEncoding
+		fields["sms_segments"] = notif.SMSSegments
+	}
+	if len(notif.Attachments) > 0 {
+		attachments, err := json.Marshal(notif.Attachments)
+		if err != nil {
+			return fmt.Errorf("marshal attachments: %w", err)
+		}
+		fields["attachments"] = attachments
+	}
+	if notif.ExpiresAt != nil {
+		fields["expires_at"] = notif.ExpiresAt.Unix()
+	}
 
-	if _, err := s.rdb.HSet(ctx, key, fields).Result(); err != nil {
+	pipe := s.rdb.TxPipeline()
+	pipe.HSet(ctx, key, fields)
+	if notif.EventID != "" {
+		pipe.ZAdd(ctx, s.eventNotifsKey(notif.EventID), redis.Z{
+			Score:  float64(notif.Timestamp.UnixMilli()),
+			Member: notif.ID,
+		})
+	}
+	if notif.Recipient != "" {
+		rkey := s.recipientNotifsKey(notif.Channel, notif.Recipient)
+		pipe.ZAdd(ctx, rkey, redis.Z{
+			Score:  float64(notif.Timestamp.UnixMilli()),
+			Member: notif.ID,
+		})
+		pipe.ZRemRangeByRank(ctx, rkey, 0, -recipientNotifsKept-1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("hset: %w", err)
 	}
 	return nil
@@ -96,40 +159,133 @@ func (s *RedisStore) SaveNotification(ctx context.Context, notif models.Notifica
 
 // UpdateNotificationStatus marks final state for the attempt and updates timestamp.
 func (s *RedisStore) UpdateNotificationStatus(ctx context.Context, id string, status string, errMsg string) error {
-	key := s.notifKey(id)
-	fields := map[string]interface{}{
-		"status":     status,
-		"error":      errMsg,
-		"updated_at": time.Now().Unix(),
+	if err := s.updateNotification(ctx, id, "status", status, "error", errMsg, "updated_at", time.Now().Unix()); err != nil {
+		return fmt.Errorf("update status: %w", err)
+	}
+	return nil
+}
+
+// updateScript sets fields of a notification hash that exists, so updates
+// of an unknown ID do not leave an orphan hash behind. KEYS: notification
+// hash. ARGV: field, value pairs. Returns 0 if the notification does not
+// exist.
+var updateScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], unpack(ARGV))
+return 1
+`)
+
+// updateNotification sets fields of an existing notification, failing with
+// storage.ErrNotFound for an unknown ID.
+func (s *RedisStore) updateNotification(ctx context.Context, id string, fieldsAndValues ...any) error {
+	n, err := updateScript.Run(ctx, s.rdb, []string{s.notifKey(id)}, fieldsAndValues...).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("notification %s: %w", id, storage.ErrNotFound)
+	}
+	return nil
+}
+
+// transitionScript sets status, error and updated_at if the current status
+// is one of the remaining arguments. KEYS: notification hash. ARGV: status,
+// error, updated_at, allowed current statuses...
+// Returns 1 if set, 0 if not, -1 if the notification does not exist.
+var transitionScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'status')
+if not current then
+	return -1
+end
+for i = 4, #ARGV do
+	if ARGV[i] == current then
+		redis.call('HSET', KEYS[1], 'status', ARGV[1], 'error', ARGV[2], 'updated_at', ARGV[3])
+		return 1
+	end
+end
+return 0
+`)
+
+func (s *RedisStore) TransitionNotificationStatus(ctx context.Context, id string, from []string, status string, errMsg string) (bool, error) {
+	args := []any{status, errMsg, time.Now().Unix()}
+	for _, f := range from {
+		args = append(args, f)
+	}
+	n, err := transitionScript.Run(ctx, s.rdb, []string{s.notifKey(id)}, args...).Int()
+	if err != nil {
+		return false, fmt.Errorf("transition status: %w", err)
 	}
-	if _, err := s.rdb.HSet(ctx, key, fields).Result(); err != nil {
-		return fmt.Errorf("update hset: %w", err)
+	if n < 0 {
+		return false, fmt.Errorf("transition status: notification %s: %w", id, storage.ErrNotFound)
+	}
+	return n == 1, nil
+}
+
+// UpdateNotificationContent swaps the rendered content, e.g. when an alert is updated.
+func (s *RedisStore) UpdateNotificationContent(ctx context.Context, notif models.Notification) error {
+	if err := s.updateNotification(ctx, notif.ID,
+		"message", notif.Message,
+		"subject", notif.Subject,
+		"html_body", notif.HTMLBody,
+		"language", notif.Language,
+		"sms_encoding", notif.SMSEncoding,
+		"sms_segments", notif.SMSSegments,
+		"updated_at", time.Now().Unix(),
+	); err != nil {
+		return fmt.Errorf("update content: %w", err)
 	}
 	return nil
 }
 
-const retryZSet = "retry_queue"
+const (
+	retryZSet    = "retry_queue"
+	inflightZSet = "retry_inflight" // claimed IDs scored by lease deadline
+)
 
-// ScheduleRetry writes last error and adds the ID to a time-ordered ZSET
+// claimScript moves due members of the retry queue into the in-flight set in
+// one atomic step. KEYS: retry queue, in-flight set. ARGV: now, lease deadline, limit.
+var claimScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('ZADD', KEYS[2], ARGV[2], id)
+end
+return ids
+`)
+
+// reapScript returns members with an expired lease to the retry queue, due now.
+// KEYS: retry queue, in-flight set. ARGV: now, limit.
+var reapScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('ZADD', KEYS[1], ARGV[1], id)
+end
+return #ids
+`)
+
+// ScheduleRetry writes last error and adds the ID to a time-ordered ZSET.
+// Any lease held on the ID is released in the same transaction.
 func (s *RedisStore) ScheduleRetry(ctx context.Context, notifID string, nextRetry time.Time, lastErr string) error {
 	if notifID == "" {
 		return errors.New("sched retry: empty notifID")
 	}
 
+	pipe := s.rdb.TxPipeline()
 	// update error metadata and timestamp
-	if _, err := s.rdb.HSet(ctx, s.notifKey(notifID),
+	pipe.HSet(ctx, s.notifKey(notifID),
 		"error", lastErr,
 		"updated_at", time.Now().Unix(),
-	).Result(); err != nil {
-		return fmt.Errorf("schedule retry: hset metadata: %w", err)
-	}
-
-	score := float64(nextRetry.Unix())
-	if _, err := s.rdb.ZAdd(ctx, retryZSet, redis.Z{
-		Score:  score,
+	)
+	pipe.ZAdd(ctx, retryZSet, redis.Z{
+		Score:  float64(nextRetry.Unix()),
 		Member: notifID,
-	}).Result(); err != nil {
-		return fmt.Errorf("schedule retry: zadd: %w", err)
+	})
+	pipe.ZRem(ctx, inflightZSet, notifID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("schedule retry: %w", err)
 	}
 	return nil
 }
@@ -150,12 +306,37 @@ func (s *RedisStore) GetDueRetries(ctx context.Context, before time.Time, limit
 	return ids, nil
 }
 
+// ClaimDueRetries leases due IDs to the caller via claimScript.
+func (s *RedisStore) ClaimDueRetries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]string, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	ids, err := claimScript.Run(ctx, s.rdb, []string{retryZSet, inflightZSet},
+		now.Unix(), now.Add(lease).Unix(), limit).StringSlice()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("claim due retries: %w", err)
+	}
+	return ids, nil
+}
+
+// ReapExpiredLeases puts IDs whose lease expired back on the retry queue.
+func (s *RedisStore) ReapExpiredLeases(ctx context.Context, now time.Time) (int, error) {
+	n, err := reapScript.Run(ctx, s.rdb, []string{retryZSet, inflightZSet}, now.Unix(), 1000).Int()
+	if err != nil {
+		return 0, fmt.Errorf("reap expired leases: %w", err)
+	}
+	return n, nil
+}
+
 // RemoveFromRetryQueue removes an ID after success or max attempts
 func (s *RedisStore) RemoveFromRetryQueue(ctx context.Context, notifID string) error {
 	if notifID == "" {
 		return errors.New("remove retry: empty notifID")
 	}
-	if _, err := s.rdb.ZRem(ctx, retryZSet, notifID).Result(); err != nil {
+	pipe := s.rdb.TxPipeline()
+	pipe.ZRem(ctx, retryZSet, notifID)
+	pipe.ZRem(ctx, inflightZSet, notifID)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("zrem: %w", err)
 	}
 	return nil
@@ -163,24 +344,98 @@ func (s *RedisStore) RemoveFromRetryQueue(ctx context.Context, notifID string) e
 
 // GetNotification fetches a notification struct from Redis (parses attempts and max_retries)
 func (s *RedisStore) GetNotification(ctx context.Context, id string) (*models.Notification, error) {
-	notif := &models.Notification{}
-
 	key := s.notifKey(id)
 	result, err := s.rdb.HGetAll(ctx, key).Result()
 	if err != nil {
 		return nil, fmt.Errorf("hgetall: %w", err)
 	}
 	if len(result) == 0 {
-		return nil, errors.New("notification not found")
+		return nil, fmt.Errorf("notification %s: %w", id, storage.ErrNotFound)
 	}
+	return parseNotification(id, result), nil
+}
+
+// ListNotificationsByEvent pages through the event's index and loads each hash.
+func (s *RedisStore) ListNotificationsByEvent(ctx context.Context, eventID string, offset, limit int) ([]models.Notification, int, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	idxKey := s.eventNotifsKey(eventID)
+
+	total, err := s.rdb.ZCard(ctx, idxKey).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("list by event: zcard: %w", err)
+	}
+	ids, err := s.rdb.ZRange(ctx, idxKey, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("list by event: zrange: %w", err)
+	}
+
+	pipe := s.rdb.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, s.notifKey(id))
+	}
+	if len(ids) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, 0, fmt.Errorf("list by event: hgetall: %w", err)
+		}
+	}
+
+	notifs := make([]models.Notification, 0, len(ids))
+	for i, cmd := range cmds {
+		if result := cmd.Val(); len(result) > 0 {
+			notifs = append(notifs, *parseNotification(ids[i], result))
+		}
+	}
+	return notifs, int(total), nil
+}
+
+func (s *RedisStore) LatestNotificationTo(ctx context.Context, channel, recipient string) (*models.Notification, error) {
+	ids, err := s.rdb.ZRevRange(ctx, s.recipientNotifsKey(channel, recipient), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("latest notification: %w", err)
+	}
+	// newest first; skip those not handed over yet, e.g. scheduled escalations
+	pipe := s.rdb.Pipeline()
+	statuses := make([]*redis.StringCmd, len(ids))
+	for i, id := range ids {
+		statuses[i] = pipe.HGet(ctx, s.notifKey(id), "status")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("latest notification: %w", err)
+	}
+	for i, id := range ids {
+		if slices.Contains(storage.HandedOver, statuses[i].Val()) {
+			return s.GetNotification(ctx, id)
+		}
+	}
+	return nil, fmt.Errorf("notification to %s via %s: %w", recipient, channel, storage.ErrNotFound)
+}
+
+// parseNotification builds a Notification from its Redis hash fields.
+func parseNotification(id string, result map[string]string) *models.Notification {
+	notif := &models.Notification{}
 
 	notif.ID = id
 	notif.EventID = result["event_id"]
 	notif.Recipient = result["recipient"]
+	notif.RecipientID = result["recipient_id"]
 	notif.Channel = result["channel"]
+	notif.Severity = result["severity"]
 	notif.Message = result["message"]
+	notif.Subject = result["subject"]
+	notif.HTMLBody = result["html_body"]
+	notif.Language = result["language"]
+	notif.SMSEncoding = result["sms_encoding"]
 	notif.Status = result["status"]
 	notif.Error = result["error"]
+	notif.LastError = result["last_error"]
+	notif.APIResponse = result["api_response"]
+	notif.ProviderMessageID = result["provider_id"]
 
 	// parse created_at into Timestamp
 	if ts, ok := result["created_at"]; ok {
@@ -188,6 +443,11 @@ func (s *RedisStore) GetNotification(ctx context.Context, id string) (*models.No
 			notif.Timestamp = time.Unix(t, 0)
 		}
 	}
+	if ts, ok := result["updated_at"]; ok {
+		if t, err := strconv.ParseInt(ts, 10, 64); err == nil {
+			notif.UpdatedAt = time.Unix(t, 0)
+		}
+	}
 
 	// parse attempts and max_retries (optional)
 	if v, ok := result["attempts"]; ok {
@@ -200,45 +460,76 @@ func (s *RedisStore) GetNotification(ctx context.Context, id string) (*models.No
 			notif.MaxRetries = ai
 		}
 	}
+	if v, ok := result["expires_at"]; ok {
+		if t, err := strconv.ParseInt(v, 10, 64); err == nil {
+			exp := time.Unix(t, 0)
+			notif.ExpiresAt = &exp
+		}
+	}
+	if v, ok := result["attachments"]; ok {
+		_ = json.Unmarshal([]byte(v), &notif.Attachments)
+	}
+	if v, ok := result["sms_segments"]; ok {
+		if ai, err := strconv.Atoi(v); err == nil {
+			notif.SMSSegments = ai
+		}
+	}
+	if v, ok := result["api_code"]; ok {
+		if ai, err := strconv.Atoi(v); err == nil {
+			notif.APIStatusCode = ai
+		}
+	}
 
-	return notif, nil
+	return notif
 }
 
 // IncrementAttempts increments attempts and records last_error + last_attempt_at.
 // Returns new attempts count and any error.
-func (s *RedisStore) IncrementAttempts(ctx context.Context, id string, lastError string) (int, error) {
-	key := s.notifKey(id)
+// incrementScript counts an attempt of an existing notification. KEYS:
+// notification hash. ARGV: last error, attempted at. Returns the new count,
+// or -1 if the notification does not exist.
+var incrementScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+local n = redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+redis.call('HSET', KEYS[1], 'last_error', ARGV[1], 'last_attempt_at', ARGV[2])
+return n
+`)
 
-	pipe := s.rdb.TxPipeline()
-	incr := pipe.HIncrBy(ctx, key, "attempts", 1)
-	pipe.HSet(ctx, key, "last_error", lastError)
-	pipe.HSet(ctx, key, "last_attempt_at", time.Now().Unix())
-	_, err := pipe.Exec(ctx)
+func (s *RedisStore) IncrementAttempts(ctx context.Context, id string, lastError string) (int, error) {
+	n, err := incrementScript.Run(ctx, s.rdb, []string{s.notifKey(id)}, lastError, time.Now().Unix()).Int()
 	if err != nil {
-		return 0, fmt.Errorf("increment attempts exec: %w", err)
+		return 0, fmt.Errorf("increment attempts: %w", err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("increment attempts: notification %s: %w", id, storage.ErrNotFound)
 	}
-	attempts, _ := incr.Result()
-	return int(attempts), nil
+	return n, nil
 }
 
 func (s *RedisStore) UpdateAPIResponse(ctx context.Context, id string, statusCode int, body string) error {
 	if id == "" {
 		return fmt.Errorf("update api response: empty id")
 	}
-	key := s.notifKey(id)
-
 	// Keep body short to avoid huge storage; trim to 1000 chars for safety
 	if len(body) > 1000 {
 		body = body[:1000]
 	}
 
-	_, err := s.rdb.HSet(ctx, key,
+	if err := s.updateNotification(ctx, id,
 		"api_code", statusCode,
 		"api_response", body,
 		"updated_at", time.Now().Unix(),
-	).Result()
-	if err != nil {
-		return fmt.Errorf("hset api response: %w", err)
+	); err != nil {
+		return fmt.Errorf("update api response: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) SetProviderMessageID(ctx context.Context, id string, providerID string) error {
+	if err := s.updateNotification(ctx, id, "provider_id", providerID, "updated_at", time.Now().Unix()); err != nil {
+		return fmt.Errorf("set provider message id: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,34 @@
+package redisstore
+
+import (
+	"context"
+	"testing"
+
+	"notification-service/internal/storage"
+	"notification-service/internal/storage/storagetest"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.RunNotificationStoreTests(t, func(t *testing.T) storage.NotificationStore {
+		return open(t)
+	})
+}
+
+func TestEventConformance(t *testing.T) {
+	storagetest.RunEventStoreTests(t, func(t *testing.T) storage.EventStore {
+		return open(t)
+	})
+}
+
+// open connects a store to a fresh in-process Redis.
+func open(t *testing.T) *RedisStore {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	s, err := NewRedisStore(context.Background(), Config{Addr: srv.Addr()})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	return s
+}
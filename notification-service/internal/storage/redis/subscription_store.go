@@ -0,0 +1,109 @@
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func (s *RedisStore) subscriptionKey(id string) string {
+	return "subscription:" + id
+}
+
+// recipientSubsKey indexes a recipient's subscription IDs by creation time.
+func (s *RedisStore) recipientSubsKey(recipientID string) string {
+	return "recipient_subscriptions:" + recipientID
+}
+
+// SaveSubscription stores the subscription and indexes it under its
+// recipient, moving it if the recipient changed.
+func (s *RedisStore) SaveSubscription(ctx context.Context, sub models.Subscription) error {
+	payload, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("save subscription: marshal: %w", err)
+	}
+	old, err := s.GetSubscription(ctx, sub.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("save subscription: %w", err)
+	}
+
+	pipe := s.rdb.TxPipeline()
+	if old != nil && old.RecipientID != sub.RecipientID {
+		pipe.ZRem(ctx, s.recipientSubsKey(old.RecipientID), sub.ID)
+	}
+	pipe.Set(ctx, s.subscriptionKey(sub.ID), payload, 0)
+	pipe.ZAdd(ctx, s.recipientSubsKey(sub.RecipientID), redis.Z{
+		Score:  float64(sub.CreatedAt.UnixMilli()),
+		Member: sub.ID,
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("save subscription: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) GetSubscription(ctx context.Context, id string) (*models.Subscription, error) {
+	payload, err := s.rdb.Get(ctx, s.subscriptionKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("subscription %s: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get subscription: %w", err)
+	}
+	var sub models.Subscription
+	if err := json.Unmarshal(payload, &sub); err != nil {
+		return nil, fmt.Errorf("get subscription: unmarshal: %w", err)
+	}
+	return &sub, nil
+}
+
+func (s *RedisStore) DeleteSubscription(ctx context.Context, id string) error {
+	sub, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return err
+	}
+	pipe := s.rdb.TxPipeline()
+	pipe.Del(ctx, s.subscriptionKey(id))
+	pipe.ZRem(ctx, s.recipientSubsKey(sub.RecipientID), id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("delete subscription: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) ListSubscriptions(ctx context.Context, recipientID string) ([]models.Subscription, error) {
+	ids, err := s.rdb.ZRange(ctx, s.recipientSubsKey(recipientID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list subscriptions: zrange: %w", err)
+	}
+	out := []models.Subscription{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+
+	pipe := s.rdb.Pipeline()
+	gets := make([]*redis.StringCmd, len(ids))
+	for i, id := range ids {
+		gets[i] = pipe.Get(ctx, s.subscriptionKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("list subscriptions: %w", err)
+	}
+	for _, get := range gets {
+		payload, err := get.Bytes()
+		if err != nil {
+			continue // deleted between ZRANGE and GET
+		}
+		var sub models.Subscription
+		if err := json.Unmarshal(payload, &sub); err != nil {
+			return nil, fmt.Errorf("list subscriptions: unmarshal: %w", err)
+		}
+		out = append(out, sub)
+	}
+	return out, nil
+}
@@ -0,0 +1,120 @@
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+	"sort"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// templatesSet holds the name of every stored template.
+const templatesSet = "templates"
+
+// templateKey is a hash of version number -> template JSON, plus a "latest"
+// field holding the newest version number.
+func (s *RedisStore) templateKey(name string) string {
+	return "template:" + name
+}
+
+// createTemplateScript assigns the next version and stores the template in
+// one step, so "latest" never points at a missing version.
+// KEYS: template hash, templates set. ARGV: payload, name.
+var createTemplateScript = redis.NewScript(`
+local v = redis.call('HINCRBY', KEYS[1], 'latest', 1)
+redis.call('HSET', KEYS[1], v, ARGV[1])
+redis.call('SADD', KEYS[2], ARGV[2])
+return v
+`)
+
+// CreateTemplateVersion stores t under the next version number; the version
+// is kept in the hash field, not in the JSON payload.
+func (s *RedisStore) CreateTemplateVersion(ctx context.Context, t models.Template) (int, error) {
+	t.Version = 0
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return 0, fmt.Errorf("create template: marshal: %w", err)
+	}
+	version, err := createTemplateScript.Run(ctx, s.rdb,
+		[]string{s.templateKey(t.Name), templatesSet}, payload, t.Name).Int()
+	if err != nil {
+		return 0, fmt.Errorf("create template: %w", err)
+	}
+	return version, nil
+}
+
+func (s *RedisStore) GetTemplate(ctx context.Context, name string, version int) (*models.Template, error) {
+	key := s.templateKey(name)
+	if version == 0 {
+		latest, err := s.rdb.HGet(ctx, key, "latest").Int()
+		if errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("template %s: %w", name, storage.ErrNotFound)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get template: %w", err)
+		}
+		version = latest
+	}
+
+	payload, err := s.rdb.HGet(ctx, key, strconv.Itoa(version)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("template %s v%d: %w", name, version, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get template: %w", err)
+	}
+	var t models.Template
+	if err := json.Unmarshal(payload, &t); err != nil {
+		return nil, fmt.Errorf("get template: unmarshal: %w", err)
+	}
+	t.Version = version
+	return &t, nil
+}
+
+func (s *RedisStore) ListTemplateVersions(ctx context.Context, name string) ([]models.Template, error) {
+	fields, err := s.rdb.HGetAll(ctx, s.templateKey(name)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list template versions: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("template %s: %w", name, storage.ErrNotFound)
+	}
+
+	out := []models.Template{}
+	for field, payload := range fields {
+		if field == "latest" {
+			continue
+		}
+		var t models.Template
+		if err := json.Unmarshal([]byte(payload), &t); err != nil {
+			return nil, fmt.Errorf("list template versions: unmarshal: %w", err)
+		}
+		t.Version, _ = strconv.Atoi(field)
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+func (s *RedisStore) ListTemplates(ctx context.Context) ([]models.Template, error) {
+	names, err := s.rdb.SMembers(ctx, templatesSet).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list templates: smembers: %w", err)
+	}
+	sort.Strings(names)
+
+	out := []models.Template{}
+	for _, name := range names {
+		t, err := s.GetTemplate(ctx, name, 0)
+		if err != nil {
+			return nil, fmt.Errorf("list templates: %w", err)
+		}
+		out = append(out, *t)
+	}
+	return out, nil
+}
@@ -0,0 +1,96 @@
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// webhooksZSet indexes webhook endpoint IDs by creation time.
+const webhooksZSet = "webhooks"
+
+func (s *RedisStore) webhookKey(id string) string {
+	return "webhook:" + id
+}
+
+func (s *RedisStore) SaveWebhook(ctx context.Context, w models.WebhookEndpoint) error {
+	payload, err := json.Marshal(w)
+	if err != nil {
+		return fmt.Errorf("save webhook: marshal: %w", err)
+	}
+	pipe := s.rdb.TxPipeline()
+	pipe.Set(ctx, s.webhookKey(w.ID), payload, 0)
+	pipe.ZAdd(ctx, webhooksZSet, redis.Z{
+		Score:  float64(w.CreatedAt.UnixMilli()),
+		Member: w.ID,
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("save webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) GetWebhook(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	payload, err := s.rdb.Get(ctx, s.webhookKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("webhook %s: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get webhook: %w", err)
+	}
+	var w models.WebhookEndpoint
+	if err := json.Unmarshal(payload, &w); err != nil {
+		return nil, fmt.Errorf("get webhook: unmarshal: %w", err)
+	}
+	return &w, nil
+}
+
+func (s *RedisStore) DeleteWebhook(ctx context.Context, id string) error {
+	pipe := s.rdb.TxPipeline()
+	del := pipe.Del(ctx, s.webhookKey(id))
+	pipe.ZRem(ctx, webhooksZSet, id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	if del.Val() == 0 {
+		return fmt.Errorf("webhook %s: %w", id, storage.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *RedisStore) ListWebhooks(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	ids, err := s.rdb.ZRange(ctx, webhooksZSet, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: zrange: %w", err)
+	}
+	out := []models.WebhookEndpoint{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+
+	pipe := s.rdb.Pipeline()
+	gets := make([]*redis.StringCmd, len(ids))
+	for i, id := range ids {
+		gets[i] = pipe.Get(ctx, s.webhookKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	for _, get := range gets {
+		payload, err := get.Bytes()
+		if err != nil {
+			continue // deleted between ZRANGE and GET
+		}
+		var w models.WebhookEndpoint
+		if err := json.Unmarshal(payload, &w); err != nil {
+			return nil, fmt.Errorf("list webhooks: unmarshal: %w", err)
+		}
+		out = append(out, w)
+	}
+	return out, nil
+}
@@ -0,0 +1,133 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+// SaveEvent upserts the event payload and status.
+func (s *SQLStore) SaveEvent(ctx context.Context, record models.EventRecord) error {
+	payload, err := json.Marshal(record.Event)
+	if err != nil {
+		return fmt.Errorf("save event: marshal: %w", err)
+	}
+	updated := record.UpdatedAt
+	if updated.IsZero() {
+		updated = record.AcceptedAt
+	}
+	if _, err := s.exec(ctx, `INSERT INTO events (id, type, severity, payload, status, accepted_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			type = excluded.type, severity = excluded.severity, payload = excluded.payload,
+			status = excluded.status, updated_at = excluded.updated_at`,
+		record.Event.ID, record.Event.Type, record.Event.Severity, string(payload), record.Status,
+		record.AcceptedAt.Unix(), updated.Unix()); err != nil {
+		return fmt.Errorf("save event: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) CreateEvent(ctx context.Context, record models.EventRecord) error {
+	payload, err := json.Marshal(record.Event)
+	if err != nil {
+		return fmt.Errorf("create event: marshal: %w", err)
+	}
+	updated := record.UpdatedAt
+	if updated.IsZero() {
+		updated = record.AcceptedAt
+	}
+	res, err := s.exec(ctx, `INSERT INTO events (id, type, severity, payload, status, accepted_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`,
+		record.Event.ID, record.Event.Type, record.Event.Severity, string(payload), record.Status,
+		record.AcceptedAt.Unix(), updated.Unix())
+	if err != nil {
+		return fmt.Errorf("create event: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("event %s: %w", record.Event.ID, storage.ErrExists)
+	}
+	return nil
+}
+
+func (s *SQLStore) GetEvent(ctx context.Context, id string) (*models.EventRecord, error) {
+	var payload string
+	var accepted, updated int64
+	record := &models.EventRecord{}
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT payload, status, accepted_at, updated_at FROM events WHERE id = ?`), id).
+		Scan(&payload, &record.Status, &accepted, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("event %s: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get event: %w", err)
+	}
+	if err := json.Unmarshal([]byte(payload), &record.Event); err != nil {
+		return nil, fmt.Errorf("get event: unmarshal: %w", err)
+	}
+	record.AcceptedAt = time.Unix(accepted, 0)
+	record.UpdatedAt = time.Unix(updated, 0)
+	return record, nil
+}
+
+func (s *SQLStore) UpdateEventStatus(ctx context.Context, id string, status string) error {
+	res, err := s.exec(ctx, `UPDATE events SET status = ?, updated_at = ? WHERE id = ?`, status, time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("update event status: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("event %s: %w", id, storage.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *SQLStore) TransitionEventStatus(ctx context.Context, id string, from []string, status string) (bool, error) {
+	if len(from) == 0 {
+		return false, nil
+	}
+	args := []any{status, time.Now().Unix(), id}
+	for _, f := range from {
+		args = append(args, f)
+	}
+	res, err := s.exec(ctx, `UPDATE events SET status = ?, updated_at = ?
+		WHERE id = ? AND status IN (?`+strings.Repeat(", ?", len(from)-1)+`)`, args...)
+	if err != nil {
+		return false, fmt.Errorf("transition event status: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		return true, nil
+	}
+	var exists int
+	err = s.db.QueryRowContext(ctx, s.rebind(`SELECT 1 FROM events WHERE id = ?`), id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("transition event status: event %s: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return false, fmt.Errorf("transition event status: %w", err)
+	}
+	return false, nil
+}
+
+func (s *SQLStore) UpdateEventContent(ctx context.Context, event models.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("update event: marshal: %w", err)
+	}
+	res, err := s.exec(ctx, `UPDATE events SET type = ?, severity = ?, payload = ?, updated_at = ? WHERE id = ?`,
+		event.Type, event.Severity, string(payload), time.Now().Unix(), event.ID)
+	if err != nil {
+		return fmt.Errorf("update event: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("event %s: %w", event.ID, storage.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// migration is one forward-only schema change. The DDL is written to run
+// unchanged on both SQLite and PostgreSQL.
+type migration struct {
+	version int
+	name    string
+	stmts   []string
+}
+
+// migrations must only ever be appended to; applied versions are recorded
+// in schema_migrations and skipped on the next start.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create notifications",
+		stmts: []string{
+			`CREATE TABLE notifications (
+				id              TEXT PRIMARY KEY,
+				event_id        TEXT    NOT NULL DEFAULT '',
+				recipient       TEXT    NOT NULL DEFAULT '',
+				channel         TEXT    NOT NULL DEFAULT '',
+				message         TEXT    NOT NULL DEFAULT '',
+				status          TEXT    NOT NULL DEFAULT '',
+				error           TEXT    NOT NULL DEFAULT '',
+				last_error      TEXT    NOT NULL DEFAULT '',
+				attempts        INTEGER NOT NULL DEFAULT 0,
+				max_retries     INTEGER NOT NULL DEFAULT 0,
+				api_code        INTEGER NOT NULL DEFAULT 0,
+				api_response    TEXT    NOT NULL DEFAULT '',
+				created_at      BIGINT  NOT NULL,
+				updated_at      BIGINT  NOT NULL,
+				last_attempt_at BIGINT,
+				next_retry_at   BIGINT,
+				lease_until     BIGINT
+			)`,
+			`CREATE INDEX idx_notifications_event ON notifications (event_id, created_at, id)`,
+			`CREATE INDEX idx_notifications_recipient ON notifications (recipient)`,
+			`CREATE INDEX idx_notifications_status ON notifications (status)`,
+			`CREATE INDEX idx_notifications_next_retry ON notifications (next_retry_at) WHERE next_retry_at IS NOT NULL`,
+			`CREATE INDEX idx_notifications_lease ON notifications (lease_until) WHERE lease_until IS NOT NULL`,
+		},
+	},
+	{
+		version: 2,
+		name:    "create events",
+		stmts: []string{
+			`CREATE TABLE events (
+				id          TEXT PRIMARY KEY,
+				type        TEXT   NOT NULL DEFAULT '',
+				severity    TEXT   NOT NULL DEFAULT '',
+				payload     TEXT   NOT NULL,
+				status      TEXT   NOT NULL DEFAULT '',
+				accepted_at BIGINT NOT NULL,
+				updated_at  BIGINT NOT NULL
+			)`,
+			`CREATE INDEX idx_events_accepted ON events (accepted_at)`,
+		},
+	},
+	{
+		version: 3,
+		name:    "add notification expiry",
+		stmts: []string{
+			`ALTER TABLE notifications ADD COLUMN expires_at BIGINT`,
+		},
+	},
+	{
+		version: 4,
+		name:    "add notification recipient id",
+		stmts: []string{
+			`ALTER TABLE notifications ADD COLUMN recipient_id TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX idx_notifications_recipient_id ON notifications (recipient_id)`,
+		},
+	},
+	{
+		version: 5,
+		name:    "add rendered notification content",
+		stmts: []string{
+			`ALTER TABLE notifications ADD COLUMN subject TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE notifications ADD COLUMN html_body TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 6,
+		name:    "add notification language",
+		stmts: []string{
+			`ALTER TABLE notifications ADD COLUMN language TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 7,
+		name:    "add sms segment count",
+		stmts: []string{
+			`ALTER TABLE notifications ADD COLUMN sms_encoding TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE notifications ADD COLUMN sms_segments INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version: 8,
+		name:    "add email attachments",
+		stmts: []string{
+			`ALTER TABLE notifications ADD COLUMN attachments TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 9,
+		name:    "add notification severity",
+		stmts: []string{
+			`ALTER TABLE notifications ADD COLUMN severity TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 10,
+		name:    "add provider message id",
+		stmts: []string{
+			`ALTER TABLE notifications ADD COLUMN provider_id TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+}
+
+// migrate applies every migration newer than the recorded schema version,
+// each in its own transaction.
+func (s *SQLStore) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT   NOT NULL,
+		applied_at BIGINT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("migrate: create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("migrate: read version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := s.applyMigration(ctx, m); err != nil {
+			return fmt.Errorf("migrate: v%d %s: %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLStore) applyMigration(ctx context.Context, m migration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.stmts {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`),
+		m.version, m.name, time.Now().Unix()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
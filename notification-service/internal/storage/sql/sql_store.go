@@ -0,0 +1,461 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+// SQLStore keeps notifications and events in SQLite (local/dev) or PostgreSQL
+// (production) so delivery history stays queryable long after dispatch.
+type SQLStore struct {
+	db      *sql.DB
+	dialect string
+}
+
+// Config holds the database connection settings.
+type Config struct {
+	// Dialect is "sqlite" or "postgres".
+	Dialect string
+	// DSN is a file path / sqlite URI, or a postgres:// connection URL.
+	DSN string
+}
+
+// NewSQLStore opens the database, verifies the connection and applies any
+// pending schema migrations.
+func NewSQLStore(ctx context.Context, cfg Config) (*SQLStore, error) {
+	if cfg.DSN == "" {
+		return nil, errors.New("database DSN is empty")
+	}
+
+	var driver string
+	switch cfg.Dialect {
+	case "sqlite":
+		driver = "sqlite"
+	case "postgres":
+		driver = "pgx"
+	default:
+		return nil, fmt.Errorf("unsupported SQL dialect %q", cfg.Dialect)
+	}
+
+	db, err := sql.Open(driver, cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("sql open: %w", err)
+	}
+	if cfg.Dialect == "sqlite" {
+		// SQLite allows a single writer; one connection avoids SQLITE_BUSY
+		// and keeps ":memory:" databases shared across calls.
+		db.SetMaxOpenConns(1)
+		if _, err := db.ExecContext(ctx, `PRAGMA busy_timeout = 5000`); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("sqlite pragma: %w", err)
+		}
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sql ping failed: %w", err)
+	}
+
+	s := &SQLStore{db: db, dialect: cfg.Dialect}
+	if err := s.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SQLStore) Close(ctx context.Context) error {
+	return s.db.Close()
+}
+
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// rebind rewrites ? placeholders to $1, $2, ... for PostgreSQL.
+func (s *SQLStore) rebind(query string) string {
+	if s.dialect != "postgres" {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *SQLStore) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return s.db.ExecContext(ctx, s.rebind(query), args...)
+}
+
+// execOne runs an UPDATE that must match exactly one existing row.
+func (s *SQLStore) execOne(ctx context.Context, id, query string, args ...any) error {
+	res, err := s.exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("notification %s: %w", id, storage.ErrNotFound)
+	}
+	return nil
+}
+
+const notifColumns = `id, event_id, recipient, recipient_id, channel, severity,
+	message, subject, html_body, language, sms_encoding, sms_segments, attachments,
+	status, error, attempts, max_retries, api_code, api_response, provider_id, last_error, created_at, updated_at, expires_at`
+
+// SaveNotification inserts the notification or updates it in place; attempts
+// and max_retries are only overwritten when set, as in the Redis store.
+func (s *SQLStore) SaveNotification(ctx context.Context, notif models.Notification) error {
+	ts := notif.Timestamp.Unix()
+	attachments := ""
+	if len(notif.Attachments) > 0 {
+		b, err := json.Marshal(notif.Attachments)
+		if err != nil {
+			return fmt.Errorf("save notification: marshal attachments: %w", err)
+		}
+		attachments = string(b)
+	}
+	_, err := s.exec(ctx, `INSERT INTO notifications (`+notifColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, '', '', '', ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			event_id     = excluded.event_id,
+			recipient    = excluded.recipient,
+			recipient_id = excluded.recipient_id,
+			channel      = excluded.channel,
+			severity     = excluded.severity,
+			message      = excluded.message,
+			subject      = excluded.subject,
+			html_body    = excluded.html_body,
+			language     = excluded.language,
+			sms_encoding = excluded.sms_encoding,
+			sms_segments = excluded.sms_segments,
+			attachments  = excluded.attachments,
+			status       = excluded.status,
+			error        = excluded.error,
+			attempts     = CASE WHEN excluded.attempts > 0 THEN excluded.attempts ELSE notifications.attempts END,
+			max_retries  = CASE WHEN excluded.max_retries > 0 THEN excluded.max_retries ELSE notifications.max_retries END,
+			expires_at   = excluded.expires_at,
+			updated_at   = excluded.updated_at`,
+		notif.ID, notif.EventID, notif.Recipient, notif.RecipientID, notif.Channel, notif.Severity,
+		notif.Message, notif.Subject, notif.HTMLBody, notif.Language, notif.SMSEncoding, notif.SMSSegments, attachments,
+		notif.Status, notif.Error, notif.Attempts, notif.MaxRetries, ts, ts, nullableUnix(notif.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("save notification: %w", err)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanNotification(row rowScanner) (*models.Notification, error) {
+	var n models.Notification
+	var created, updated int64
+	var expires sql.NullInt64
+	var attachments string
+	if err := row.Scan(&n.ID, &n.EventID, &n.Recipient, &n.RecipientID, &n.Channel, &n.Severity,
+		&n.Message, &n.Subject, &n.HTMLBody, &n.Language, &n.SMSEncoding, &n.SMSSegments, &attachments,
+		&n.Status, &n.Error, &n.Attempts, &n.MaxRetries, &n.APIStatusCode, &n.APIResponse, &n.ProviderMessageID, &n.LastError, &created, &updated, &expires); err != nil {
+		return nil, err
+	}
+	n.Timestamp = time.Unix(created, 0)
+	n.UpdatedAt = time.Unix(updated, 0)
+	if attachments != "" {
+		if err := json.Unmarshal([]byte(attachments), &n.Attachments); err != nil {
+			return nil, fmt.Errorf("unmarshal attachments: %w", err)
+		}
+	}
+	if expires.Valid {
+		exp := time.Unix(expires.Int64, 0)
+		n.ExpiresAt = &exp
+	}
+	return &n, nil
+}
+
+// nullableUnix maps an optional time to a nullable Unix-seconds column.
+func nullableUnix(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.Unix()
+}
+
+func (s *SQLStore) GetNotification(ctx context.Context, id string) (*models.Notification, error) {
+	row := s.db.QueryRowContext(ctx, s.rebind(`SELECT `+notifColumns+` FROM notifications WHERE id = ?`), id)
+	n, err := scanNotification(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("notification %s: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get notification: %w", err)
+	}
+	return n, nil
+}
+
+func (s *SQLStore) LatestNotificationTo(ctx context.Context, channel, recipient string) (*models.Notification, error) {
+	args := []any{recipient, channel}
+	for _, status := range storage.HandedOver {
+		args = append(args, status)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(storage.HandedOver)), ", ")
+	row := s.db.QueryRowContext(ctx, s.rebind(`SELECT `+notifColumns+` FROM notifications
+		WHERE recipient = ? AND channel = ? AND status IN (`+placeholders+`)
+		ORDER BY created_at DESC, id DESC LIMIT 1`), args...)
+	n, err := scanNotification(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("notification to %s via %s: %w", recipient, channel, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("latest notification: %w", err)
+	}
+	return n, nil
+}
+
+func (s *SQLStore) ListNotificationsByEvent(ctx context.Context, eventID string, offset, limit int) ([]models.Notification, int, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, s.rebind(`SELECT COUNT(*) FROM notifications WHERE event_id = ?`), eventID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("list by event: count: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT `+notifColumns+` FROM notifications
+		WHERE event_id = ? ORDER BY created_at, id LIMIT ? OFFSET ?`), eventID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list by event: %w", err)
+	}
+	defer rows.Close()
+
+	notifs := []models.Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("list by event: scan: %w", err)
+		}
+		notifs = append(notifs, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("list by event: %w", err)
+	}
+	return notifs, total, nil
+}
+
+func (s *SQLStore) UpdateNotificationStatus(ctx context.Context, id string, status string, errMsg string) error {
+	if err := s.execOne(ctx, id, `UPDATE notifications SET status = ?, error = ?, updated_at = ? WHERE id = ?`,
+		status, errMsg, time.Now().Unix(), id); err != nil {
+		return fmt.Errorf("update status: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) TransitionNotificationStatus(ctx context.Context, id string, from []string, status string, errMsg string) (bool, error) {
+	if len(from) == 0 {
+		return false, nil
+	}
+	args := []any{status, errMsg, time.Now().Unix(), id}
+	for _, f := range from {
+		args = append(args, f)
+	}
+	res, err := s.exec(ctx, `UPDATE notifications SET status = ?, error = ?, updated_at = ?
+		WHERE id = ? AND status IN (?`+strings.Repeat(", ?", len(from)-1)+`)`, args...)
+	if err != nil {
+		return false, fmt.Errorf("transition status: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		return true, nil
+	}
+	var exists int
+	err = s.db.QueryRowContext(ctx, s.rebind(`SELECT 1 FROM notifications WHERE id = ?`), id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("transition status: notification %s: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return false, fmt.Errorf("transition status: %w", err)
+	}
+	return false, nil
+}
+
+func (s *SQLStore) UpdateNotificationContent(ctx context.Context, notif models.Notification) error {
+	if err := s.execOne(ctx, notif.ID, `UPDATE notifications
+		SET message = ?, subject = ?, html_body = ?, language = ?, sms_encoding = ?, sms_segments = ?, updated_at = ?
+		WHERE id = ?`,
+		notif.Message, notif.Subject, notif.HTMLBody, notif.Language, notif.SMSEncoding, notif.SMSSegments,
+		time.Now().Unix(), notif.ID); err != nil {
+		return fmt.Errorf("update content: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) IncrementAttempts(ctx context.Context, id string, lastError string) (int, error) {
+	var attempts int
+	err := s.db.QueryRowContext(ctx, s.rebind(`UPDATE notifications
+		SET attempts = attempts + 1, last_error = ?, last_attempt_at = ?
+		WHERE id = ? RETURNING attempts`), lastError, time.Now().Unix(), id).Scan(&attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("increment attempts: notification %s: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("increment attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// The retry queue lives in two columns: next_retry_at marks a queued retry,
+// lease_until marks one claimed by a worker.
+
+func (s *SQLStore) ScheduleRetry(ctx context.Context, notifID string, nextRetry time.Time, lastErr string) error {
+	if notifID == "" {
+		return errors.New("sched retry: empty notifID")
+	}
+	if err := s.execOne(ctx, notifID, `UPDATE notifications
+		SET next_retry_at = ?, lease_until = NULL, error = ?, updated_at = ?
+		WHERE id = ?`, nextRetry.Unix(), lastErr, time.Now().Unix(), notifID); err != nil {
+		return fmt.Errorf("schedule retry: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) GetDueRetries(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT id FROM notifications
+		WHERE next_retry_at <= ? AND lease_until IS NULL
+		ORDER BY next_retry_at, id LIMIT ?`), before.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("get due retries: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("get due retries: scan: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ClaimDueRetries leases due rows in a single UPDATE. On PostgreSQL the
+// inner SELECT uses FOR UPDATE SKIP LOCKED so concurrent claimers pass over
+// each other's rows instead of blocking; SQLite serialises writers anyway.
+func (s *SQLStore) ClaimDueRetries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]string, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	lock := ""
+	if s.dialect == "postgres" {
+		lock = " FOR UPDATE SKIP LOCKED"
+	}
+	rows, err := s.db.QueryContext(ctx, s.rebind(`UPDATE notifications SET lease_until = ?
+		WHERE id IN (
+			SELECT id FROM notifications
+			WHERE next_retry_at <= ? AND lease_until IS NULL
+			ORDER BY next_retry_at, id LIMIT ?`+lock+`
+		)
+		RETURNING id, next_retry_at`), now.Add(lease).Unix(), now.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim due retries: %w", err)
+	}
+	defer rows.Close()
+
+	type claimed struct {
+		id  string
+		due int64
+	}
+	var out []claimed
+	for rows.Next() {
+		var c claimed
+		if err := rows.Scan(&c.id, &c.due); err != nil {
+			return nil, fmt.Errorf("claim due retries: scan: %w", err)
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim due retries: %w", err)
+	}
+
+	// RETURNING order is unspecified; hand IDs out earliest-due first
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].due == out[j].due {
+			return out[i].id < out[j].id
+		}
+		return out[i].due < out[j].due
+	})
+	ids := make([]string, len(out))
+	for i, c := range out {
+		ids[i] = c.id
+	}
+	return ids, nil
+}
+
+func (s *SQLStore) ReapExpiredLeases(ctx context.Context, now time.Time) (int, error) {
+	res, err := s.exec(ctx, `UPDATE notifications SET lease_until = NULL, next_retry_at = ?
+		WHERE lease_until <= ?`, now.Unix(), now.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("reap expired leases: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func (s *SQLStore) RemoveFromRetryQueue(ctx context.Context, notifID string) error {
+	if notifID == "" {
+		return errors.New("remove retry: empty notifID")
+	}
+	if _, err := s.exec(ctx, `UPDATE notifications SET next_retry_at = NULL, lease_until = NULL WHERE id = ?`, notifID); err != nil {
+		return fmt.Errorf("remove retry: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) UpdateAPIResponse(ctx context.Context, id string, statusCode int, body string) error {
+	if id == "" {
+		return fmt.Errorf("update api response: empty id")
+	}
+	// Keep body short to avoid huge storage; trim to 1000 chars for safety
+	if len(body) > 1000 {
+		body = body[:1000]
+	}
+	if err := s.execOne(ctx, id, `UPDATE notifications SET api_code = ?, api_response = ?, updated_at = ? WHERE id = ?`,
+		statusCode, body, time.Now().Unix(), id); err != nil {
+		return fmt.Errorf("update api response: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) SetProviderMessageID(ctx context.Context, id string, providerID string) error {
+	if err := s.execOne(ctx, id, `UPDATE notifications SET provider_id = ?, updated_at = ? WHERE id = ?`,
+		providerID, time.Now().Unix(), id); err != nil {
+		return fmt.Errorf("set provider message id: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package sqlstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"notification-service/internal/storage"
+	"notification-service/internal/storage/storagetest"
+)
+
+func TestConformanceSQLite(t *testing.T) {
+	storagetest.RunNotificationStoreTests(t, func(t *testing.T) storage.NotificationStore {
+		dsn := "file:" + filepath.Join(t.TempDir(), "notifications.db")
+		// open twice so the suite also checks migrations are idempotent
+		s := open(t, Config{Dialect: "sqlite", DSN: dsn})
+		_ = s.Close(context.Background())
+		return open(t, Config{Dialect: "sqlite", DSN: dsn})
+	})
+}
+
+// TestConformancePostgres runs against the database in POSTGRES_TEST_URL,
+// which it empties first.
+func TestConformancePostgres(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_URL")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_URL not set")
+	}
+	storagetest.RunNotificationStoreTests(t, func(t *testing.T) storage.NotificationStore {
+		s := open(t, Config{Dialect: "postgres", DSN: dsn})
+		if _, err := s.db.ExecContext(context.Background(), "TRUNCATE notifications, events"); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return s
+	})
+}
+
+func TestEventConformanceSQLite(t *testing.T) {
+	storagetest.RunEventStoreTests(t, func(t *testing.T) storage.EventStore {
+		return open(t, Config{Dialect: "sqlite", DSN: "file:" + filepath.Join(t.TempDir(), "notifications.db")})
+	})
+}
+
+func TestEventConformancePostgres(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_URL")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_URL not set")
+	}
+	storagetest.RunEventStoreTests(t, func(t *testing.T) storage.EventStore {
+		s := open(t, Config{Dialect: "postgres", DSN: dsn})
+		if _, err := s.db.ExecContext(context.Background(), "TRUNCATE notifications, events"); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return s
+	})
+}
+
+func open(t *testing.T, cfg Config) *SQLStore {
+	t.Helper()
+	s, err := NewSQLStore(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	return s
+}
@@ -0,0 +1,143 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+// EventStoreFactory returns a fresh, empty event store for one subtest.
+type EventStoreFactory func(t *testing.T) storage.EventStore
+
+// RunEventStoreTests runs the event store suite against stores from newStore.
+func RunEventStoreTests(t *testing.T, newStore EventStoreFactory) {
+	tests := []struct {
+		name string
+		fn   func(*testing.T, storage.EventStore)
+	}{
+		{"SaveAndGet", testSaveAndGetEvent},
+		{"CreateRejectsTakenID", testCreateEventRejectsTakenID},
+		{"UpdateStatus", testUpdateEventStatus},
+		{"TransitionStatus", testTransitionEventStatus},
+		{"UpdateContentKeepsStatus", testUpdateEventContentKeepsStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func eventRecord(id, status string) models.EventRecord {
+	return models.EventRecord{
+		Event:      models.Event{ID: id, Type: "cyclone", Title: "Cyclone warning", Severity: "severe", Channels: []string{"sms"}},
+		Status:     status,
+		AcceptedAt: base,
+	}
+}
+
+func testSaveAndGetEvent(t *testing.T, s storage.EventStore) {
+	ctx := context.Background()
+	if err := s.SaveEvent(ctx, eventRecord("e1", "queued")); err != nil {
+		t.Fatalf("SaveEvent: %v", err)
+	}
+	got, err := s.GetEvent(ctx, "e1")
+	if err != nil {
+		t.Fatalf("GetEvent: %v", err)
+	}
+	if got.Event.Title != "Cyclone warning" || got.Status != "queued" || !got.AcceptedAt.Equal(base) {
+		t.Errorf("GetEvent = %+v", got)
+	}
+	if _, err := s.GetEvent(ctx, "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetEvent(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func testCreateEventRejectsTakenID(t *testing.T, s storage.EventStore) {
+	ctx := context.Background()
+	if err := s.CreateEvent(ctx, eventRecord("e1", "queued")); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	again := eventRecord("e1", "queued")
+	again.Event.Title = "Different alert, same ID"
+	if err := s.CreateEvent(ctx, again); !errors.Is(err, storage.ErrExists) {
+		t.Errorf("CreateEvent(taken ID) error = %v, want ErrExists", err)
+	}
+	if got, err := s.GetEvent(ctx, "e1"); err != nil || got.Event.Title != "Cyclone warning" {
+		t.Errorf("GetEvent = %+v, %v, want the first event kept", got, err)
+	}
+}
+
+func testUpdateEventStatus(t *testing.T, s storage.EventStore) {
+	ctx := context.Background()
+	if err := s.CreateEvent(ctx, eventRecord("e1", "queued")); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	before := time.Now().Add(-time.Second)
+	if err := s.UpdateEventStatus(ctx, "e1", "cancelled"); err != nil {
+		t.Fatalf("UpdateEventStatus: %v", err)
+	}
+	got, err := s.GetEvent(ctx, "e1")
+	if err != nil {
+		t.Fatalf("GetEvent: %v", err)
+	}
+	if got.Status != "cancelled" || got.UpdatedAt.Before(before) {
+		t.Errorf("status/updated = %s/%v, want cancelled and a fresh update time", got.Status, got.UpdatedAt)
+	}
+}
+
+func testTransitionEventStatus(t *testing.T, s storage.EventStore) {
+	ctx := context.Background()
+	if err := s.CreateEvent(ctx, eventRecord("e1", "queued")); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	ok, err := s.TransitionEventStatus(ctx, "e1", []string{"queued", "processing"}, "processing")
+	if err != nil || !ok {
+		t.Fatalf("TransitionEventStatus(queued -> processing) = %v, %v", ok, err)
+	}
+	if err := s.UpdateEventStatus(ctx, "e1", "cancelled"); err != nil {
+		t.Fatalf("UpdateEventStatus: %v", err)
+	}
+	ok, err = s.TransitionEventStatus(ctx, "e1", []string{"processing"}, "dispatched")
+	if err != nil || ok {
+		t.Errorf("TransitionEventStatus(cancelled -> dispatched) = %v, %v, want refused", ok, err)
+	}
+	if got, err := s.GetEvent(ctx, "e1"); err != nil || got.Status != "cancelled" {
+		t.Errorf("GetEvent = %+v, %v, want cancelled kept", got, err)
+	}
+	if _, err := s.TransitionEventStatus(ctx, "missing", []string{"queued"}, "processing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("TransitionEventStatus(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func testUpdateEventContentKeepsStatus(t *testing.T, s storage.EventStore) {
+	ctx := context.Background()
+	record := eventRecord("e1", "processing")
+	if err := s.CreateEvent(ctx, record); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if err := s.UpdateEventStatus(ctx, "e1", "cancelled"); err != nil {
+		t.Fatalf("UpdateEventStatus: %v", err)
+	}
+	ev := record.Event
+	ev.Message = "Cyclone now expected at dawn"
+	if err := s.UpdateEventContent(ctx, ev); err != nil {
+		t.Fatalf("UpdateEventContent: %v", err)
+	}
+	got, err := s.GetEvent(ctx, "e1")
+	if err != nil {
+		t.Fatalf("GetEvent: %v", err)
+	}
+	if got.Event.Message != ev.Message || got.Status != "cancelled" {
+		t.Errorf("GetEvent = %+v, want the new message and the cancel kept", got)
+	}
+	missing := ev
+	missing.ID = "missing"
+	if err := s.UpdateEventContent(ctx, missing); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("UpdateEventContent(missing) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,494 @@
+// Package storagetest is a conformance suite for storage.NotificationStore
+// and storage.EventStore. Every implementation should pass it from its own
+// test file:
+//
+//	func TestConformance(t *testing.T) {
+//		storagetest.RunNotificationStoreTests(t, func(t *testing.T) storage.NotificationStore {
+//			return memorystore.New()
+//		})
+//	}
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+// Factory returns a fresh, empty store for one subtest.
+type Factory func(t *testing.T) storage.NotificationStore
+
+// RunNotificationStoreTests runs the whole suite against stores from newStore.
+func RunNotificationStoreTests(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s storage.NotificationStore)
+	}{
+		{"SaveAndGet", testSaveAndGet},
+		{"GetMissing", testGetMissing},
+		{"UpdateMissing", testUpdateMissing},
+		{"UpdateStatus", testUpdateStatus},
+		{"TransitionStatus", testTransitionStatus},
+		{"UpdateContent", testUpdateContent},
+		{"Resave", testResave},
+		{"IncrementAttempts", testIncrementAttempts},
+		{"RetryOrdering", testRetryOrdering},
+		{"RetryReschedule", testRetryReschedule},
+		{"ClaimAndLease", testClaimAndLease},
+		{"UpdateAPIResponse", testUpdateAPIResponse},
+		{"ProviderMessageID", testProviderMessageID},
+		{"LatestNotificationTo", testLatestNotificationTo},
+		{"ListByEvent", testListByEvent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStore(t)
+			t.Cleanup(func() { _ = s.Close(context.Background()) })
+			tt.fn(t, s)
+		})
+	}
+}
+
+// base is truncated to whole seconds since stores may persist Unix seconds.
+var base = time.Now().Truncate(time.Second)
+
+func notif(id, eventID string, created time.Time) models.Notification {
+	return models.Notification{
+		ID:         id,
+		EventID:    eventID,
+		Recipient:  "+910000000000",
+		Channel:    "sms",
+		Message:    "High tide warning",
+		Status:     "pending",
+		Timestamp:  created,
+		MaxRetries: 3,
+	}
+}
+
+func mustSave(t *testing.T, s storage.NotificationStore, n models.Notification) {
+	t.Helper()
+	if err := s.SaveNotification(context.Background(), n); err != nil {
+		t.Fatalf("SaveNotification(%s): %v", n.ID, err)
+	}
+}
+
+func mustGet(t *testing.T, s storage.NotificationStore, id string) *models.Notification {
+	t.Helper()
+	n, err := s.GetNotification(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetNotification(%s): %v", id, err)
+	}
+	return n
+}
+
+func testSaveAndGet(t *testing.T, s storage.NotificationStore) {
+	want := notif("n1", "e1", base)
+	expires := base.Add(6 * time.Hour)
+	want.ExpiresAt = &expires
+	want.RecipientID = "r1"
+	want.Subject = "Tide warning"
+	want.HTMLBody = "<p>High tide warning</p>"
+	want.Language = "or"
+	want.Severity = "severe"
+	want.SMSEncoding = "UCS-2"
+	want.SMSSegments = 2
+	want.Attachments = []models.Attachment{{Filename: "map.png", ContentType: "image/png", ContentID: "map", Data: []byte{0x89, 'P', 'N', 'G'}}}
+	mustSave(t, s, want)
+
+	got := mustGet(t, s, "n1")
+	if got.ID != want.ID || got.EventID != want.EventID || got.Recipient != want.Recipient ||
+		got.RecipientID != want.RecipientID || got.Subject != want.Subject || got.HTMLBody != want.HTMLBody ||
+		got.Language != want.Language || got.SMSEncoding != want.SMSEncoding || got.SMSSegments != want.SMSSegments ||
+		got.Severity != want.Severity || got.Channel != want.Channel || got.Message != want.Message || got.Status != want.Status ||
+		got.MaxRetries != want.MaxRetries {
+		t.Errorf("GetNotification = %+v, want %+v", got, want)
+	}
+	if len(got.Attachments) != 1 || got.Attachments[0].ContentID != "map" || string(got.Attachments[0].Data) != string(want.Attachments[0].Data) {
+		t.Errorf("Attachments = %+v, want %+v", got.Attachments, want.Attachments)
+	}
+	if !got.Timestamp.Equal(want.Timestamp) {
+		t.Errorf("Timestamp = %v, want %v", got.Timestamp, want.Timestamp)
+	}
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(expires) {
+		t.Errorf("ExpiresAt = %v, want %v", got.ExpiresAt, expires)
+	}
+
+	mustSave(t, s, notif("n2", "e1", base))
+	if got := mustGet(t, s, "n2"); got.ExpiresAt != nil {
+		t.Errorf("ExpiresAt = %v, want nil when unset", got.ExpiresAt)
+	}
+}
+
+func testGetMissing(t *testing.T, s storage.NotificationStore) {
+	_, err := s.GetNotification(context.Background(), "does-not-exist")
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetNotification(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func testUpdateMissing(t *testing.T, s storage.NotificationStore) {
+	ctx := context.Background()
+	updates := []struct {
+		name string
+		fn   func() error
+	}{
+		{"UpdateNotificationStatus", func() error { return s.UpdateNotificationStatus(ctx, "missing", "failed", "timeout") }},
+		{"UpdateNotificationContent", func() error {
+			return s.UpdateNotificationContent(ctx, notif("missing", "e1", base))
+		}},
+		{"IncrementAttempts", func() error {
+			n, err := s.IncrementAttempts(ctx, "missing", "timeout")
+			if n != 0 {
+				t.Errorf("IncrementAttempts(missing) = %d, want 0", n)
+			}
+			return err
+		}},
+		{"UpdateAPIResponse", func() error { return s.UpdateAPIResponse(ctx, "missing", 503, "unavailable") }},
+		{"SetProviderMessageID", func() error { return s.SetProviderMessageID(ctx, "missing", "SM1") }},
+	}
+	for _, u := range updates {
+		if err := u.fn(); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("%s(missing) = %v, want ErrNotFound", u.name, err)
+		}
+	}
+	// no update may leave a partial record behind
+	if _, err := s.GetNotification(ctx, "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetNotification after updates = %v, want ErrNotFound", err)
+	}
+}
+
+func testUpdateStatus(t *testing.T, s storage.NotificationStore) {
+	ctx := context.Background()
+	mustSave(t, s, notif("n1", "e1", base))
+
+	if err := s.UpdateNotificationStatus(ctx, "n1", "failed", "timeout"); err != nil {
+		t.Fatalf("UpdateNotificationStatus: %v", err)
+	}
+	got := mustGet(t, s, "n1")
+	if got.Status != "failed" || got.Error != "timeout" {
+		t.Errorf("status/error = %q/%q, want failed/timeout", got.Status, got.Error)
+	}
+
+	if err := s.UpdateNotificationStatus(ctx, "n1", "success", ""); err != nil {
+		t.Fatalf("UpdateNotificationStatus: %v", err)
+	}
+	got = mustGet(t, s, "n1")
+	if got.Status != "success" || got.Error != "" {
+		t.Errorf("status/error = %q/%q, want success/\"\"", got.Status, got.Error)
+	}
+}
+
+func testTransitionStatus(t *testing.T, s storage.NotificationStore) {
+	ctx := context.Background()
+	mustSave(t, s, notif("n1", "e1", base))
+
+	ok, err := s.TransitionNotificationStatus(ctx, "n1", storage.Unsettled, "cancelled", "cancelled by c1")
+	if err != nil || !ok {
+		t.Fatalf("TransitionNotificationStatus(pending -> cancelled) = %v, %v", ok, err)
+	}
+	// a send finishing after the cancel must not overwrite it
+	ok, err = s.TransitionNotificationStatus(ctx, "n1", storage.Unsettled, "success", "")
+	if err != nil || ok {
+		t.Errorf("TransitionNotificationStatus(cancelled -> success) = %v, %v, want refused", ok, err)
+	}
+	if got := mustGet(t, s, "n1"); got.Status != "cancelled" || got.Error != "cancelled by c1" {
+		t.Errorf("status/error = %q/%q, want cancelled/cancelled by c1", got.Status, got.Error)
+	}
+
+	if _, err := s.TransitionNotificationStatus(ctx, "missing", storage.Unsettled, "success", ""); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("TransitionNotificationStatus(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func testUpdateContent(t *testing.T, s storage.NotificationStore) {
+	mustSave(t, s, notif("n1", "e1", base))
+
+	updated := notif("n1", "e1", base)
+	updated.Message, updated.Subject, updated.HTMLBody = "Warning withdrawn", "Update", "<p>Warning withdrawn</p>"
+	updated.Language, updated.SMSEncoding, updated.SMSSegments = "ta", "UCS-2", 2
+	if err := s.UpdateNotificationContent(context.Background(), updated); err != nil {
+		t.Fatalf("UpdateNotificationContent: %v", err)
+	}
+	got := mustGet(t, s, "n1")
+	if got.Message != "Warning withdrawn" || got.Status != "pending" {
+		t.Errorf("message/status = %q/%q, want updated message and unchanged status", got.Message, got.Status)
+	}
+	if got.Subject != "Update" || got.HTMLBody != "<p>Warning withdrawn</p>" || got.Language != "ta" ||
+		got.SMSEncoding != "UCS-2" || got.SMSSegments != 2 {
+		t.Errorf("content = %q/%q/%q/%q/%d, want every rendered field updated",
+			got.Subject, got.HTMLBody, got.Language, got.SMSEncoding, got.SMSSegments)
+	}
+}
+
+func testResave(t *testing.T, s storage.NotificationStore) {
+	ctx := context.Background()
+	mustSave(t, s, notif("n1", "e1", base))
+	for range 2 {
+		if _, err := s.IncrementAttempts(ctx, "n1", "timeout"); err != nil {
+			t.Fatalf("IncrementAttempts: %v", err)
+		}
+	}
+	if err := s.UpdateAPIResponse(ctx, "n1", 503, "unavailable"); err != nil {
+		t.Fatalf("UpdateAPIResponse: %v", err)
+	}
+	if err := s.SetProviderMessageID(ctx, "n1", "SM1"); err != nil {
+		t.Fatalf("SetProviderMessageID: %v", err)
+	}
+
+	// a fresh copy, as the dispatcher saves when it resends a notification
+	// left pending, knows nothing of the earlier attempts
+	again := notif("n1", "e1", base)
+	again.Message, again.Status, again.MaxRetries = "Tide warning raised", "failed", 0
+	mustSave(t, s, again)
+
+	got := mustGet(t, s, "n1")
+	if got.Message != again.Message || got.Status != "failed" {
+		t.Errorf("message/status = %q/%q, want the re-saved ones", got.Message, got.Status)
+	}
+	if got.Attempts != 2 || got.MaxRetries != 3 || got.LastError != "timeout" {
+		t.Errorf("attempts/max_retries/last_error = %d/%d/%q, want 2/3/%q kept",
+			got.Attempts, got.MaxRetries, got.LastError, "timeout")
+	}
+	if got.APIStatusCode != 503 || got.APIResponse != "unavailable" || got.ProviderMessageID != "SM1" {
+		t.Errorf("api code/response/provider id = %d/%q/%q, want 503/%q/%q kept",
+			got.APIStatusCode, got.APIResponse, got.ProviderMessageID, "unavailable", "SM1")
+	}
+
+	// set fields still win
+	again.Attempts, again.MaxRetries = 5, 7
+	mustSave(t, s, again)
+	if got := mustGet(t, s, "n1"); got.Attempts != 5 || got.MaxRetries != 7 {
+		t.Errorf("attempts/max_retries = %d/%d, want 5/7", got.Attempts, got.MaxRetries)
+	}
+}
+
+func testIncrementAttempts(t *testing.T, s storage.NotificationStore) {
+	ctx := context.Background()
+	mustSave(t, s, notif("n1", "e1", base))
+
+	for want := 1; want <= 3; want++ {
+		got, err := s.IncrementAttempts(ctx, "n1", fmt.Sprintf("err %d", want))
+		if err != nil {
+			t.Fatalf("IncrementAttempts: %v", err)
+		}
+		if got != want {
+			t.Errorf("IncrementAttempts = %d, want %d", got, want)
+		}
+	}
+	got := mustGet(t, s, "n1")
+	if got.Attempts != 3 || got.LastError != "err 3" {
+		t.Errorf("stored Attempts/LastError = %d/%q, want 3/%q", got.Attempts, got.LastError, "err 3")
+	}
+	// saving the notification again, e.g. with new content, keeps it
+	mustSave(t, s, *got)
+	if got := mustGet(t, s, "n1"); got.LastError != "err 3" {
+		t.Errorf("LastError after save = %q, want %q", got.LastError, "err 3")
+	}
+}
+
+func testRetryOrdering(t *testing.T, s storage.NotificationStore) {
+	ctx := context.Background()
+	schedule := map[string]time.Duration{
+		"late":   3 * time.Minute,
+		"early":  -10 * time.Minute,
+		"middle": -5 * time.Minute,
+		"future": time.Hour,
+	}
+	for id, offset := range schedule {
+		mustSave(t, s, notif(id, "e1", base))
+		if err := s.ScheduleRetry(ctx, id, base.Add(offset), "boom"); err != nil {
+			t.Fatalf("ScheduleRetry(%s): %v", id, err)
+		}
+	}
+
+	got, err := s.GetDueRetries(ctx, base.Add(5*time.Minute), 10)
+	if err != nil {
+		t.Fatalf("GetDueRetries: %v", err)
+	}
+	if want := []string{"early", "middle", "late"}; !slices.Equal(got, want) {
+		t.Errorf("GetDueRetries = %v, want %v", got, want)
+	}
+
+	got, err = s.GetDueRetries(ctx, base.Add(5*time.Minute), 2)
+	if err != nil {
+		t.Fatalf("GetDueRetries: %v", err)
+	}
+	if want := []string{"early", "middle"}; !slices.Equal(got, want) {
+		t.Errorf("GetDueRetries(limit 2) = %v, want %v", got, want)
+	}
+
+	if err := s.RemoveFromRetryQueue(ctx, "early"); err != nil {
+		t.Fatalf("RemoveFromRetryQueue: %v", err)
+	}
+	got, _ = s.GetDueRetries(ctx, base.Add(5*time.Minute), 10)
+	if want := []string{"middle", "late"}; !slices.Equal(got, want) {
+		t.Errorf("after remove GetDueRetries = %v, want %v", got, want)
+	}
+	if e := mustGet(t, s, "late").Error; e != "boom" {
+		t.Errorf("ScheduleRetry error = %q, want boom", e)
+	}
+}
+
+func testRetryReschedule(t *testing.T, s storage.NotificationStore) {
+	ctx := context.Background()
+	mustSave(t, s, notif("n1", "e1", base))
+	_ = s.ScheduleRetry(ctx, "n1", base.Add(-time.Minute), "first")
+	_ = s.ScheduleRetry(ctx, "n1", base.Add(time.Hour), "second")
+
+	got, _ := s.GetDueRetries(ctx, base, 10)
+	if len(got) != 0 {
+		t.Errorf("rescheduled ID still due: %v", got)
+	}
+	got, _ = s.GetDueRetries(ctx, base.Add(2*time.Hour), 10)
+	if want := []string{"n1"}; !slices.Equal(got, want) {
+		t.Errorf("GetDueRetries = %v, want %v (one entry per ID)", got, want)
+	}
+}
+
+func testClaimAndLease(t *testing.T, s storage.NotificationStore) {
+	ctx := context.Background()
+	for _, id := range []string{"a", "b"} {
+		mustSave(t, s, notif(id, "e1", base))
+	}
+	_ = s.ScheduleRetry(ctx, "a", base.Add(-2*time.Minute), "x")
+	_ = s.ScheduleRetry(ctx, "b", base.Add(-time.Minute), "x")
+
+	claimed, err := s.ClaimDueRetries(ctx, base, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("ClaimDueRetries: %v", err)
+	}
+	if want := []string{"a", "b"}; !slices.Equal(claimed, want) {
+		t.Fatalf("ClaimDueRetries = %v, want %v", claimed, want)
+	}
+	if again, _ := s.ClaimDueRetries(ctx, base, time.Minute, 10); len(again) != 0 {
+		t.Errorf("second claim returned %v, want nothing while leased", again)
+	}
+
+	// a finishes and reschedules; b's worker dies
+	_ = s.ScheduleRetry(ctx, "a", base.Add(time.Hour), "y")
+	if n, err := s.ReapExpiredLeases(ctx, base.Add(30*time.Second)); err != nil || n != 0 {
+		t.Errorf("ReapExpiredLeases before deadline = %d, %v; want 0", n, err)
+	}
+	if n, err := s.ReapExpiredLeases(ctx, base.Add(2*time.Minute)); err != nil || n != 1 {
+		t.Errorf("ReapExpiredLeases after deadline = %d, %v; want 1", n, err)
+	}
+	claimed, _ = s.ClaimDueRetries(ctx, base.Add(2*time.Minute), time.Minute, 10)
+	if want := []string{"b"}; !slices.Equal(claimed, want) {
+		t.Errorf("claim after reap = %v, want %v", claimed, want)
+	}
+
+	// removal releases the lease so nothing comes back
+	_ = s.RemoveFromRetryQueue(ctx, "b")
+	if n, _ := s.ReapExpiredLeases(ctx, base.Add(time.Hour)); n != 0 {
+		t.Errorf("ReapExpiredLeases after remove = %d, want 0", n)
+	}
+}
+
+func testUpdateAPIResponse(t *testing.T, s storage.NotificationStore) {
+	ctx := context.Background()
+	mustSave(t, s, notif("n1", "e1", base))
+
+	if err := s.UpdateAPIResponse(ctx, "n1", 429, `{"code":20429}`); err != nil {
+		t.Fatalf("UpdateAPIResponse: %v", err)
+	}
+	got := mustGet(t, s, "n1")
+	if got.APIStatusCode != 429 || got.APIResponse != `{"code":20429}` {
+		t.Errorf("api code/response = %d/%q", got.APIStatusCode, got.APIResponse)
+	}
+
+	if err := s.UpdateAPIResponse(ctx, "n1", 500, strings.Repeat("x", 5000)); err != nil {
+		t.Fatalf("UpdateAPIResponse: %v", err)
+	}
+	if got := mustGet(t, s, "n1").APIResponse; len(got) != 1000 {
+		t.Errorf("stored body length = %d, want truncated to 1000", len(got))
+	}
+
+	if err := s.UpdateAPIResponse(ctx, "", 200, ""); err == nil {
+		t.Error("UpdateAPIResponse with empty id succeeded, want error")
+	}
+}
+
+func testListByEvent(t *testing.T, s storage.NotificationStore) {
+	ctx := context.Background()
+	for i, id := range []string{"n0", "n1", "n2", "n3", "n4"} {
+		mustSave(t, s, notif(id, "e1", base.Add(time.Duration(i)*time.Second)))
+	}
+	mustSave(t, s, notif("other", "e2", base))
+
+	page, total, err := s.ListNotificationsByEvent(ctx, "e1", 1, 2)
+	if err != nil {
+		t.Fatalf("ListNotificationsByEvent: %v", err)
+	}
+	if total != 5 {
+		t.Errorf("total = %d, want 5", total)
+	}
+	var got []string
+	for _, n := range page {
+		got = append(got, n.ID)
+	}
+	if want := []string{"n1", "n2"}; !slices.Equal(got, want) {
+		t.Errorf("page = %v, want %v", got, want)
+	}
+
+	page, total, err = s.ListNotificationsByEvent(ctx, "missing", 0, 10)
+	if err != nil || total != 0 || len(page) != 0 {
+		t.Errorf("unknown event = %v, %d, %v; want empty", page, total, err)
+	}
+}
+
+func testProviderMessageID(t *testing.T, s storage.NotificationStore) {
+	ctx := context.Background()
+	mustSave(t, s, notif("n1", "e1", base))
+
+	if err := s.SetProviderMessageID(ctx, "n1", "SM123"); err != nil {
+		t.Fatalf("SetProviderMessageID: %v", err)
+	}
+	if got := mustGet(t, s, "n1").ProviderMessageID; got != "SM123" {
+		t.Errorf("provider message id = %q, want SM123", got)
+	}
+	if err := s.SetProviderMessageID(ctx, "missing", "SM1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("SetProviderMessageID(missing) = %v, want ErrNotFound", err)
+	}
+}
+
+func testLatestNotificationTo(t *testing.T, s storage.NotificationStore) {
+	ctx := context.Background()
+	for _, n := range []models.Notification{
+		notif("old", "e1", base),
+		notif("new", "e2", base.Add(time.Minute)),
+		notif("email", "e3", base.Add(2*time.Minute)),
+		// never handed over, so nothing a reply can answer
+		notif("escalation", "e4", base.Add(3*time.Minute)),
+		notif("cancelled", "e5", base.Add(4*time.Minute)),
+	} {
+		switch n.ID {
+		case "email":
+			n.Channel, n.Status = "email", "success"
+		case "escalation":
+			n.Status = "scheduled"
+		case "cancelled":
+			n.Status = "cancelled"
+		default:
+			n.Status = "sent"
+		}
+		mustSave(t, s, n)
+	}
+
+	got, err := s.LatestNotificationTo(ctx, "sms", "+910000000000")
+	if err != nil {
+		t.Fatalf("LatestNotificationTo: %v", err)
+	}
+	if got.ID != "new" || got.EventID != "e2" {
+		t.Errorf("latest = %s (event %s), want new (event e2)", got.ID, got.EventID)
+	}
+	if _, err := s.LatestNotificationTo(ctx, "sms", "+919999999999"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("LatestNotificationTo(unknown) = %v, want ErrNotFound", err)
+	}
+}
@@ -2,19 +2,84 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"notification-service/pkg/models"
 	"time"
 )
 
+// ErrNotFound is returned (possibly wrapped) when a record does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrExists is returned (possibly wrapped) when creating a record whose ID
+// is already taken.
+var ErrExists = errors.New("already exists")
+
+// HandedOver are the notification states in which the message went to the
+// provider, so the recipient may have seen it and replied. Pending,
+// scheduled, skipped or cancelled notifications never reached anyone.
+var HandedOver = []string{"queued", "sent", "success", "acknowledged", "failed"}
+
+// Delivered are the HandedOver states of a message the provider has not
+// reported failing. Queued and sent count, as a delivery report can arrive
+// after the recipient already replied.
+var Delivered = []string{"queued", "sent", "success", "acknowledged"}
+
+// Unsettled are the notification states a send or its outcome may still
+// move on from. Cancelled, expired, skipped and the final outcomes are left
+// alone.
+var Unsettled = []string{"pending", "scheduled", "failed", "queued", "sent"}
+
 type NotificationStore interface {
 	SaveNotification(ctx context.Context, notif models.Notification) error
 	GetNotification(ctx context.Context, id string) (*models.Notification, error)
+	// ListNotificationsByEvent returns one page of an event's notifications in
+	// creation order, plus the total number of notifications for the event.
+	ListNotificationsByEvent(ctx context.Context, eventID string, offset, limit int) ([]models.Notification, int, error)
 	UpdateNotificationStatus(ctx context.Context, id string, status string, errMsg string) error
+	// TransitionNotificationStatus sets the status only while the current
+	// one is among from, atomically, and reports whether it did; e.g. so a
+	// send finishing after a cancel does not overwrite "cancelled".
+	TransitionNotificationStatus(ctx context.Context, id string, from []string, status string, errMsg string) (bool, error)
+	// UpdateNotificationContent replaces the rendered content of a
+	// not-yet-delivered notification: message, subject, HTML body, language
+	// and SMS segment info.
+	UpdateNotificationContent(ctx context.Context, notif models.Notification) error
 	IncrementAttempts(ctx context.Context, id string, lastError string) (int, error)
 	ScheduleRetry(ctx context.Context, notifID string, nextRetry time.Time, lastErr string) error
 	GetDueRetries(ctx context.Context, before time.Time, limit int) ([]string, error)
+	// ClaimDueRetries atomically moves up to limit due IDs from the retry queue
+	// into an in-flight set leased until now+lease, so concurrent workers (in
+	// this or another instance) never claim the same notification twice.
+	ClaimDueRetries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]string, error)
+	// ReapExpiredLeases returns claimed IDs whose lease ran out (their worker
+	// died) to the retry queue as due immediately; it reports how many moved.
+	ReapExpiredLeases(ctx context.Context, now time.Time) (int, error)
+	// RemoveFromRetryQueue drops the ID from the retry queue and releases any lease on it.
 	RemoveFromRetryQueue(ctx context.Context, notifID string) error
 	UpdateAPIResponse(ctx context.Context, id string, statusCode int, body string) error // NEW
+	// SetProviderMessageID records the provider's ID for the latest send.
+	SetProviderMessageID(ctx context.Context, id string, providerID string) error
+	// LatestNotificationTo returns the most recent notification sent to an
+	// address on a channel, e.g. the alert an SMS reply answers. Only
+	// notifications in one of the HandedOver states count.
+	LatestNotificationTo(ctx context.Context, channel, recipient string) (*models.Notification, error)
 	Ping(ctx context.Context) error
 	Close(ctx context.Context) error
 }
+
+// EventStore keeps accepted events so they can be queried after dispatch.
+type EventStore interface {
+	SaveEvent(ctx context.Context, record models.EventRecord) error
+	// CreateEvent stores a new event record, atomically failing with
+	// ErrExists if an event with the same ID is already stored.
+	CreateEvent(ctx context.Context, record models.EventRecord) error
+	GetEvent(ctx context.Context, id string) (*models.EventRecord, error)
+	UpdateEventStatus(ctx context.Context, id string, status string) error
+	// TransitionEventStatus sets the status only while the current one is
+	// among from, atomically, and reports whether it did; e.g. so a consumer
+	// starting a dispatch does not overwrite "cancelled".
+	TransitionEventStatus(ctx context.Context, id string, from []string, status string) (bool, error)
+	// UpdateEventContent replaces the stored event but keeps its status, so
+	// an update cannot revert a cancel that landed meanwhile.
+	UpdateEventContent(ctx context.Context, event models.Event) error
+}
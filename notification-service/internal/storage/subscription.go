@@ -0,0 +1,15 @@
+package storage
+
+import (
+	"context"
+	"notification-service/pkg/models"
+)
+
+// SubscriptionStore keeps recipients' hazard subscriptions.
+type SubscriptionStore interface {
+	SaveSubscription(ctx context.Context, sub models.Subscription) error
+	GetSubscription(ctx context.Context, id string) (*models.Subscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	// ListSubscriptions returns every subscription of a recipient, oldest first.
+	ListSubscriptions(ctx context.Context, recipientID string) ([]models.Subscription, error)
+}
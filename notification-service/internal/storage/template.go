@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"notification-service/pkg/models"
+)
+
+// TemplateStore keeps every version of every message template. Versions are
+// immutable; saving a template always adds a new one.
+type TemplateStore interface {
+	// CreateTemplateVersion stores t as the next version of t.Name and
+	// returns the version number it was given.
+	CreateTemplateVersion(ctx context.Context, t models.Template) (int, error)
+	// GetTemplate loads one version of a template; version 0 means the latest.
+	GetTemplate(ctx context.Context, name string, version int) (*models.Template, error)
+	// ListTemplateVersions returns every version of a template, oldest first.
+	ListTemplateVersions(ctx context.Context, name string) ([]models.Template, error)
+	// ListTemplates returns the latest version of each template by name.
+	ListTemplates(ctx context.Context) ([]models.Template, error)
+}
@@ -0,0 +1,15 @@
+package storage
+
+import (
+	"context"
+	"notification-service/pkg/models"
+)
+
+// WebhookStore keeps the partner endpoints of the webhook channel.
+type WebhookStore interface {
+	SaveWebhook(ctx context.Context, w models.WebhookEndpoint) error
+	GetWebhook(ctx context.Context, id string) (*models.WebhookEndpoint, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	// ListWebhooks returns every endpoint, oldest first.
+	ListWebhooks(ctx context.Context) ([]models.WebhookEndpoint, error)
+}
@@ -0,0 +1,211 @@
+// Package templating renders stored message templates into the per-channel
+// content of a notification: a short SMS body, an email subject with text
+// and HTML parts, or a push title and body.
+package templating
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"notification-service/internal/storage"
+	"notification-service/pkg/models"
+)
+
+// Data holds the variables a template can use. Event.Message is already
+// the translation for Language.
+type Data struct {
+	Event     models.Event
+	Recipient models.Recipient // zero value for raw addresses
+	Address   string           // where this copy is delivered
+	Language  string           // language of Event.Message, e.g. "hi"
+}
+
+// Rendered is the content of one notification.
+type Rendered struct {
+	Subject string `json:"subject,omitempty"`
+	Text    string `json:"text"`
+	HTML    string `json:"html,omitempty"`
+}
+
+var funcs = template.FuncMap{
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"truncate": truncate,
+	"time":     formatTime,
+}
+
+// truncate shortens s to at most n characters, ending in "…" when cut.
+func truncate(n int, s string) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n < 1 {
+		return ""
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// formatTime accepts a time.Time or *time.Time; nil renders as "".
+func formatTime(v any) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format("02 Jan 2006 15:04 MST")
+	case *time.Time:
+		if t == nil {
+			return ""
+		}
+		return t.Format("02 Jan 2006 15:04 MST")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Compiled is a parsed template version ready to render.
+type Compiled struct {
+	Template models.Template
+
+	text map[string]*template.Template // part name -> parsed body
+	html *htmltemplate.Template
+}
+
+// Compile parses every part of t, reporting the first syntax error.
+func Compile(t models.Template) (*Compiled, error) {
+	c := &Compiled{Template: t, text: map[string]*template.Template{}}
+	parts := map[string]string{
+		"sms":           t.SMS,
+		"email_subject": t.EmailSubject,
+		"email_text":    t.EmailText,
+		"push_title":    t.PushTitle,
+		"push_body":     t.PushBody,
+		"default":       t.Default,
+	}
+	for name, body := range parts {
+		if body == "" {
+			continue
+		}
+		parsed, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", t.Name, err)
+		}
+		c.text[name] = parsed
+	}
+	if t.EmailHTML != "" {
+		parsed, err := htmltemplate.New("email_html").Funcs(htmltemplate.FuncMap(funcs)).Parse(t.EmailHTML)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", t.Name, err)
+		}
+		c.html = parsed
+	}
+	if len(c.text) == 0 && c.html == nil {
+		return nil, fmt.Errorf("template %s has no content", t.Name)
+	}
+	return c, nil
+}
+
+// Render produces the content for one channel. Channels without their own
+// parts use the default body.
+func (c *Compiled) Render(channel string, data Data) (Rendered, error) {
+	var out Rendered
+	var err error
+	switch channel {
+	case "sms":
+		out.Text, err = c.execFirst(data, "sms", "default")
+	case "email":
+		if out.Subject, err = c.execFirst(data, "email_subject"); err != nil {
+			break
+		}
+		if out.HTML, err = c.execHTML(data); err != nil {
+			break
+		}
+		out.Text, err = c.execFirst(data, "email_text", "default")
+	case "push", "webpush":
+		if out.Subject, err = c.execFirst(data, "push_title"); err != nil {
+			break
+		}
+		out.Text, err = c.execFirst(data, "push_body", "default")
+	default:
+		out.Text, err = c.execFirst(data, "default", "sms")
+	}
+	if err != nil {
+		return Rendered{}, fmt.Errorf("render %s v%d for %s: %w", c.Template.Name, c.Template.Version, channel, err)
+	}
+	if out.Text == "" && out.HTML == "" {
+		return Rendered{}, fmt.Errorf("template %s v%d has no body for channel %s", c.Template.Name, c.Template.Version, channel)
+	}
+	return out, nil
+}
+
+// execFirst renders the first of the named parts that exists.
+func (c *Compiled) execFirst(data Data, names ...string) (string, error) {
+	for _, name := range names {
+		if t, ok := c.text[name]; ok {
+			var buf bytes.Buffer
+			if err := t.Execute(&buf, data); err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(buf.String()), nil
+		}
+	}
+	return "", nil
+}
+
+func (c *Compiled) execHTML(data Data) (string, error) {
+	if c.html == nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := c.html.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Engine loads templates from a store and caches compiled versions; a
+// version never changes once stored.
+type Engine struct {
+	store storage.TemplateStore
+
+	mu    sync.Mutex
+	cache map[string]*Compiled // "name@version"
+}
+
+func NewEngine(store storage.TemplateStore) *Engine {
+	return &Engine{store: store, cache: map[string]*Compiled{}}
+}
+
+// Load returns a compiled template version; version 0 means the latest.
+func (e *Engine) Load(ctx context.Context, name string, version int) (*Compiled, error) {
+	if version > 0 {
+		if c := e.cached(name, version); c != nil {
+			return c, nil
+		}
+	}
+	t, err := e.store.GetTemplate(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	if c := e.cached(t.Name, t.Version); c != nil {
+		return c, nil
+	}
+	c, err := Compile(*t)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	e.cache[fmt.Sprintf("%s@%d", t.Name, t.Version)] = c
+	e.mu.Unlock()
+	return c, nil
+}
+
+func (e *Engine) cached(name string, version int) *Compiled {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.cache[fmt.Sprintf("%s@%d", name, version)]
+}
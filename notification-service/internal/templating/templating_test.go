@@ -0,0 +1,196 @@
+package templating
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	memorystore "notification-service/internal/storage/memory"
+	"notification-service/pkg/models"
+)
+
+var expires = time.Date(2025, 11, 1, 16, 0, 0, 0, time.UTC)
+
+func sampleData() Data {
+	return Data{
+		Event: models.Event{ID: "evt-1", Type: "cyclone", Title: "Cyclone Dana", Severity: "severe",
+			Message: "Landfall expected near Puri", ExpiresAt: &expires},
+		Recipient: models.Recipient{ID: "r1", Name: "Asha"},
+		Address:   "+911234567890",
+		Language:  "en",
+	}
+}
+
+func compile(t *testing.T, tmpl models.Template) *Compiled {
+	t.Helper()
+	tmpl.Name, tmpl.Version = "cyclone", 1
+	c, err := Compile(tmpl)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	return c
+}
+
+func TestRenderPerChannel(t *testing.T) {
+	c := compile(t, models.Template{
+		SMS:          "{{upper .Event.Severity}}: {{.Event.Message}}",
+		EmailSubject: "[{{.Event.Type}}] {{.Event.Title}}",
+		EmailText:    "Dear {{.Recipient.Name}},\n{{.Event.Message}}\nValid until {{time .Event.ExpiresAt}}",
+		EmailHTML:    "<p>Dear {{.Recipient.Name}},</p><p>{{.Event.Message}}</p>",
+		PushTitle:    "{{.Event.Title}}",
+		PushBody:     "{{truncate 12 .Event.Message}}",
+		Default:      "{{.Event.Title}} - {{.Event.Message}}",
+	})
+
+	tests := []struct {
+		channel string
+		want    Rendered
+	}{
+		{"sms", Rendered{Text: "SEVERE: Landfall expected near Puri"}},
+		{"email", Rendered{
+			Subject: "[cyclone] Cyclone Dana",
+			Text:    "Dear Asha,\nLandfall expected near Puri\nValid until 01 Nov 2025 16:00 UTC",
+			HTML:    "<p>Dear Asha,</p><p>Landfall expected near Puri</p>",
+		}},
+		{"push", Rendered{Subject: "Cyclone Dana", Text: "Landfall ex…"}},
+		{"webpush", Rendered{Subject: "Cyclone Dana", Text: "Landfall ex…"}},
+		{"telegram", Rendered{Text: "Cyclone Dana - Landfall expected near Puri"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			got, err := c.Render(tt.channel, sampleData())
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderFallsBackToOtherParts(t *testing.T) {
+	onlyDefault := compile(t, models.Template{Default: "{{.Event.Message}}"})
+	for _, channel := range []string{"sms", "email", "push", "voice"} {
+		got, err := onlyDefault.Render(channel, sampleData())
+		if err != nil || got.Text != "Landfall expected near Puri" || got.Subject != "" {
+			t.Errorf("%s: Render = %+v, %v, want the default body", channel, got, err)
+		}
+	}
+
+	// channels without a part of their own borrow the SMS body
+	onlySMS := compile(t, models.Template{SMS: "{{.Event.Message}}"})
+	if got, err := onlySMS.Render("whatsapp", sampleData()); err != nil || got.Text != "Landfall expected near Puri" {
+		t.Errorf("whatsapp: Render = %+v, %v, want the SMS body", got, err)
+	}
+
+	// a push title alone is not a message
+	onlyTitle := compile(t, models.Template{PushTitle: "{{.Event.Title}}"})
+	if _, err := onlyTitle.Render("push", sampleData()); err == nil || !strings.Contains(err.Error(), "no body for channel push") {
+		t.Errorf("push without body: error = %v", err)
+	}
+}
+
+func TestRenderMissingVariables(t *testing.T) {
+	data := sampleData()
+	data.Recipient = models.Recipient{} // a raw address has no profile
+	data.Event.ExpiresAt = nil
+
+	c := compile(t, models.Template{
+		SMS:       "Hi {{.Recipient.Name}}. {{.Event.Messages.ta}}{{.Event.Message}} {{time .Event.ExpiresAt}}",
+		EmailHTML: "<p>{{.Event.Messages.ta}}</p>",
+		EmailText: "{{.Event.Message}}",
+	})
+	got, err := c.Render("sms", data)
+	if err != nil || got.Text != "Hi . Landfall expected near Puri" {
+		t.Errorf("sms: Render = %q, %v, want empty values for what is missing", got.Text, err)
+	}
+	if got, err := c.Render("email", data); err != nil || got.HTML != "<p></p>" {
+		t.Errorf("email: Render = %+v, %v, want an empty missing translation", got, err)
+	}
+
+	// a field that does not exist at all is an error, so the dispatcher
+	// sends the plain message instead of a half-rendered one
+	bad := compile(t, models.Template{SMS: "{{.Event.Wind}} {{.Event.Message}}"})
+	if _, err := bad.Render("sms", data); err == nil || !strings.Contains(err.Error(), "render cyclone v1 for sms") {
+		t.Errorf("unknown field: error = %v", err)
+	}
+}
+
+func TestRenderEscapesOnlyHTML(t *testing.T) {
+	data := sampleData()
+	data.Event.Message = `Shelters: <b>Puri & Konark</b> "open"`
+	data.Address = `x" onmouseover="alert(1)`
+
+	c := compile(t, models.Template{
+		SMS:       "{{.Event.Message}}",
+		EmailText: "{{.Event.Message}}",
+		EmailHTML: `<p title="{{.Address}}">{{.Event.Message}}</p>`,
+	})
+	got, err := c.Render("email", data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if got.Text != data.Event.Message {
+		t.Errorf("text part = %q, want it unescaped", got.Text)
+	}
+	wantHTML := `<p title="x&#34; onmouseover=&#34;alert(1)">Shelters: &lt;b&gt;Puri &amp; Konark&lt;/b&gt; &#34;open&#34;</p>`
+	if got.HTML != wantHTML {
+		t.Errorf("html part = %s, want %s", got.HTML, wantHTML)
+	}
+	if sms, _ := c.Render("sms", data); sms.Text != data.Event.Message {
+		t.Errorf("sms = %q, want it unescaped", sms.Text)
+	}
+}
+
+func TestCompileRejectsBadTemplates(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl models.Template
+		want string
+	}{
+		{"SyntaxError", models.Template{Name: "t", SMS: "{{.Event.Message"}, "template t"},
+		{"HTMLSyntaxError", models.Template{Name: "t", EmailHTML: "{{if}}"}, "template t"},
+		{"Empty", models.Template{Name: "t"}, "has no content"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(tt.tmpl); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineLoadsVersions(t *testing.T) {
+	ctx := context.Background()
+	mem := memorystore.New()
+	for _, body := range []string{"v1: {{.Event.Message}}", "v2: {{.Event.Message}}"} {
+		if _, err := mem.CreateTemplateVersion(ctx, models.Template{Name: "cyclone", SMS: body}); err != nil {
+			t.Fatalf("CreateTemplateVersion: %v", err)
+		}
+	}
+	e := NewEngine(mem)
+
+	for _, tt := range []struct {
+		version int
+		want    string
+	}{{0, "v2: "}, {1, "v1: "}, {2, "v2: "}} {
+		c, err := e.Load(ctx, "cyclone", tt.version)
+		if err != nil {
+			t.Fatalf("Load(%d): %v", tt.version, err)
+		}
+		if got, _ := c.Render("sms", sampleData()); !strings.HasPrefix(got.Text, tt.want) {
+			t.Errorf("Load(%d) renders %q, want %q…", tt.version, got.Text, tt.want)
+		}
+	}
+	latest, _ := e.Load(ctx, "cyclone", 0)
+	pinned, _ := e.Load(ctx, "cyclone", 2)
+	if latest != pinned {
+		t.Error("latest and version 2 compiled twice, want one cached copy")
+	}
+	if _, err := e.Load(ctx, "missing", 0); err == nil {
+		t.Error("Load(missing) succeeded")
+	}
+}
@@ -0,0 +1,104 @@
+package webpush
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// tokenLifetime is how long a VAPID token is valid. RFC 8292 caps it at 24
+// hours; push services differ in how close to that they accept.
+const tokenLifetime = 12 * time.Hour
+
+// VAPID identifies this application server to push services. The browser
+// must subscribe with PublicKey as its applicationServerKey.
+type VAPID struct {
+	key     *ecdsa.PrivateKey
+	public  string
+	subject string // contact URI, "mailto:" or "https:"
+
+	mu     sync.Mutex
+	tokens map[string]vapidToken // by audience (push service origin)
+}
+
+type vapidToken struct {
+	jwt     string
+	expires time.Time
+}
+
+// NewVAPID loads a key pair from its base64url raw private scalar, the
+// format web-push libraries print.
+func NewVAPID(privateKey, subject string) (*VAPID, error) {
+	raw, err := decode(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("vapid private key: %w", err)
+	}
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), raw)
+	if err != nil {
+		return nil, fmt.Errorf("vapid private key: %w", err)
+	}
+	return newVAPID(key, subject)
+}
+
+// GenerateVAPID creates a new key pair, e.g. for development. Its
+// PrivateKey must be kept for existing subscriptions to keep working.
+func GenerateVAPID(subject string) (*VAPID, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newVAPID(key, subject)
+}
+
+func newVAPID(key *ecdsa.PrivateKey, subject string) (*VAPID, error) {
+	pub, err := key.PublicKey.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("vapid public key: %w", err)
+	}
+	return &VAPID{key: key, public: encode(pub), subject: subject, tokens: map[string]vapidToken{}}, nil
+}
+
+// PublicKey is the base64url uncompressed public key.
+func (v *VAPID) PublicKey() string {
+	return v.public
+}
+
+// PrivateKey is the base64url raw private scalar NewVAPID accepts.
+func (v *VAPID) PrivateKey() string {
+	raw, _ := v.key.Bytes()
+	return encode(raw)
+}
+
+// Authorization returns the Authorization header for a request to the
+// endpoint. Tokens are signed per push service origin and reused until an
+// hour before they expire.
+func (v *VAPID) Authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid endpoint %q", endpoint)
+	}
+	aud := u.Scheme + "://" + u.Host
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	tok, ok := v.tokens[aud]
+	if !ok || now.After(tok.expires.Add(-time.Hour)) {
+		claims := jwt.MapClaims{"aud": aud, "exp": now.Add(tokenLifetime).Unix()}
+		if v.subject != "" {
+			claims["sub"] = v.subject
+		}
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(v.key)
+		if err != nil {
+			return "", fmt.Errorf("sign vapid token: %w", err)
+		}
+		tok = vapidToken{jwt: signed, expires: now.Add(tokenLifetime)}
+		v.tokens[aud] = tok
+	}
+	return "vapid t=" + tok.jwt + ", k=" + v.public, nil
+}
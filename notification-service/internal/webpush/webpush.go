@@ -0,0 +1,136 @@
+// Package webpush implements the sender side of browser Web Push: message
+// encryption for a PushSubscription (RFC 8291, aes128gcm content coding from
+// RFC 8188) and VAPID authorization of the request (RFC 8292). Delivery
+// itself is a plain HTTP POST to the subscription endpoint (RFC 8030).
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// recordSize is the aes128gcm record size; the whole message is sent as
+	// a single record.
+	recordSize = 4096
+	// headerSize is salt (16) + record size (4) + key ID length (1) + the
+	// sender's uncompressed P-256 public key (65).
+	headerSize = 16 + 4 + 1 + 65
+	// MaxPayload is the largest plaintext push services must accept: RFC
+	// 8291 section 4 caps the whole body, header included, at 4096 octets,
+	// and the record also carries the delimiter octet and the 16-octet GCM
+	// tag.
+	MaxPayload = 4096 - headerSize - 1 - 16
+)
+
+// Keys are the decoded "keys" of a PushSubscription.
+type Keys struct {
+	P256dh *ecdh.PublicKey // the user agent's ECDH public key
+	Auth   []byte          // 16-octet authentication secret
+}
+
+// ParseKeys decodes the base64url p256dh and auth values a browser reports
+// in PushSubscription.toJSON().
+func ParseKeys(p256dh, auth string) (Keys, error) {
+	pub, err := decode(p256dh)
+	if err != nil {
+		return Keys{}, fmt.Errorf("p256dh: %w", err)
+	}
+	key, err := ecdh.P256().NewPublicKey(pub)
+	if err != nil {
+		return Keys{}, fmt.Errorf("p256dh: %w", err)
+	}
+	secret, err := decode(auth)
+	if err != nil {
+		return Keys{}, fmt.Errorf("auth: %w", err)
+	}
+	if len(secret) != 16 {
+		return Keys{}, fmt.Errorf("auth: want 16 bytes, got %d", len(secret))
+	}
+	return Keys{P256dh: key, Auth: secret}, nil
+}
+
+// Encrypt encrypts payload for the subscription as an aes128gcm message body
+// (RFC 8291 section 4), using a fresh ephemeral key and salt.
+func Encrypt(payload []byte, keys Keys) ([]byte, error) {
+	if len(payload) > MaxPayload {
+		return nil, fmt.Errorf("payload of %d bytes exceeds %d", len(payload), MaxPayload)
+	}
+	ephemeral, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encrypt(payload, keys, ephemeral, salt)
+}
+
+func encrypt(payload []byte, keys Keys, ephemeral *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	shared, err := ephemeral.ECDH(keys.P256dh)
+	if err != nil {
+		return nil, err
+	}
+	uaPublic := keys.P256dh.Bytes()
+	asPublic := ephemeral.PublicKey().Bytes()
+
+	// combine the ECDH secret with the subscription's auth secret
+	keyInfo := "WebPush: info\x00" + string(uaPublic) + string(asPublic)
+	ikm, err := hkdf.Key(sha256.New, shared, keys.Auth, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	body := make([]byte, headerSize, headerSize+len(payload)+1+gcm.Overhead())
+	copy(body, salt)
+	binary.BigEndian.PutUint32(body[16:], recordSize)
+	body[20] = byte(len(asPublic))
+	copy(body[21:], asPublic)
+
+	// 0x02 marks the last (and only) record; no padding follows it
+	plaintext := append(append(make([]byte, 0, len(payload)+1), payload...), 0x02)
+	return gcm.Seal(body, nonce, plaintext, nil), nil
+}
+
+// decode accepts base64url with or without padding; browsers omit it.
+func decode(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("missing")
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
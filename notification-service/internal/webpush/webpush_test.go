@@ -0,0 +1,50 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"testing"
+)
+
+// TestEncryptRFC8291 checks the example of RFC 8291 section 5.
+func TestEncryptRFC8291(t *testing.T) {
+	asPrivate, _ := decode("yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw")
+	ephemeral, err := ecdh.P256().NewPrivateKey(asPrivate)
+	if err != nil {
+		t.Fatalf("application server key: %v", err)
+	}
+	keys, err := ParseKeys("BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4", "BTBZMqHH6r4Tts7J_aSIgg")
+	if err != nil {
+		t.Fatalf("ParseKeys: %v", err)
+	}
+	salt, _ := decode("DGv6ra1nlYgDCS1FRnbzlw")
+
+	body, err := encrypt([]byte("When I grow up, I want to be a watermelon"), keys, ephemeral, salt)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	want := "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	if got := encode(body); got != want {
+		t.Errorf("body = %s\nwant   %s", got, want)
+	}
+}
+
+func TestEncryptFitsPushServiceLimit(t *testing.T) {
+	private, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	keys := Keys{P256dh: private.PublicKey(), Auth: bytes.Repeat([]byte{1}, 16)}
+
+	body, err := Encrypt(bytes.Repeat([]byte("a"), MaxPayload), keys)
+	if err != nil {
+		t.Fatalf("Encrypt(MaxPayload): %v", err)
+	}
+	if len(body) != 4096 {
+		t.Errorf("body of the largest payload is %d bytes, want 4096", len(body))
+	}
+	if _, err := Encrypt(bytes.Repeat([]byte("a"), MaxPayload+1), keys); err == nil {
+		t.Error("Encrypt accepted a payload over MaxPayload")
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Ack records that a recipient confirmed they saw an event's alert. The
+// first acknowledgement on any channel counts for the whole event.
+type Ack struct {
+	EventID        string    `json:"event_id"`
+	Recipient      string    `json:"recipient"` // see Notification.RecipientKey
+	NotificationID string    `json:"notification_id"`
+	Channel        string    `json:"channel"`
+	Via            string    `json:"via"` // e.g. "link", "push action", "voice keypress", "sms reply"
+	AckedAt        time.Time `json:"acked_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "encoding/json"
+
+// GeoPoint is a WGS84 coordinate in decimal degrees.
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Circle is a centre point and radius in kilometres.
+type Circle struct {
+	Center   GeoPoint `json:"center"`
+	RadiusKm float64  `json:"radius_km"`
+}
+
+// Geocode is a named code identifying an area (e.g. a district code).
+type Geocode struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Area describes where an event applies: the union of its polygons (closed
+// rings of points), circles and GeoJSON geometry.
+type Area struct {
+	Description string          `json:"description,omitempty"`
+	Polygons    [][]GeoPoint    `json:"polygons,omitempty"`
+	Circles     []Circle        `json:"circles,omitempty"`
+	GeoJSON     json.RawMessage `json:"geojson,omitempty"`
+	Geocodes    []Geocode       `json:"geocodes,omitempty"`
+}
+
+// LocatedRecipient is a recipient ID with its registered position.
+type LocatedRecipient struct {
+	RecipientID string   `json:"recipient_id"`
+	Location    GeoPoint `json:"location"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// Attachment is a file sent with an email. An attachment with a ContentID
+// is sent inline and can be shown by the HTML body as <img src="cid:...">.
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"` // guessed from Filename when empty
+	ContentID   string `json:"content_id,omitempty"`
+	Data        []byte `json:"data"` // base64 in JSON
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Event defines the structure of the incoming JSON payload
+type Event struct {
+	ID         string   `json:"id"`
+	Type       string   `json:"type"`
+	Title      string   `json:"title"`
+	Message    string   `json:"message"`
+	Severity   string   `json:"severity"`
+	Channels   []string `json:"channels"`
+	Recipients []string `json:"recipients"`
+
+	// Optional alert details; populated from CAP <info> for CAP-ingested events.
+	Urgency     string     `json:"urgency,omitempty"`
+	Certainty   string     `json:"certainty,omitempty"`
+	Category    string     `json:"category,omitempty"`
+	Headline    string     `json:"headline,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Instruction string     `json:"instruction,omitempty"`
+	Sender      string     `json:"sender,omitempty"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Areas       []Area     `json:"areas,omitempty"`
+	Source      string     `json:"source,omitempty"` // "cap" when ingested from a CAP document
+
+	// Messages holds translations of Message keyed by language code, e.g.
+	// "hi", "ta" or "en-IN". FallbackLanguage is the language Message is
+	// written in; recipients whose language has no translation get Message.
+	Messages         map[string]string `json:"messages,omitempty"`
+	FallbackLanguage string            `json:"fallback_language,omitempty"`
+
+	// Template names a stored message template rendered per channel and
+	// recipient instead of sending Message verbatim; TemplateVersion 0 means
+	// the latest version.
+	Template        string `json:"template,omitempty"`
+	TemplateVersion int    `json:"template_version,omitempty"`
+
+	// Attachments go out with the email copies of the alert, e.g. a map.
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// RecipientChannels narrows Channels for individual recipients; stages
+	// such as the subscription filter fill it in.
+	RecipientChannels map[string][]string `json:"recipient_channels,omitempty"`
+
+	// MsgType is "alert" (default), "update" or "cancel". Updates and cancels
+	// reference earlier event IDs and amend them instead of being dispatched.
+	MsgType    string   `json:"msg_type,omitempty"`
+	References []string `json:"references,omitempty"`
+	// SendAllClear asks a cancel to notify recipients who already received
+	// the cancelled alert that it has been withdrawn. Webhook endpoints are
+	// notified either way.
+	SendAllClear bool `json:"send_all_clear,omitempty"`
+	// AllClear marks the follow-up such a cancel sends; webhook endpoints
+	// receive it as a "cancel" of the event it references. It skips the
+	// subscription filter, so the API refuses it from clients.
+	AllClear bool `json:"all_clear,omitempty"`
+}
+
+// MessageFor picks the text for a recipient's preferred language and reports
+// the language it is in. An exact translation wins, then one for the base
+// language ("en" for "en-IN"), then a regional one in the same base language
+// ("ta-IN" for "ta" or "ta-LK"), then Message in FallbackLanguage.
+func (e Event) MessageFor(lang string) (text, language string) {
+	if lang != "" {
+		base := baseLanguage(lang)
+		for _, match := range []func(code string) bool{
+			func(code string) bool { return strings.EqualFold(code, lang) },
+			func(code string) bool { return strings.EqualFold(code, base) },
+			func(code string) bool { return strings.EqualFold(baseLanguage(code), base) },
+		} {
+			// sorted, so the same regional translation is picked every time
+			for _, code := range slices.Sorted(maps.Keys(e.Messages)) {
+				if msg := e.Messages[code]; msg != "" && match(code) {
+					return msg, code
+				}
+			}
+		}
+	}
+	if e.Message == "" {
+		if msg, ok := e.Messages[e.FallbackLanguage]; ok {
+			return msg, e.FallbackLanguage
+		}
+	}
+	return e.Message, e.FallbackLanguage
+}
+
+// baseLanguage strips the region from a language code: "ta" for "ta-IN".
+func baseLanguage(code string) string {
+	base, _, _ := strings.Cut(code, "-")
+	return base
+}
+
+// EventRecord is the stored copy of an accepted event and its lifecycle
+// status ("queued", "processing", "dispatched").
+type EventRecord struct {
+	Event      Event     `json:"event"`
+	Status     string    `json:"status"`
+	AcceptedAt time.Time `json:"accepted_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// EventSummary rolls up the notifications of one event.
+type EventSummary struct {
+	Total       int                          `json:"total"`
+	SMSSegments int                          `json:"sms_segments"` // sum over SMS notifications
+	ByStatus    map[string]int               `json:"by_status"`
+	ByChannel   map[string]map[string]int    `json:"by_channel"`   // channel -> status -> count
+	Recipients  map[string]map[string]string `json:"by_recipient"` // recipient -> channel -> status
+}
@@ -0,0 +1,40 @@
+package models
+
+import "testing"
+
+func TestMessageFor(t *testing.T) {
+	event := Event{
+		Message:          "Move to higher ground",
+		FallbackLanguage: "en",
+		Messages: map[string]string{
+			"hi":    "ऊँचे स्थान पर जाएँ",
+			"ta-IN": "உயரமான இடத்துக்குச் செல்லுங்கள்",
+			"ta-LK": "உயர்ந்த இடத்துக்குச் செல்லவும்",
+		},
+	}
+	tests := []struct {
+		lang, wantLang string
+	}{
+		{"hi", "hi"},
+		{"hi-IN", "hi"},
+		{"ta-LK", "ta-LK"},
+		{"TA-lk", "ta-LK"},
+		{"ta", "ta-IN"},
+		{"ta-SG", "ta-IN"},
+		{"kn", "en"},
+		{"", "en"},
+	}
+	for _, tt := range tests {
+		text, lang := event.MessageFor(tt.lang)
+		if lang != tt.wantLang {
+			t.Errorf("MessageFor(%q) language = %q, want %q", tt.lang, lang, tt.wantLang)
+		}
+		want := event.Message
+		if tt.wantLang != "en" {
+			want = event.Messages[tt.wantLang]
+		}
+		if text != want {
+			t.Errorf("MessageFor(%q) = %q, want %q", tt.lang, text, want)
+		}
+	}
+}
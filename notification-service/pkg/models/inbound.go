@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Suppression stops a channel from delivering to one address, e.g. after
+// the recipient replied STOP to an SMS alert.
+type Suppression struct {
+	Channel   string    `json:"channel"`
+	Address   string    `json:"address"`
+	Reason    string    `json:"reason,omitempty"` // e.g. the keyword received
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CitizenReport is a free-text reply to an alert, kept for the hazard
+// pipeline. EventID and NotificationID link it to the alert it answered and
+// are empty when the sender had not been alerted.
+type CitizenReport struct {
+	ID                string    `json:"id"`
+	EventID           string    `json:"event_id,omitempty"`
+	NotificationID    string    `json:"notification_id,omitempty"`
+	RecipientID       string    `json:"recipient_id,omitempty"`
+	Channel           string    `json:"channel"`
+	From              string    `json:"from"`
+	Body              string    `json:"body"`
+	ProviderMessageID string    `json:"provider_message_id,omitempty"`
+	ReceivedAt        time.Time `json:"received_at"`
+}
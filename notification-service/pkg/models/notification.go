@@ -0,0 +1,104 @@
+package models
+
+import "time"
+
+type Notification struct {
+	ID            string     `json:"id"`
+	EventID       string     `json:"event_id"`
+	Recipient     string     `json:"recipient"`              // address the channel delivers to
+	RecipientID   string     `json:"recipient_id,omitempty"` // directory ID, empty for raw addresses
+	Channel       string     `json:"channel"`                // "sms", "email", "push", "webpush", "voice"
+	Severity      string     `json:"severity,omitempty"`     // of the event, for channel priority
+	Message       string     `json:"message"`                // plain-text body
+	Subject       string     `json:"subject,omitempty"`      // email subject or push title
+	HTMLBody      string     `json:"html_body,omitempty"`    // email HTML alternative
+	Language      string     `json:"language,omitempty"`     // language the message was sent in
+	SMSEncoding   string     `json:"sms_encoding,omitempty"` // "GSM-7" or "UCS-2"
+	SMSSegments   int        `json:"sms_segments,omitempty"` // billed segments, counted when created
+	Status        string     `json:"status"`                 // "pending", "queued", "sent", "success", "acknowledged", "failed", "failed_permanent", "expired", "cancelled", "scheduled", "skipped"
+	Error         string     `json:"error,omitempty"`
+	LastError     string     `json:"last_error,omitempty"` // of the latest counted attempt
+	Timestamp     time.Time  `json:"timestamp"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"` // not sent or retried after this
+	Attempts      int        `json:"attempts"`
+	MaxRetries    int        `json:"max_retries"`
+	APIStatusCode int        `json:"api_status_code,omitempty"` // HTTP code from provider
+	APIResponse   string     `json:"api_response,omitempty"`    // raw response body (short)
+	// ProviderMessageID is the provider's ID for the latest send, e.g. a
+	// Twilio message or call SID; delivery callbacks refer to it.
+	ProviderMessageID string `json:"provider_message_id,omitempty"`
+
+	Attachments []Attachment `json:"attachments,omitempty"` // email only
+
+	// AckURL is the signed acknowledgement link, filled in at send time for
+	// alerts that ask to be acknowledged. It is not stored.
+	AckURL string `json:"-"`
+}
+
+// Error classes of a failed send.
+const (
+	// ErrorTransient failures, e.g. timeouts or provider outages, are retried
+	// with exponential backoff, or after RetryAfter when the provider gave
+	// one. Unclassified failures are transient.
+	ErrorTransient = "transient"
+	// ErrorPermanent failures, e.g. an invalid or unsubscribed number, are
+	// not retried; the notification fails permanently at once.
+	ErrorPermanent = "permanent"
+	// ErrorRateLimited failures are retried once the provider's RetryAfter
+	// has passed, or with backoff when it gave none.
+	ErrorRateLimited = "rate_limited"
+	// ErrorAuth failures mean our credentials or configuration were refused
+	// or are missing; they are retried with backoff until fixed.
+	ErrorAuth = "auth"
+)
+
+type DispatchResult struct {
+	NotificationID string    `json:"notification_id"`
+	Success        bool      `json:"success"`
+	Error          string    `json:"error,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+
+	// Provider reply, recorded on the notification when set: an HTTP status
+	// or SMTP reply code and a short excerpt of the response.
+	APIStatusCode int    `json:"api_status_code,omitempty"`
+	APIResponse   string `json:"api_response,omitempty"`
+	// ErrorClass says what kind of failure this is, one of the Error*
+	// classes, and so whether and when the send is retried.
+	ErrorClass string `json:"error_class,omitempty"`
+	// ErrorCode is the provider's own code for the failure, e.g. Twilio's
+	// "21211" or APNs' "BadDeviceToken".
+	ErrorCode string `json:"error_code,omitempty"`
+	// RetryAfter is how long a rate-limiting or unavailable provider asked
+	// us to wait.
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+	// DeadAddress reports that the address no longer exists, e.g. an
+	// unregistered push token; the directory stops using it.
+	DeadAddress bool `json:"dead_address,omitempty"`
+	// ProviderMessageID is the provider's ID for the message sent, recorded
+	// on the notification.
+	ProviderMessageID string `json:"provider_message_id,omitempty"`
+	// Pending marks a successful hand-over whose outcome the provider
+	// reports later by callback, e.g. a placed voice call; the notification
+	// stays "queued" until then.
+	Pending bool `json:"pending,omitempty"`
+}
+
+// RecipientKey identifies the recipient across channels: the directory ID,
+// or the address itself for raw addresses, as in the event's recipients.
+func (n Notification) RecipientKey() string {
+	if n.RecipientID != "" {
+		return n.RecipientID
+	}
+	return n.Recipient
+}
+
+// IsPermanent reports whether retrying the send cannot help.
+func (r DispatchResult) IsPermanent() bool {
+	return r.ErrorClass == ErrorPermanent
+}
+
+// Expired reports whether the notification's delivery window has passed.
+func (n Notification) Expired(now time.Time) bool {
+	return n.ExpiresAt != nil && !now.Before(*n.ExpiresAt)
+}
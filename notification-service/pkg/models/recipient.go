@@ -0,0 +1,113 @@
+package models
+
+import "time"
+
+// Recipient is a contact profile in the recipient directory. Events may list
+// a recipient ID instead of a raw address; the dispatcher then picks the
+// right address for each channel from the profile.
+type Recipient struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name,omitempty"`
+	Phones     []string    `json:"phones,omitempty"` // E.164, primary first
+	Emails     []string    `json:"emails,omitempty"`
+	PushTokens []PushToken `json:"push_tokens,omitempty"`
+	// WebPush holds browser subscriptions; they are managed through their
+	// own API rather than by replacing the profile.
+	WebPush   []WebPushSubscription `json:"web_push,omitempty"`
+	Addresses map[string][]string   `json:"addresses,omitempty"` // handles for other channels, keyed by channel name
+	Language  string                `json:"language,omitempty"`  // preferred language, e.g. "hi" or "en-IN"
+	Location  *GeoPoint             `json:"location,omitempty"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+// PushToken is one registered device. Notifications address it as
+// "<platform>:<token>"; a bare token is sent through FCM.
+type PushToken struct {
+	Token    string `json:"token"`
+	Platform string `json:"platform,omitempty"` // "fcm" (default) or "apns"
+	// Dead is set when the push service reports the token unregistered.
+	Dead bool `json:"dead,omitempty"`
+}
+
+// Address is how notifications address the device.
+func (t PushToken) Address() string {
+	if t.Platform == "" {
+		return t.Token
+	}
+	return t.Platform + ":" + t.Token
+}
+
+// AddressesFor returns every address the recipient has for a channel.
+func (r Recipient) AddressesFor(channel string) []string {
+	switch channel {
+	case "sms":
+		return r.Phones
+	case "email":
+		return r.Emails
+	case "push":
+		tokens := make([]string, 0, len(r.PushTokens))
+		for _, t := range r.PushTokens {
+			if !t.Dead {
+				tokens = append(tokens, t.Address())
+			}
+		}
+		return tokens
+	case "webpush":
+		endpoints := make([]string, 0, len(r.WebPush))
+		for _, sub := range r.WebPush {
+			endpoints = append(endpoints, sub.Endpoint)
+		}
+		return endpoints
+	default:
+		return r.Addresses[channel]
+	}
+}
+
+// WebPushSubscription is a browser PushSubscription as serialised by its
+// toJSON() method. Notifications address it by endpoint.
+type WebPushSubscription struct {
+	Endpoint       string      `json:"endpoint"`
+	ExpirationTime *int64      `json:"expirationTime,omitempty"` // ms since epoch, as browsers report it
+	Keys           WebPushKeys `json:"keys"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// WebPushKeys are the base64url subscription keys used to encrypt messages.
+type WebPushKeys struct {
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// FindWebPush returns the subscription with the endpoint, if any.
+func (r Recipient) FindWebPush(endpoint string) (WebPushSubscription, bool) {
+	for _, sub := range r.WebPush {
+		if sub.Endpoint == endpoint {
+			return sub, true
+		}
+	}
+	return WebPushSubscription{}, false
+}
+
+// PutWebPush adds the subscription or replaces the one with its endpoint.
+func (r *Recipient) PutWebPush(sub WebPushSubscription) {
+	for i := range r.WebPush {
+		if r.WebPush[i].Endpoint == sub.Endpoint {
+			r.WebPush[i] = sub
+			return
+		}
+	}
+	r.WebPush = append(r.WebPush, sub)
+}
+
+// RemoveWebPush drops the subscription with the endpoint and reports
+// whether there was one.
+func (r *Recipient) RemoveWebPush(endpoint string) bool {
+	for i := range r.WebPush {
+		if r.WebPush[i].Endpoint == endpoint {
+			r.WebPush = append(r.WebPush[:i:i], r.WebPush[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
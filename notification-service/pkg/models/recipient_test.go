@@ -0,0 +1,41 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAddressesFor(t *testing.T) {
+	r := Recipient{
+		ID:     "asha",
+		Phones: []string{"+911111111111", "+912222222222"},
+		Emails: []string{"asha@example.com"},
+		PushTokens: []PushToken{
+			{Token: "fcm-token"},
+			{Token: "apns-token", Platform: "apns"},
+			{Token: "old-token", Dead: true},
+		},
+		WebPush:   []WebPushSubscription{{Endpoint: "https://push.example/sub/1"}},
+		Addresses: map[string][]string{"telegram": {"123456"}},
+	}
+	tests := []struct {
+		channel string
+		want    []string
+	}{
+		{"sms", []string{"+911111111111", "+912222222222"}},
+		{"email", []string{"asha@example.com"}},
+		{"push", []string{"fcm-token", "apns:apns-token"}},
+		{"webpush", []string{"https://push.example/sub/1"}},
+		{"telegram", []string{"123456"}},
+		{"whatsapp", nil},
+	}
+	for _, tt := range tests {
+		if got := r.AddressesFor(tt.channel); !slices.Equal(got, tt.want) {
+			t.Errorf("AddressesFor(%s) = %v, want %v", tt.channel, got, tt.want)
+		}
+	}
+
+	if got := (Recipient{}).AddressesFor("push"); len(got) != 0 {
+		t.Errorf("AddressesFor(push) without tokens = %v, want none", got)
+	}
+}
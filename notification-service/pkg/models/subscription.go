@@ -0,0 +1,45 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Subscription opts a directory recipient in to one hazard type at or above
+// a minimum severity, optionally on a subset of channels. A recipient with no
+// subscriptions receives every event, as before subscriptions existed.
+type Subscription struct {
+	ID          string    `json:"id"`
+	RecipientID string    `json:"recipient_id"`
+	HazardType  string    `json:"hazard_type"`            // Event.Type, or "*" for every type
+	MinSeverity string    `json:"min_severity,omitempty"` // CAP severity; empty matches any
+	Channels    []string  `json:"channels,omitempty"`     // empty means every channel of the event
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// severityRank orders the CAP severities; anything else ranks as unknown.
+var severityRank = map[string]int{
+	"unknown":  0,
+	"minor":    1,
+	"moderate": 2,
+	"severe":   3,
+	"extreme":  4,
+}
+
+// ValidSeverity reports whether s is one of the lower-case CAP severities.
+func ValidSeverity(s string) bool {
+	_, ok := severityRank[s]
+	return ok
+}
+
+// SeverityAtLeast reports whether severity is at or above min, ignoring case.
+func SeverityAtLeast(severity, min string) bool {
+	return min == "" || severityRank[strings.ToLower(severity)] >= severityRank[strings.ToLower(min)]
+}
+
+// Matches reports whether the subscription covers an event of the given
+// hazard type and severity.
+func (s Subscription) Matches(hazardType, severity string) bool {
+	return (s.HazardType == "*" || s.HazardType == hazardType) && SeverityAtLeast(severity, s.MinSeverity)
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Template is one immutable version of a named message template. Bodies use
+// Go template syntax with the event, the recipient profile and the address
+// as variables, e.g. {{.Event.Title}} or {{.Recipient.Name}}.
+type Template struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+
+	SMS          string `json:"sms,omitempty"`
+	EmailSubject string `json:"email_subject,omitempty"`
+	EmailText    string `json:"email_text,omitempty"`
+	EmailHTML    string `json:"email_html,omitempty"` // rendered with HTML escaping
+	PushTitle    string `json:"push_title,omitempty"`
+	PushBody     string `json:"push_body,omitempty"`
+	// Default is the body for any channel without its own part.
+	Default string `json:"default,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// WebhookEndpoint is a partner system that receives alerts as signed JSON
+// POSTs. Events sent on the "webhook" channel go to every active endpoint
+// whose filter matches.
+type WebhookEndpoint struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name,omitempty"`
+	URL         string   `json:"url"`
+	HazardTypes []string `json:"hazard_types,omitempty"` // Event.Type values; empty matches every type
+	MinSeverity string   `json:"min_severity,omitempty"` // CAP severity; empty matches any
+	Disabled    bool     `json:"disabled,omitempty"`
+
+	// Secret signs request bodies. After a rotation the previous secret
+	// also signs until PreviousExpiresAt, so receivers can switch over.
+	Secret            string     `json:"secret,omitempty"`
+	PreviousSecret    string     `json:"previous_secret,omitempty"`
+	PreviousExpiresAt *time.Time `json:"previous_expires_at,omitempty"`
+	SecretRotatedAt   time.Time  `json:"secret_rotated_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Matches reports whether the endpoint wants an event of the given hazard
+// type and severity.
+func (w WebhookEndpoint) Matches(hazardType, severity string) bool {
+	if w.Disabled {
+		return false
+	}
+	if len(w.HazardTypes) > 0 && !slices.Contains(w.HazardTypes, hazardType) {
+		return false
+	}
+	return SeverityAtLeast(severity, w.MinSeverity)
+}
+
+// SigningSecrets returns the secrets requests are signed with at now,
+// current first.
+func (w WebhookEndpoint) SigningSecrets(now time.Time) []string {
+	secrets := []string{w.Secret}
+	if w.PreviousSecret != "" && w.PreviousExpiresAt != nil && now.Before(*w.PreviousExpiresAt) {
+		secrets = append(secrets, w.PreviousSecret)
+	}
+	return secrets
+}
+
+// Redacted is the endpoint without its secrets, as listed by the API.
+func (w WebhookEndpoint) Redacted() WebhookEndpoint {
+	w.Secret, w.PreviousSecret = "", ""
+	return w
+}